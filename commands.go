@@ -3,18 +3,42 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 
+	"go.goms.io/aks/AKSFlexNode/pkg/adminapi"
 	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/arc"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
 	"go.goms.io/aks/AKSFlexNode/pkg/logger"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/preflight"
+	"go.goms.io/aks/AKSFlexNode/pkg/reload"
+	"go.goms.io/aks/AKSFlexNode/pkg/remediation"
+	"go.goms.io/aks/AKSFlexNode/pkg/report"
+	"go.goms.io/aks/AKSFlexNode/pkg/sdnotify"
+	"go.goms.io/aks/AKSFlexNode/pkg/selfupdate"
 	"go.goms.io/aks/AKSFlexNode/pkg/status"
+	"go.goms.io/aks/AKSFlexNode/pkg/supportbundle"
+	"go.goms.io/aks/AKSFlexNode/pkg/upgrade"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
 // Version information variables (set at build time)
@@ -24,90 +48,1571 @@ var (
 	BuildTime = "unknown"
 )
 
+// daemonReportsToKeep is the number of auto-bootstrap reports kept in the state directory
+const daemonReportsToKeep = 20
+
+// Exit codes of the bootstrap command; 0 is success
+const (
+	bootstrapExitFailed  = 1
+	bootstrapExitTimeout = 2
+)
+
+const (
+	// defaultWaitReadyTimeout bounds waiting for the node to become Ready after bootstrap
+	defaultWaitReadyTimeout = 10 * time.Minute
+	// nodeReadyPollInterval is how often node readiness is checked while waiting
+	nodeReadyPollInterval = 5 * time.Second
+)
+
+// monitoringStatus is the systemd status of a daemon between its checks
+const monitoringStatus = "Monitoring node"
+
+// adminAPITimeout bounds CLI requests to the daemon's admin API before falling back to other sources
+const adminAPITimeout = 2 * time.Second
+
+// azureResourcesFileName is the file in agent.stateDir listing the Azure resources a reset left behind
+const azureResourcesFileName = "azure-resources.json"
+
+// exitCodeError makes the process exit with a specific code; its message, if any, is printed to stderr
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit code %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+// reportOptions holds where and in which format an execution report is written
+type reportOptions struct {
+	file   string
+	format string
+}
+
+// addReportFlags registers the execution report flags on cmd
+func addReportFlags(cmd *cobra.Command, opts *reportOptions) {
+	cmd.Flags().StringVar(&opts.file, "report-file", "", "Write a machine-readable report of the run to this file")
+	cmd.Flags().StringVar(&opts.format, "report-format", report.FormatJSON, "Report format: json or junit")
+}
+
 // NewAgentCommand creates a new agent command
 func NewAgentCommand() *cobra.Command {
+	var (
+		resumeOpts  bootstrapper.ResumeOptions
+		rollback    bool
+		reportOpts  reportOptions
+		noBootstrap bool
+	)
+
 	cmd := &cobra.Command{
 		Use:   "agent",
 		Short: "Start AKS node agent with Arc connection",
-		Long:  "Initialize and run the AKS node agent daemon with automatic status tracking and self-recovery",
+		Long: "Initialize and run the AKS node agent daemon with automatic status tracking and self-recovery. " +
+			"A restart of a node that completed its bootstrap with the same configuration and was last reported healthy " +
+			"resumes the daemon without bootstrapping again. " +
+			"With --no-bootstrap the initial bootstrap is always skipped and the agent only runs the daemon",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if noBootstrap {
+				return runDaemon(cmd.Context())
+			}
+			return runAgent(cmd.Context(), resumeOpts, rollback, reportOpts)
+		},
+	}
+
+	addBootstrapFlags(cmd, &resumeOpts, &rollback, &reportOpts)
+	cmd.Flags().BoolVar(&noBootstrap, "no-bootstrap", false, "Skip the initial bootstrap and only run the daemon, same as the daemon command")
+
+	return cmd
+}
+
+// NewBootstrapCommand creates a new bootstrap command
+func NewBootstrapCommand() *cobra.Command {
+	var (
+		resumeOpts  bootstrapper.ResumeOptions
+		rollback    bool
+		reportOpts  reportOptions
+		waitReady   bool
+		waitTimeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "bootstrap",
+		Short: "Bootstrap the node once and exit",
+		Long: fmt.Sprintf(`Run the bootstrap sequence once and exit, optionally waiting until the Node object reports Ready.
+Intended for cloud-init and pipelines; ongoing monitoring is left to the daemon command.
+
+Exit codes: 0 bootstrap succeeded (and the node is Ready with --wait-ready), %d bootstrap failed,
+%d a step or the overall deadline timed out, or the node did not become Ready within --wait-timeout.`,
+			bootstrapExitFailed, bootstrapExitTimeout),
+		SilenceUsage:  true,
+		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runAgent(cmd.Context())
+			return runBootstrap(cmd.Context(), resumeOpts, rollback, reportOpts, waitReady, waitTimeout)
 		},
 	}
 
+	addBootstrapFlags(cmd, &resumeOpts, &rollback, &reportOpts)
+	cmd.Flags().BoolVar(&waitReady, "wait-ready", false, "Wait until the Node object reports Ready after bootstrap")
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", defaultWaitReadyTimeout, "Maximum time to wait for the node to become Ready")
+
 	return cmd
 }
 
+// NewDaemonCommand creates a new daemon command
+func NewDaemonCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the agent daemon without bootstrapping first",
+		Long: "Run the periodic status collection and self-recovery loop of the agent without an initial bootstrap. " +
+			"Use together with the bootstrap command, which performs the initial bootstrap and exits",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDaemon(cmd.Context())
+		},
+	}
+}
+
+// addBootstrapFlags adds the flags controlling a bootstrap run to cmd
+func addBootstrapFlags(cmd *cobra.Command, resumeOpts *bootstrapper.ResumeOptions, rollback *bool, reportOpts *reportOptions) {
+	cmd.Flags().StringVar(&resumeOpts.RestartFrom, "restart-from", "", "Re-run bootstrap from the named step and everything that depends on it")
+	cmd.Flags().BoolVar(&resumeOpts.Force, "force", false, "Ignore the bootstrap journal and re-run every step")
+	cmd.Flags().BoolVar(rollback, "rollback-on-failure", false, "Undo the steps this run changed if bootstrap fails")
+	addReportFlags(cmd, reportOpts)
+}
+
 // NewUnbootstrapCommand creates a new unbootstrap command
 func NewUnbootstrapCommand() *cobra.Command {
+	var reportOpts reportOptions
+
 	cmd := &cobra.Command{
 		Use:   "unbootstrap",
 		Short: "Remove AKS node configuration and Arc connection",
 		Long:  "Clean up and remove all AKS node components and Arc registration from this machine",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUnbootstrap(cmd.Context())
+			return runUnbootstrap(cmd.Context(), reportOpts)
+		},
+	}
+
+	addReportFlags(cmd, &reportOpts)
+
+	return cmd
+}
+
+// NewResetCommand creates a new reset command
+func NewResetCommand() *cobra.Command {
+	var reportOpts reportOptions
+	var resourcesFile string
+
+	cmd := &cobra.Command{
+		Use:   "reset",
+		Short: "Remove AKS node components locally without calling Azure",
+		Long: `Stop and remove the services, binaries, configuration and CNI state installed by bootstrap and
+disconnect the Arc agent with azcmagent disconnect --force-local-only. Unlike unbootstrap, reset never
+authenticates to Azure, so it works on nodes that lost connectivity or whose subscription is gone.
+The Azure resources of this machine (Arc machine, role assignments) are left in place and written
+to a file together with the commands that remove them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReset(cmd.Context(), cmd.OutOrStdout(), reportOpts, resourcesFile)
+		},
+	}
+
+	addReportFlags(cmd, &reportOpts)
+	cmd.Flags().StringVar(&resourcesFile, "azure-resources-file", "",
+		"Write the Azure resources left for manual cleanup to this file (default <agent.stateDir>/"+azureResourcesFileName+")")
+
+	return cmd
+}
+
+// NewPlanCommand creates a new plan command
+func NewPlanCommand() *cobra.Command {
+	var (
+		resumeOpts  bootstrapper.ResumeOptions
+		output      string
+		unbootstrap bool
+		reset       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Show the changes bootstrap would make without applying them",
+		Long: "Compute the files, downloads, systemd units, packages and Azure resources that bootstrap " +
+			"(or unbootstrap with --unbootstrap, reset with --reset) would create, update or remove on this machine, without changing anything",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			operation := "bootstrap"
+			switch {
+			case unbootstrap && reset:
+				return fmt.Errorf("--unbootstrap and --reset cannot be combined")
+			case unbootstrap:
+				operation = "unbootstrap"
+			case reset:
+				operation = "reset"
+			}
+			return runPlan(cmd.Context(), cmd.OutOrStdout(), output, operation, resumeOpts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&unbootstrap, "unbootstrap", false, "Plan unbootstrap instead of bootstrap")
+	cmd.Flags().BoolVar(&reset, "reset", false, "Plan a local reset instead of bootstrap")
+	cmd.Flags().StringVar(&resumeOpts.RestartFrom, "restart-from", "", "Plan a bootstrap restarted from the named step")
+	cmd.Flags().BoolVar(&resumeOpts.Force, "force", false, "Plan a bootstrap that ignores the journal and re-runs every step")
+
+	return cmd
+}
+
+// NewComponentCommand creates the component command and its list, install, uninstall and status subcommands
+func NewComponentCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "component",
+		Short: "Install, uninstall or inspect individual node components",
+		// Built before the configuration is loaded, so configured artifacts are described rather than listed
+		Long: "Operate on a single node component (" + strings.Join(bootstrapper.ComponentNames(), ", ") + ") " +
+			"without running the full bootstrap or unbootstrap sequence. Every artifact in the configuration " +
+			"is a component as well, named artifact-<name>.",
+	}
+
+	cmd.AddCommand(newComponentListCommand())
+	cmd.AddCommand(newComponentInstallCommand())
+	cmd.AddCommand(newComponentUninstallCommand())
+	cmd.AddCommand(newComponentStatusCommand())
+
+	return cmd
+}
+
+// newComponentListCommand creates the component list subcommand
+func newComponentListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the available components",
+		Long:  "List the node components in install order together with the components each one depends on",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runComponentList(cmd.OutOrStdout())
+		},
+	}
+}
+
+// newComponentInstallCommand creates the component install subcommand
+func newComponentInstallCommand() *cobra.Command {
+	var (
+		skipPrerequisites bool
+		reportOpts        reportOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "install <name>",
+		Short: "Install or reinstall a single component",
+		Long: "Install the named component, re-running it even if it is already installed. " +
+			"Components it depends on are installed first unless they are already complete.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runComponentInstall(cmd.Context(), args[0], !skipPrerequisites, reportOpts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&skipPrerequisites, "skip-prerequisites", false, "Install only the named component, not the components it depends on")
+	addReportFlags(cmd, &reportOpts)
+
+	return cmd
+}
+
+// newComponentUninstallCommand creates the component uninstall subcommand
+func newComponentUninstallCommand() *cobra.Command {
+	var reportOpts reportOptions
+
+	cmd := &cobra.Command{
+		Use:   "uninstall <name>",
+		Short: "Uninstall a single component",
+		Long:  "Remove the named component from this machine; components that depend on it are left in place",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runComponentUninstall(cmd.Context(), args[0], reportOpts)
+		},
+	}
+
+	addReportFlags(cmd, &reportOpts)
+
+	return cmd
+}
+
+// newComponentStatusCommand creates the component status subcommand
+func newComponentStatusCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "status <name>",
+		Short: "Show whether a component is installed",
+		Long:  "Report whether the named component and the components it depends on are installed on this machine",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runComponentStatus(cmd.Context(), cmd.OutOrStdout(), args[0], output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json")
+
+	return cmd
+}
+
+// NewStatusCommand creates a new status command
+func NewStatusCommand() *cobra.Command {
+	var output string
+	var live bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show node status and health",
+		Long: `Show the node status held by the running daemon, read from its admin API or status file, or collect it
+live when no daemon status exists.
+The exit code reflects node health: 0 healthy, 2 degraded, 3 needs bootstrap, 4 needs attention,
+1 if status could not be determined.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runStatus(cmd.Context(), cmd.OutOrStdout(), output, live)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table, json or yaml")
+	cmd.Flags().BoolVar(&live, "live", false, "Collect status live instead of reading the daemon's status file")
+
+	return cmd
+}
+
+// NewDoctorCommand creates a new doctor command
+func NewDoctorCommand() *cobra.Command {
+	var output string
+	var checks []string
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the host for common bootstrap problems",
+		Long: `Run host preflight checks such as swap, cgroup version, kernel modules, ports, disk space,
+clock synchronization and conflicting packages, and print a remediation hint for each problem.
+Exits non-zero when any check fails. Checks can be skipped with preflight.skipChecks in the config.`,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runDoctor(cmd.Context(), cmd.OutOrStdout(), output, checks)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().StringSliceVar(&checks, "check", nil, "Run only the named checks ("+strings.Join(preflight.CheckNames(), ", ")+")")
+
+	return cmd
+}
+
+// NewUpgradeCommand creates a new upgrade command
+func NewUpgradeCommand() *cobra.Command {
+	var opts upgrade.Options
+
+	cmd := &cobra.Command{
+		Use:   "upgrade",
+		Short: "Upgrade node components to the configured versions",
+		Long: `Compare the installed kubelet, containerd and runc versions with the configuration and upgrade the ones
+that differ. The node is cordoned and drained, binaries are swapped, containerd and kubelet are restarted,
+and the node is uncordoned once it is Ready. If it does not become Ready, the previous binaries are restored.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUpgrade(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Only report version differences")
+	cmd.Flags().BoolVar(&opts.SkipDrain, "skip-drain", false, "Swap binaries without cordoning and draining the node")
+	cmd.Flags().DurationVar(&opts.DrainTimeout, "drain-timeout", upgrade.DefaultDrainTimeout, "Maximum time to drain the node")
+	cmd.Flags().DurationVar(&opts.ReadyTimeout, "ready-timeout", upgrade.DefaultReadyTimeout, "Maximum time to wait for the node to become Ready")
+
+	return cmd
+}
+
+// NewSelfUpdateCommand creates a new self-update command
+func NewSelfUpdateCommand() *cobra.Command {
+	var opts selfupdate.Options
+
+	cmd := &cobra.Command{
+		Use:   "self-update",
+		Short: "Update the agent binary to the release in the manifest",
+		Long: `Fetch the release manifest from selfUpdate.manifestUrl and install the release if its version differs from
+the running agent. The binary's SHA-256, and its signature when selfUpdate.publicKey is set, are verified before it
+is staged and swapped in atomically. The agent unit is then restarted, and the previous binary is restored if the
+new agent does not report healthy within the health timeout.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSelfUpdate(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.CheckOnly, "check", false, "Only report whether an update is available")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Install the release even if it is the running version")
+	cmd.Flags().BoolVar(&opts.NoRestart, "no-restart", false, "Swap the binary without restarting the agent unit")
+	cmd.Flags().DurationVar(&opts.HealthTimeout, "health-timeout", 0, "Maximum time for the restarted agent to report healthy (default selfUpdate.healthTimeout)")
+
+	return cmd
+}
+
+// NewCollectLogsCommand creates a new collect-logs command
+func NewCollectLogsCommand() *cobra.Command {
+	var outputDir string
+	var maxSizeMiB int64
+	var since time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "collect-logs",
+		Short: "Collect logs and node state into a support bundle",
+		Long: `Gather agent logs, the journal of kubelet, containerd, node-problem-detector and the agent, Arc agent
+information, rendered component configuration, sysctl values, network state, the status file and component
+versions into a timestamped tarball. Tokens, client secrets and kubeconfig credentials are redacted.
+The configuration is optional; a configuration that fails to load does not prevent collection.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := supportbundle.Options{
+				OutputDir: outputDir,
+				MaxBytes:  maxSizeMiB << 20,
+				Since:     since,
+			}
+			return runCollectLogs(cmd.Context(), cmd.OutOrStdout(), opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "d", ".", "Directory the bundle is written to")
+	cmd.Flags().Int64Var(&maxSizeMiB, "max-size", supportbundle.DefaultMaxBytes>>20, "Maximum uncompressed size of the bundle content in MiB; larger logs are truncated")
+	cmd.Flags().DurationVar(&since, "since", supportbundle.DefaultSince, "How far back journal entries are collected")
+
+	return cmd
+}
+
+// NewConfigCommand creates the config command with its validate and show subcommands
+func NewConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Validate and inspect the agent configuration",
+		Long:  "Validate the configuration file or show the configuration the agent runs with",
+	}
+
+	cmd.AddCommand(newConfigValidateCommand())
+	cmd.AddCommand(newConfigShowCommand())
+
+	return cmd
+}
+
+// newConfigValidateCommand creates the config validate subcommand
+func newConfigValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "validate",
+		Short:         "Report every problem of the configuration",
+		Long:          "Load the configuration file with environment overrides and defaults and report all validation errors at once",
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigValidate(cmd.OutOrStdout())
+		},
+	}
+}
+
+// newConfigShowCommand creates the config show subcommand
+func newConfigShowCommand() *cobra.Command {
+	var output string
+	var effective bool
+
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Show the configuration and where each value comes from",
+		Long: `Show the configuration values set in the config file or by AKS_NODE_CONTROLLER_ environment variables.
+With --effective, show the complete configuration the agent runs with, including defaults and values derived
+from the target cluster resource ID. Each value is annotated with its source: file, env, default or derived.
+Secrets are redacted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runConfigShow(cmd.OutOrStdout(), output, effective)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: text or json")
+	cmd.Flags().BoolVar(&effective, "effective", false, "Include default and derived values")
+
+	return cmd
+}
+
+// NewAdminCommand creates the admin command whose subcommands talk to the running daemon's admin API
+func NewAdminCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Control the running daemon",
+		Long: `Inspect and control the running daemon through its admin API on a Unix socket.
+Access requires membership of the socket's group, normally the aks-flex-node group.`,
+	}
+
+	cmd.AddCommand(newAdminStepsCommand())
+	cmd.AddCommand(newAdminReconcileCommand())
+	cmd.AddCommand(newAdminRemediationCommand("pause", "Pause automatic remediation", true))
+	cmd.AddCommand(newAdminRemediationCommand("resume", "Resume automatic remediation", false))
+	cmd.AddCommand(newAdminLogLevelCommand())
+
+	return cmd
+}
+
+// newAdminStepsCommand creates the admin steps subcommand
+func newAdminStepsCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:          "steps",
+		Short:        "Show the daemon's last bootstrap run",
+		Long:         "Show the step results of the most recent bootstrap run of the running daemon",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminSteps(cmd.Context(), cmd.OutOrStdout(), output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+
+	return cmd
+}
+
+// newAdminReconcileCommand creates the admin reconcile subcommand
+func newAdminReconcileCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "reconcile",
+		Short:        "Check and repair the node now",
+		Long:         "Make the running daemon check the node and bootstrap it if needed without waiting for the next periodic check",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminReconcile(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+}
+
+// newAdminRemediationCommand creates the admin pause or resume subcommand
+func newAdminRemediationCommand(use, short string, paused bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long: short + `. While paused the daemon keeps collecting status but skips its periodic bootstrap and
+self-update checks; admin reconcile still runs. The setting is not persisted across daemon restarts.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminSetPaused(cmd.Context(), cmd.OutOrStdout(), paused)
+		},
+	}
+}
+
+// newAdminLogLevelCommand creates the admin log-level subcommand
+func newAdminLogLevelCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:          "log-level LEVEL",
+		Short:        "Change the daemon's log level",
+		Long:         "Change the log level of the running daemon (debug, info, warning, error) until it restarts",
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAdminLogLevel(cmd.Context(), cmd.OutOrStdout(), args[0])
+		},
+	}
+}
+
+// NewRemediationCommand creates a new remediation command
+func NewRemediationCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remediation",
+		Short: "Inspect and reset the remediation limits",
+		Long: `Inspect and reset the circuit breaker that limits how often the daemon remediates the node.
+When the node keeps breaking the breaker trips, automatic remediation stops and the node reports
+needs-attention until the breaker is reset.`,
+	}
+
+	cmd.AddCommand(newRemediationStatusCommand())
+	cmd.AddCommand(newRemediationResetCommand())
+
+	return cmd
+}
+
+// newRemediationStatusCommand creates the remediation status subcommand
+func newRemediationStatusCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:          "status",
+		Short:        "Show the remediation breaker state",
+		Long:         "Show whether automatic remediation is stopped and the attempts counted against the configured limits",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemediationStatus(cmd.OutOrStdout(), output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: table or json")
+
+	return cmd
+}
+
+// newRemediationResetCommand creates the remediation reset subcommand
+func newRemediationResetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reset",
+		Short: "Resume automatic remediation after manual repair",
+		Long: `Forget the counted remediation attempts and close a tripped breaker so that the daemon remediates
+the node again. A running daemon is asked to check the node right away.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRemediationReset(cmd.Context(), cmd.OutOrStdout())
+		},
+	}
+}
+
+// NewVersionCommand creates a new version command
+func NewVersionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "version",
+		Short: "Show version information",
+		Long:  "Display version, build commit, and build time information",
+		Run: func(cmd *cobra.Command, args []string) {
+			runVersion()
 		},
 	}
-
-	return cmd
+
+	return cmd
+}
+
+// runAgent executes the bootstrap process and then runs as daemon
+func runAgent(ctx context.Context, resumeOpts bootstrapper.ResumeOptions, rollback bool, reportOpts reportOptions) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if err := report.ValidateFormat(reportOpts.format); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	// A restart of a bootstrapped node, e.g. by self-update, resumes the daemon: bootstrapping again
+	// would stop kubelet in the ServicesDisabled step and hold systemd's readiness for the whole run
+	if canResumeDaemon(ctx, cfg, resumeOpts) {
+		logger.Info("Node is already bootstrapped with this configuration and healthy, resuming daemon mode...")
+		return runDaemonLoop(ctx, cfg, nil)
+	}
+
+	// systemd waits for readiness until the bootstrap completes; the watchdog is pinged while steps progress
+	notifySystemd(ctx, sdnotify.Status("Bootstrapping node"))
+	bootstrapCtx, endLease := startWatchdogLease(ctx)
+	result, err := bootstrapNode(bootstrapCtx, cfg, resumeOpts, rollback, reportOpts)
+	endLease()
+	if err != nil {
+		notifySystemd(ctx, sdnotify.Status("Bootstrap failed: %v", err))
+		return err
+	}
+
+	// After successful bootstrap, transition to daemon mode
+	logger.Info("Bootstrap completed successfully, transitioning to daemon mode...")
+	return runDaemonLoop(ctx, cfg, result)
+}
+
+// needsBootstrap reports whether the status file of the previous daemon session asks for a bootstrap.
+// Overridable for tests.
+var needsBootstrap = func(ctx context.Context, cfg *config.Config) bool {
+	return status.NewCollector(cfg, logger.GetLoggerFromContext(ctx), Version).NeedsBootstrap(ctx)
+}
+
+// canResumeDaemon reports whether the agent can skip its initial bootstrap: no resume option asks for
+// a run, the journal records a completed bootstrap with the current configuration and the previous
+// daemon session found the node healthy
+func canResumeDaemon(ctx context.Context, cfg *config.Config, resumeOpts bootstrapper.ResumeOptions) bool {
+	if resumeOpts.Force || resumeOpts.RestartFrom != "" {
+		return false
+	}
+
+	journal, err := bootstrapper.LoadJournalState(bootstrapper.GetJournalPath(cfg.Agent.StateDir))
+	if err != nil {
+		logger.GetLoggerFromContext(ctx).Warnf("Failed to read bootstrap journal: %v", err)
+		return false
+	}
+	if journal == nil || !journal.Completed || journal.ConfigHash != cfg.Hash() {
+		return false
+	}
+	return !needsBootstrap(ctx, cfg)
+}
+
+// runBootstrap bootstraps the node once, optionally waits for it to become Ready, and exits
+// with a code telling success, failure and timeout apart
+func runBootstrap(ctx context.Context, resumeOpts bootstrapper.ResumeOptions, rollback bool, reportOpts reportOptions,
+	waitReady bool, waitTimeout time.Duration) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if err := report.ValidateFormat(reportOpts.format); err != nil {
+		return &exitCodeError{code: bootstrapExitFailed, err: err}
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return &exitCodeError{code: bootstrapExitFailed, err: fmt.Errorf("failed to load config from %s: %w", configPath, err)}
+	}
+
+	result, err := bootstrapNode(ctx, cfg, resumeOpts, rollback, reportOpts)
+	if err != nil {
+		return &exitCodeError{code: bootstrapExitCode(result), err: err}
+	}
+
+	if waitReady {
+		collector := status.NewCollector(cfg, logger, Version)
+		if err := collector.WaitForNodeReady(ctx, waitTimeout, nodeReadyPollInterval); err != nil {
+			code := bootstrapExitFailed
+			if errors.Is(err, context.DeadlineExceeded) {
+				code = bootstrapExitTimeout
+			}
+			return &exitCodeError{code: code, err: err}
+		}
+	}
+	return nil
+}
+
+// bootstrapNode runs the bootstrap sequence, writes the requested report and returns an error
+// if the run failed
+func bootstrapNode(ctx context.Context, cfg *config.Config, resumeOpts bootstrapper.ResumeOptions, rollback bool,
+	reportOpts reportOptions) (*bootstrapper.ExecutionResult, error) {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	bootstrapExecutor.SetResumeOptions(resumeOpts)
+	bootstrapExecutor.SetRollbackOnFailure(rollback)
+	reportStepsToSystemd(ctx, bootstrapExecutor)
+	result, err := bootstrapExecutor.Bootstrap(ctx)
+	writeReport(cfg, result, "bootstrap", reportOpts, logger)
+	recordRunMetrics(cfg, "bootstrap", result, logger)
+	if err != nil {
+		return result, err
+	}
+
+	// Handle and log the bootstrap result
+	return result, handleExecutionResult(result, "bootstrap", logger)
+}
+
+// bootstrapExitCode returns the exit code of a failed bootstrap run: bootstrapExitTimeout when a step
+// or the overall deadline timed out, bootstrapExitFailed otherwise
+func bootstrapExitCode(result *bootstrapper.ExecutionResult) int {
+	if result != nil {
+		for _, step := range result.StepResults {
+			if step.TimedOut {
+				return bootstrapExitTimeout
+			}
+		}
+	}
+	return bootstrapExitFailed
+}
+
+// runDaemon runs the daemon loop without bootstrapping first
+func runDaemon(ctx context.Context) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	logger.Info("Starting daemon mode without bootstrap...")
+	return runDaemonLoop(ctx, cfg, nil)
+}
+
+// runUnbootstrap executes the unbootstrap process
+func runUnbootstrap(ctx context.Context, reportOpts reportOptions) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if err := report.ValidateFormat(reportOpts.format); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	result, err := bootstrapExecutor.Unbootstrap(ctx)
+	writeReport(cfg, result, "unbootstrap", reportOpts, logger)
+	recordRunMetrics(cfg, "unbootstrap", result, logger)
+	if err != nil {
+		return err
+	}
+
+	// Handle and log the result (unbootstrap is more lenient with failures)
+	return handleExecutionResult(result, "unbootstrap", logger)
+}
+
+// runReset removes all node components locally and reports the Azure resources left behind
+func runReset(ctx context.Context, out io.Writer, reportOpts reportOptions, resourcesFile string) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if err := report.ValidateFormat(reportOpts.format); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	result, err := bootstrapExecutor.Reset(ctx)
+	writeReport(cfg, result, "reset", reportOpts, logger)
+
+	if resourcesFile == "" {
+		resourcesFile = filepath.Join(cfg.Agent.StateDir, azureResourcesFileName)
+	}
+	resources := arc.NewLocalUnInstaller(logger).RemainingResources()
+	if werr := writeAzureResources(resourcesFile, resources); werr != nil {
+		logger.Warnf("Failed to write Azure resources to %s: %v", resourcesFile, werr)
+		resourcesFile = ""
+	}
+	printAzureResources(out, resources, resourcesFile)
+
+	if err != nil {
+		return err
+	}
+	return handleExecutionResult(result, "reset", logger)
+}
+
+// writeAzureResources writes the Azure resources left by a reset as JSON
+func writeAzureResources(path string, resources []arc.AzureResource) error {
+	data, err := json.MarshalIndent(resources, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return utils.WriteFileAtomic(path, append(data, '\n'), 0600)
+}
+
+// printAzureResources lists the Azure resources that need manual cleanup and how to remove them
+func printAzureResources(out io.Writer, resources []arc.AzureResource, path string) {
+	fmt.Fprintln(out, "The following Azure resources were not removed and need manual cleanup:")
+	for _, resource := range resources {
+		fmt.Fprintf(out, "\n  %s %q\n    scope: %s\n    %s\n", resource.Type, resource.Name, resource.Scope, resource.CleanupCommand)
+	}
+	if path != "" {
+		fmt.Fprintf(out, "\nThe list was written to %s\n", path)
+	}
+}
+
+// runPlan computes the bootstrap, unbootstrap or reset plan and writes it in the requested format
+func runPlan(ctx context.Context, out io.Writer, output string, operation string, resumeOpts bootstrapper.ResumeOptions) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	bootstrapExecutor.SetResumeOptions(resumeOpts)
+
+	var result *plan.Result
+	switch operation {
+	case "unbootstrap":
+		result, err = bootstrapExecutor.PlanUnbootstrap(ctx)
+	case "reset":
+		result, err = bootstrapExecutor.PlanReset(ctx)
+	default:
+		result, err = bootstrapExecutor.PlanBootstrap(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to compute plan: %w", err)
+	}
+
+	if output == "json" {
+		return result.WriteJSON(out)
+	}
+	return result.WriteText(out)
+}
+
+// runComponentList writes the registered components in install order
+func runComponentList(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tDEPENDS ON\tDESCRIPTION")
+	for _, component := range bootstrapper.Components() {
+		deps := strings.Join(component.DependsOn, ",")
+		if deps == "" {
+			deps = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", component.Name, deps, component.Description)
+	}
+	return w.Flush()
+}
+
+// runComponentInstall installs a single component and, optionally, its prerequisites
+func runComponentInstall(ctx context.Context, name string, withPrerequisites bool, reportOpts reportOptions) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if err := report.ValidateFormat(reportOpts.format); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	result, err := bootstrapExecutor.InstallComponent(ctx, name, withPrerequisites)
+	writeReport(cfg, result, "component-install", reportOpts, logger)
+	if err != nil {
+		return err
+	}
+
+	return handleExecutionResult(result, "install of component "+name, logger)
+}
+
+// runComponentUninstall removes a single component
+func runComponentUninstall(ctx context.Context, name string, reportOpts reportOptions) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if err := report.ValidateFormat(reportOpts.format); err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	result, err := bootstrapExecutor.UninstallComponent(ctx, name)
+	writeReport(cfg, result, "component-uninstall", reportOpts, logger)
+	if err != nil {
+		return err
+	}
+
+	return handleExecutionResult(result, "uninstall of component "+name, logger)
+}
+
+// runComponentStatus reports the installation state of a component and its prerequisites
+func runComponentStatus(ctx context.Context, out io.Writer, name, output string) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
+
+	components, err := bootstrapper.ResolveComponents(name)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	states := make([]*bootstrapper.ComponentState, 0, len(components))
+	for _, component := range components {
+		state, err := bootstrapExecutor.ComponentStatus(ctx, component.Name)
+		if err != nil {
+			return err
+		}
+		states = append(states, state)
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(states, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal component status to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTEP\tINSTALLED")
+	for _, state := range states {
+		fmt.Fprintf(w, "%s\t%s\t%t\n", state.Name, state.StepName, state.Installed)
+	}
+	return w.Flush()
+}
+
+// runStatus prints the node status and returns an exitCodeError unless the node is healthy
+func runStatus(ctx context.Context, out io.Writer, output string, live bool) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if output != "table" && output != "json" && output != "yaml" {
+		return fmt.Errorf("unsupported output format %q, expected table, json or yaml", output)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	source := status.SourceDaemon
+	statusFilePath := status.FindStatusFile()
+	var nodeStatus *status.NodeStatus
+	if !live {
+		nodeStatus = statusFromAdminAPI(ctx, cfg)
+		if nodeStatus != nil {
+			source = status.SourceAPI
+		}
+	}
+	if !live && nodeStatus == nil {
+		nodeStatus, err = status.ReadStatusFile(statusFilePath)
+		if err != nil && !status.IsNotExist(err) {
+			return err
+		}
+	}
+	if nodeStatus == nil {
+		logger.Debug("No daemon status available, collecting status live")
+		source = status.SourceLive
+		nodeStatus, err = status.NewCollector(cfg, logger, Version).CollectStatus(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to collect node status: %w", err)
+		}
+	}
+
+	healthReport := status.Assess(nodeStatus, cfg, source, time.Now())
+	if source == status.SourceDaemon {
+		healthReport.StatusFile = statusFilePath
+	}
+
+	if err := printHealthReport(out, healthReport, output); err != nil {
+		return err
+	}
+
+	if code := healthReport.Health.ExitCode(); code != status.ExitCodeHealthy {
+		return &exitCodeError{code: code}
+	}
+	return nil
+}
+
+// statusFromAdminAPI returns the status held by a running daemon, or nil if no daemon answers
+func statusFromAdminAPI(ctx context.Context, cfg *config.Config) *status.NodeStatus {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	socket, ok := adminapi.FindSocket(cfg)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, adminAPITimeout)
+	defer cancel()
+	nodeStatus, err := adminapi.NewClient(socket).Status(ctx)
+	if err != nil {
+		logger.Debugf("Daemon admin API unavailable, falling back to the status file: %v", err)
+		return nil
+	}
+	return nodeStatus
+}
+
+// printHealthReport writes a health report as a table, JSON or YAML
+func printHealthReport(out io.Writer, healthReport *status.HealthReport, output string) error {
+	switch output {
+	case "json":
+		data, err := json.MarshalIndent(healthReport, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal status to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(healthReport)
+		if err != nil {
+			return fmt.Errorf("failed to marshal status to YAML: %w", err)
+		}
+		_, err = out.Write(data)
+		return err
+	}
+
+	nodeStatus := healthReport.Status
+	age := (time.Duration(healthReport.AgeSeconds) * time.Second).String() + " ago"
+	if healthReport.Stale {
+		age += ", stale"
+	}
+	source := healthReport.Source
+	if healthReport.StatusFile != "" {
+		source += " (" + healthReport.StatusFile + ")"
+	}
+	arc := "not connected"
+	if nodeStatus.ArcStatus.Connected {
+		arc = "connected"
+	}
+	if nodeStatus.ArcStatus.MachineName != "" {
+		arc += " as " + nodeStatus.ArcStatus.MachineName
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "HEALTH\t%s\n", healthReport.Health)
+	for _, reason := range healthReport.Reasons {
+		fmt.Fprintf(w, "  REASON\t%s\n", reason)
+	}
+	fmt.Fprintf(w, "SOURCE\t%s\n", source)
+	fmt.Fprintf(w, "LAST UPDATED\t%s (%s)\n", nodeStatus.LastUpdated.Format(time.RFC3339), age)
+	fmt.Fprintf(w, "KUBELET\t%s, running=%t, ready=%s\n", nodeStatus.KubeletVersion, nodeStatus.KubeletRunning, nodeStatus.KubeletReady)
+	fmt.Fprintf(w, "CONTAINERD\t%s, running=%t\n", nodeStatus.ContainerdVersion, nodeStatus.ContainerdRunning)
+	fmt.Fprintf(w, "RUNC\t%s\n", nodeStatus.RuncVersion)
+	fmt.Fprintf(w, "ARC\t%s\n", arc)
+	fmt.Fprintf(w, "AGENT VERSION\t%s\n", nodeStatus.AgentVersion)
+	if nodeStatus.Remediation != nil {
+		fmt.Fprintf(w, "REMEDIATION\t%s\n", remediationSummary(nodeStatus.Remediation))
+	}
+	if daemon := nodeStatus.Daemon; daemon != nil {
+		fmt.Fprintf(w, "DAEMON\tstatus every %s, remediation every %s, stale after %s, jitter %s, initial delay %s\n",
+			daemon.StatusInterval, daemon.RemediationInterval, daemon.StaleThreshold, daemon.Jitter, daemon.InitialDelay)
+	}
+	return w.Flush()
+}
+
+// runDoctor runs the host preflight checks and fails when any check fails
+func runDoctor(ctx context.Context, out io.Writer, output string, checks []string) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	preflightReport, err := preflight.Run(ctx, cfg, checks...)
+	if err != nil {
+		return err
+	}
+
+	if err := printPreflightReport(out, preflightReport, output); err != nil {
+		return err
+	}
+
+	if !preflightReport.OK() {
+		return fmt.Errorf("%d preflight check(s) failed", preflightReport.Failed)
+	}
+	return nil
+}
+
+// printPreflightReport writes preflight results as text or JSON
+func printPreflightReport(out io.Writer, preflightReport *preflight.Report, output string) error {
+	if output == "json" {
+		data, err := json.MarshalIndent(preflightReport, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal preflight results to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tMESSAGE")
+	for _, result := range preflightReport.Results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", result.Name, strings.ToUpper(string(result.Status)), result.Message)
+		if result.Remediation != "" && result.Status != preflight.StatusPass {
+			fmt.Fprintf(w, "\t\t-> %s\n", result.Remediation)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped\n",
+		preflightReport.Passed, preflightReport.Warned, preflightReport.Failed, preflightReport.Skipped)
+	return w.Flush()
+}
+
+// runUpgrade upgrades drifted components and prints what changed
+func runUpgrade(ctx context.Context, out io.Writer, opts upgrade.Options) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	upgrader, err := upgrade.New(cfg, logger, bootstrapper.New(cfg, logger), Version)
+	if err != nil {
+		return err
+	}
+
+	result, err := upgrader.Run(ctx, opts)
+	if result != nil {
+		printUpgradeResult(out, result, opts.DryRun)
+	}
+	return err
+}
+
+// printUpgradeResult writes the detected drift and the outcome of an upgrade
+func printUpgradeResult(out io.Writer, result *upgrade.Result, dryRun bool) {
+	if len(result.Drifts) == 0 {
+		fmt.Fprintln(out, "All components match the configured versions")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COMPONENT\tINSTALLED\tCONFIGURED")
+	for _, drift := range result.Drifts {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", drift.Component, drift.Installed, drift.Desired)
+	}
+	_ = w.Flush()
+
+	switch {
+	case dryRun:
+		fmt.Fprintln(out, "Dry run, nothing was changed")
+	case result.RolledBack:
+		fmt.Fprintln(out, "Upgrade failed, the previous binaries were restored")
+	case len(result.Upgraded) == len(result.Drifts):
+		fmt.Fprintf(out, "Upgraded %s\n", strings.Join(result.Upgraded, ", "))
+	}
+}
+
+// runSelfUpdate updates the agent binary and prints the outcome
+func runSelfUpdate(ctx context.Context, out io.Writer, opts selfupdate.Options) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	updater, err := selfupdate.New(cfg, logger, Version)
+	if err != nil {
+		return err
+	}
+
+	result, err := updater.Run(ctx, opts)
+	if result != nil {
+		printSelfUpdateResult(out, result, opts)
+	}
+	return err
+}
+
+// printSelfUpdateResult writes the running and released versions and the outcome of a self-update
+func printSelfUpdateResult(out io.Writer, result *selfupdate.Result, opts selfupdate.Options) {
+	fmt.Fprintf(out, "Running version: %s\n", result.CurrentVersion)
+	fmt.Fprintf(out, "Release version: %s\n", result.ReleaseVersion)
+
+	switch {
+	case result.RolledBack:
+		fmt.Fprintf(out, "Update failed, rolled back to %s\n", result.CurrentVersion)
+	case result.Restarted:
+		fmt.Fprintf(out, "Updated to %s and restarted %s\n", result.ReleaseVersion, selfupdate.ServiceName)
+	case result.Updated:
+		fmt.Fprintf(out, "Updated to %s, restart %s to run it\n", result.ReleaseVersion, selfupdate.ServiceName)
+	case opts.CheckOnly && result.UpdateAvailable:
+		fmt.Fprintln(out, "An update is available")
+	case !result.UpdateAvailable && !opts.Force:
+		fmt.Fprintln(out, "The agent is up to date")
+	}
+}
+
+// runCollectLogs writes a support bundle and prints where it was written
+func runCollectLogs(ctx context.Context, out io.Writer, opts supportbundle.Options) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	if opts.MaxBytes <= 0 {
+		return fmt.Errorf("--max-size must be positive")
+	}
+
+	var cfg *config.Config
+	if configPath != "" {
+		loaded, err := config.LoadConfig(configPath)
+		if err != nil {
+			logger.Warnf("Failed to load config from %s, collecting from default locations: %v", configPath, err)
+		} else {
+			cfg = loaded
+		}
+	}
+
+	result, err := supportbundle.New(cfg, logger, configPath, Version).Collect(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Support bundle written to %s (%d files, %d bytes before compression)\n", result.Path, result.Files, result.Bytes)
+	if len(result.Truncated) > 0 {
+		fmt.Fprintf(out, "Truncated to fit --max-size: %s\n", strings.Join(result.Truncated, ", "))
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(out, "%d item(s) could not be collected, see manifest.txt in the bundle\n", len(result.Errors))
+	}
+	return nil
+}
+
+// runConfigValidate prints every configuration problem and fails if there is any
+func runConfigValidate(out io.Writer) error {
+	inspection, err := config.Inspect(configPath)
+	if err != nil {
+		return err
+	}
+
+	problems := inspection.Errors
+	if err := preflight.ValidateCheckNames(inspection.Config.Preflight.SkipChecks...); err != nil {
+		problems = append(problems, fmt.Errorf("invalid preflight.skipChecks: %w", err))
+	}
+
+	if len(problems) == 0 {
+		_, err := fmt.Fprintf(out, "%s is valid\n", configPath)
+		return err
+	}
+
+	fmt.Fprintf(out, "%s has %d problem(s):\n", configPath, len(problems))
+	for _, problem := range problems {
+		fmt.Fprintf(out, "  - %v\n", problem)
+	}
+	return fmt.Errorf("configuration %s is invalid", configPath)
+}
+
+// runConfigShow prints configuration values with their source
+func runConfigShow(out io.Writer, output string, effective bool) error {
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected text or json", output)
+	}
+
+	inspection, err := config.Inspect(configPath)
+	if err != nil {
+		return err
+	}
+
+	values := inspection.Values
+	if !effective {
+		values = make([]config.Value, 0, len(inspection.Values))
+		for _, value := range inspection.Values {
+			if value.Source == config.SourceFile || value.Source == config.SourceEnv {
+				values = append(values, value)
+			}
+		}
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(values); err != nil {
+			return fmt.Errorf("failed to marshal configuration to JSON: %w", err)
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+	for _, value := range values {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", value.Key, formatConfigValue(value.Value), value.Source)
+	}
+	return w.Flush()
+}
+
+// formatConfigValue renders a configuration value on a single line
+func formatConfigValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	case int, bool:
+		return fmt.Sprint(v)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// newAdminClient returns a client for the running daemon's admin API
+func newAdminClient() (*adminapi.Client, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config from %s: %w", configPath, err)
+	}
+
+	socket, ok := adminapi.FindSocket(cfg)
+	if !ok {
+		return nil, fmt.Errorf("no admin socket found at %s, is the daemon running?", adminapi.SocketPath(cfg))
+	}
+	return adminapi.NewClient(socket), nil
+}
+
+// runAdminSteps prints the step results of the daemon's most recent bootstrap run
+func runAdminSteps(ctx context.Context, out io.Writer, output string) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected table or json", output)
+	}
+
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+	result, err := client.Steps(ctx)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal steps to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tRESULT\tDURATION\tERROR")
+	for _, step := range result.StepResults {
+		outcome := "succeeded"
+		switch {
+		case step.SkipReason != "":
+			outcome = "skipped"
+		case !step.Success:
+			outcome = "failed"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", step.StepName, outcome, step.Duration.Round(time.Millisecond), step.Error)
+	}
+	return w.Flush()
+}
+
+// runAdminReconcile asks the daemon to check and repair the node now
+func runAdminReconcile(ctx context.Context, out io.Writer) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+	response, err := client.Reconcile(ctx)
+	if err != nil {
+		return err
+	}
+	if !response.Accepted {
+		_, err = fmt.Fprintln(out, "A reconcile is already pending")
+		return err
+	}
+	_, err = fmt.Fprintln(out, "Reconcile scheduled")
+	return err
 }
 
-// NewVersionCommand creates a new version command
-func NewVersionCommand() *cobra.Command {
-	cmd := &cobra.Command{
-		Use:   "version",
-		Short: "Show version information",
-		Long:  "Display version, build commit, and build time information",
-		Run: func(cmd *cobra.Command, args []string) {
-			runVersion()
-		},
+// runAdminSetPaused pauses or resumes the daemon's automatic remediation
+func runAdminSetPaused(ctx context.Context, out io.Writer, paused bool) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+	if err := client.SetPaused(ctx, paused); err != nil {
+		return err
 	}
 
-	return cmd
+	state := "resumed"
+	if paused {
+		state = "paused"
+	}
+	_, err = fmt.Fprintf(out, "Automatic remediation %s\n", state)
+	return err
 }
 
-// runAgent executes the bootstrap process and then runs as daemon
-func runAgent(ctx context.Context) error {
-	logger := logger.GetLoggerFromContext(ctx)
+// runAdminLogLevel changes the daemon's log level
+func runAdminLogLevel(ctx context.Context, out io.Writer, level string) error {
+	client, err := newAdminClient()
+	if err != nil {
+		return err
+	}
+	newLevel, err := client.SetLogLevel(ctx, level)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Daemon log level set to %s\n", newLevel)
+	return err
+}
+
+// runRemediationStatus prints the state of the remediation breaker
+func runRemediationStatus(out io.Writer, output string) error {
+	if output != "table" && output != "json" {
+		return fmt.Errorf("unsupported output format %q, expected table or json", output)
+	}
 
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
 	}
-
-	bootstrapExecutor := bootstrapper.New(cfg, logger)
-	result, err := bootstrapExecutor.Bootstrap(ctx)
+	state, err := remediation.ReadState(remediation.Path(cfg.Agent.StateDir))
 	if err != nil {
 		return err
 	}
 
-	// Handle and log the bootstrap result
-	if err := handleExecutionResult(result, "bootstrap", logger); err != nil {
+	if output == "json" {
+		data, err := json.MarshalIndent(state, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal remediation state to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
 		return err
 	}
 
-	// After successful bootstrap, transition to daemon mode
-	logger.Info("Bootstrap completed successfully, transitioning to daemon mode...")
-	return runDaemonLoop(ctx, cfg)
+	policy := cfg.Agent.Remediation
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "STATE\t%s\n", remediationSummary(state))
+	if state.Tripped {
+		fmt.Fprintf(w, "TRIPPED AT\t%s\n", state.TrippedAt.Format(time.RFC3339))
+		fmt.Fprintf(w, "REASON\t%s\n", state.Reason)
+	}
+	fmt.Fprintf(w, "POLICY\t%d attempts within %s, backoff %s up to %s\n", policy.MaxAttempts, policy.Window, policy.Backoff, policy.MaxBackoff)
+	fmt.Fprintf(w, "ATTEMPTS\t%d\n", len(state.Attempts))
+	fmt.Fprintf(w, "CONSECUTIVE FAILURES\t%d\n", state.ConsecutiveFailures)
+	if state.LastError != "" {
+		fmt.Fprintf(w, "LAST ERROR\t%s\n", state.LastError)
+	}
+	return w.Flush()
 }
 
-// runUnbootstrap executes the unbootstrap process
-func runUnbootstrap(ctx context.Context) error {
+// remediationSummary describes the remediation breaker state in a few words
+func remediationSummary(state *remediation.State) string {
+	switch {
+	case state.Tripped:
+		return "stopped, run 'aks-flex-node remediation reset' after repairing the node"
+	case state.NextAttempt != nil && time.Now().Before(*state.NextAttempt):
+		return "backing off until " + state.NextAttempt.Format(time.RFC3339)
+	default:
+		return "active"
+	}
+}
+
+// runRemediationReset closes the remediation breaker and asks a running daemon to check the node
+func runRemediationReset(ctx context.Context, out io.Writer) error {
 	logger := logger.GetLoggerFromContext(ctx)
 
 	cfg, err := config.LoadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load config from %s: %w", configPath, err)
 	}
-
-	bootstrapExecutor := bootstrapper.New(cfg, logger)
-	result, err := bootstrapExecutor.Unbootstrap(ctx)
+	breaker, err := remediation.Load(cfg)
 	if err != nil {
 		return err
 	}
+	if err := breaker.Reset(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(out, "Remediation breaker reset, automatic remediation resumed"); err != nil {
+		return err
+	}
 
-	// Handle and log the result (unbootstrap is more lenient with failures)
-	return handleExecutionResult(result, "unbootstrap", logger)
+	socket, ok := adminapi.FindSocket(cfg)
+	if !ok {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, adminAPITimeout)
+	defer cancel()
+	if _, err := adminapi.NewClient(socket).Reconcile(ctx); err != nil {
+		logger.Warnf("Failed to ask the daemon to check the node: %v", err)
+		return nil
+	}
+	_, err = fmt.Fprintln(out, "Reconcile scheduled")
+	return err
 }
 
 // runVersion displays version information
@@ -118,8 +1623,254 @@ func runVersion() {
 	fmt.Printf("Build Time: %s\n", BuildTime)
 }
 
-// runDaemonLoop runs the periodic status collection and bootstrap monitoring daemon
-func runDaemonLoop(ctx context.Context, cfg *config.Config) error {
+// daemonState is the state of the daemon loop shared with the admin API
+type daemonState struct {
+	mu         sync.Mutex
+	status     *status.NodeStatus
+	lastResult *bootstrapper.ExecutionResult
+	paused     bool
+	reconcile  chan struct{}
+
+	// configError is why the last configuration reload was rejected, empty if it was not
+	configError string
+	// drift lists the components found drifted by the last remediation check
+	drift []drift.Component
+	// metrics are exported on agent.metricsAddress
+	metrics *status.Metrics
+}
+
+// newDaemonState creates the state of a daemon whose last bootstrap run, if any, is lastResult
+func newDaemonState(lastResult *bootstrapper.ExecutionResult) *daemonState {
+	return &daemonState{
+		lastResult: lastResult,
+		reconcile:  make(chan struct{}, 1),
+		metrics:    status.NewMetrics(nil, status.BuildInfo{Version: Version, GitCommit: GitCommit, BuildTime: BuildTime}),
+	}
+}
+
+// Status returns the most recently collected node status
+func (d *daemonState) Status() *status.NodeStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// setStatus records a newly collected node status
+func (d *daemonState) setStatus(nodeStatus *status.NodeStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.status = nodeStatus
+}
+
+// setConfigError records why a configuration reload was rejected; an empty reason clears it
+func (d *daemonState) setConfigError(reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.configError = reason
+}
+
+// ConfigError returns why the last configuration reload was rejected, empty if it was not
+func (d *daemonState) ConfigError() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.configError
+}
+
+// setDrift records the components found drifted by a remediation check
+func (d *daemonState) setDrift(drifted []drift.Component) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drift = drifted
+}
+
+// Drift returns the components found drifted by the last remediation check
+func (d *daemonState) Drift() []drift.Component {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.drift
+}
+
+// LastResult returns the result of the most recent bootstrap run
+func (d *daemonState) LastResult() *bootstrapper.ExecutionResult {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastResult
+}
+
+// setLastResult records the result of a bootstrap run
+func (d *daemonState) setLastResult(result *bootstrapper.ExecutionResult) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastResult = result
+}
+
+// RequestReconcile schedules a bootstrap check; requests made while one is pending are merged
+func (d *daemonState) RequestReconcile() bool {
+	select {
+	case d.reconcile <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// SetPaused pauses or resumes the periodic bootstrap and self-update checks
+func (d *daemonState) SetPaused(paused bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.paused = paused
+}
+
+// Paused reports whether the periodic checks are paused
+func (d *daemonState) Paused() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.paused
+}
+
+// startAdminAPI serves the admin API for state until ctx is cancelled. The daemon keeps running
+// without it if the socket cannot be created, in which case the returned server is nil.
+func startAdminAPI(ctx context.Context, cfg *config.Config, state *daemonState) *adminapi.Server {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	server := adminapi.NewServer(cfg, logger, state)
+	if err := server.Listen(adminapi.SocketPath(cfg)); err != nil {
+		logger.Warnf("Admin API disabled: %v", err)
+		return nil
+	}
+
+	go func() {
+		if err := server.Serve(); err != nil {
+			logger.Errorf("%v", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			logger.Warnf("Failed to stop admin API: %v", err)
+		}
+	}()
+	return server
+}
+
+// startMetrics serves metrics in the Prometheus text format on address until stopMetrics is called.
+// It returns nil when address is empty or cannot be listened on; the daemon keeps running without metrics.
+func startMetrics(ctx context.Context, address string, metrics *status.Metrics) *http.Server {
+	logger := logger.GetLoggerFromContext(ctx)
+	if address == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		logger.Warnf("Metrics disabled: failed to listen on %s: %v", address, err)
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: adminAPITimeout}
+
+	logger.Infof("Serving metrics on http://%s/metrics", listener.Addr())
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("Metrics listener failed: %v", err)
+		}
+	}()
+	return server
+}
+
+// stopMetrics stops a metrics server started by startMetrics
+func stopMetrics(ctx context.Context, server *http.Server) {
+	if server == nil {
+		return
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), adminAPITimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.GetLoggerFromContext(ctx).Warnf("Failed to stop metrics listener: %v", err)
+	}
+}
+
+// notifySystemd sends states to systemd when the agent runs as a Type=notify unit
+func notifySystemd(ctx context.Context, states ...string) {
+	if _, err := sdnotify.Notify(states...); err != nil {
+		logger.GetLoggerFromContext(ctx).Warnf("%v", err)
+	}
+}
+
+// reportStepsToSystemd makes executor show the step it starts as the systemd status of the agent.
+// Every step started renews the watchdog lease of ctx, if any.
+func reportStepsToSystemd(ctx context.Context, executor *bootstrapper.Bootstrapper) {
+	executor.SetStepObserver(func(stepType, stepName string) {
+		notifySystemd(ctx, sdnotify.Status("%s: running %s", stepType, stepName))
+		renewWatchdogLease(ctx)
+	})
+}
+
+// watchdogStallTimeout is how long work blocking the daemon loop may go without starting a step
+// before the watchdog pings stop and systemd restarts the agent. It exceeds the longest built-in
+// step timeout and the drain and ready timeouts of an upgrade. Overridable for tests.
+var watchdogStallTimeout = 30 * time.Minute
+
+// watchdogLeaseKey is the context key of the progress channel of a watchdog lease
+type watchdogLeaseKey struct{}
+
+// startWatchdogLease pings the systemd watchdog in the background while work that blocks the daemon
+// loop, such as a bootstrap, makes progress. The pings stop once watchdogStallTimeout passes
+// without renewWatchdogLease being called on the returned context, and when the returned function
+// is called.
+func startWatchdogLease(ctx context.Context) (context.Context, func()) {
+	interval, ok := sdnotify.WatchdogInterval()
+	if !ok {
+		return ctx, func() {}
+	}
+
+	progress := make(chan struct{}, 1)
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval / 2)
+		defer ticker.Stop()
+		deadline := time.Now().Add(watchdogStallTimeout)
+		stalled := false
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-progress:
+				deadline = time.Now().Add(watchdogStallTimeout)
+				stalled = false
+				notifySystemd(ctx, sdnotify.Watchdog)
+			case now := <-ticker.C:
+				if now.Before(deadline) {
+					notifySystemd(ctx, sdnotify.Watchdog)
+				} else if !stalled {
+					stalled = true
+					logger.GetLoggerFromContext(ctx).Warnf("No progress for %s, no longer pinging the systemd watchdog", watchdogStallTimeout)
+				}
+			}
+		}
+	}()
+	return context.WithValue(ctx, watchdogLeaseKey{}, progress), func() { close(done) }
+}
+
+// renewWatchdogLease records that the work running under ctx made progress
+func renewWatchdogLease(ctx context.Context) {
+	progress, ok := ctx.Value(watchdogLeaseKey{}).(chan struct{})
+	if !ok {
+		return
+	}
+	select {
+	case progress <- struct{}{}:
+	default:
+	}
+}
+
+// runDaemonLoop runs the periodic status collection and bootstrap monitoring daemon. lastResult is
+// the bootstrap run that preceded the daemon, if any, and is reported by the admin API until the
+// daemon bootstraps again.
+func runDaemonLoop(ctx context.Context, cfg *config.Config, lastResult *bootstrapper.ExecutionResult) error {
 	logger := logger.GetLoggerFromContext(ctx)
 	// Create status file directory - using runtime directory for service or temp for development
 	statusFilePath := status.GetStatusFilePath()
@@ -138,16 +1889,71 @@ func runDaemonLoop(ctx context.Context, cfg *config.Config) error {
 		}
 	}
 
-	logger.Info("Starting periodic status collection daemon (status: 1 minutes, bootstrap check: 2 minute)")
+	schedule := cfg.Agent.Daemon
+	logger.Infof("Starting periodic status collection daemon (status: %s, bootstrap check: %s, jitter: %s, initial delay: %s)",
+		schedule.StatusInterval, schedule.RemediationInterval, schedule.Jitter, schedule.InitialDelay)
+
+	state := newDaemonState(lastResult)
+	state.metrics.SetConfig(cfg)
+	adminServer := startAdminAPI(ctx, cfg, state)
+	metricsAddress := cfg.Agent.MetricsAddress
+	metricsServer := startMetrics(ctx, metricsAddress, state.metrics)
+	defer func() { stopMetrics(ctx, metricsServer) }()
+
+	// Tell systemd the agent is up; from here on the loop itself pings the watchdog
+	notifySystemd(ctx, sdnotify.Ready, sdnotify.Status(monitoringStatus))
+	defer notifySystemd(ctx, sdnotify.Stopping)
+	var watchdogTick <-chan time.Time
+	if interval, ok := sdnotify.WatchdogInterval(); ok {
+		watchdogTicker := time.NewTicker(interval / 2)
+		defer watchdogTicker.Stop()
+		watchdogTick = watchdogTicker.C
+	}
+
+	// Reload the configuration when its file changes or on SIGHUP (systemctl reload)
+	configChanged, err := reload.Watch(ctx, configPath, logger)
+	if err != nil {
+		logger.Warnf("Configuration file watch disabled, reload with SIGHUP instead: %v", err)
+	}
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	// Wait before the first collection so that nodes booting together do not check in lockstep
+	if delay := jitteredInterval(schedule.InitialDelay, schedule.Jitter); delay > 0 {
+		logger.Infof("Delaying first status collection by %s", delay.Round(time.Second))
+		delayTimer := time.NewTimer(delay)
+		for delaying := true; delaying; {
+			select {
+			case <-ctx.Done():
+				delayTimer.Stop()
+				logger.Info("Daemon shutting down due to context cancellation")
+				return ctx.Err()
+			case <-watchdogTick:
+				notifySystemd(ctx, sdnotify.Watchdog)
+			case <-delayTimer.C:
+				delaying = false
+			}
+		}
+	}
+
+	// Timers are re-armed with fresh jitter after every run instead of ticking at a fixed rate
+	statusTimer := time.NewTimer(jitteredInterval(schedule.StatusInterval, schedule.Jitter))
+	bootstrapTimer := time.NewTimer(jitteredInterval(schedule.RemediationInterval, schedule.Jitter))
+	defer statusTimer.Stop()
+	defer bootstrapTimer.Stop()
 
-	// Create tickers for different intervals
-	statusTicker := time.NewTicker(1 * time.Minute)
-	bootstrapTicker := time.NewTicker(2 * time.Minute)
-	defer statusTicker.Stop()
-	defer bootstrapTicker.Stop()
+	// Self-update is checked only when the daemon may apply it
+	var selfUpdateTick <-chan time.Time
+	var selfUpdateTimer *time.Timer
+	if cfg.SelfUpdate.Auto {
+		selfUpdateTimer = time.NewTimer(jitteredInterval(cfg.SelfUpdate.CheckInterval, schedule.Jitter))
+		defer selfUpdateTimer.Stop()
+		selfUpdateTick = selfUpdateTimer.C
+	}
 
 	// Collect status immediately on start
-	if err := collectAndWriteStatus(ctx, cfg, statusFilePath); err != nil {
+	if err := collectAndWriteStatus(ctx, cfg, statusFilePath, state); err != nil {
 		logger.Errorf("Failed to collect initial status: %v", err)
 	}
 
@@ -157,28 +1963,228 @@ func runDaemonLoop(ctx context.Context, cfg *config.Config) error {
 		case <-ctx.Done():
 			logger.Info("Daemon shutting down due to context cancellation")
 			return ctx.Err()
-		case <-statusTicker.C:
+		case <-watchdogTick:
+			// Pings stop when the loop is wedged, so that systemd restarts the agent
+			notifySystemd(ctx, sdnotify.Watchdog)
+			continue
+		case <-statusTimer.C:
+			statusTimer.Reset(jitteredInterval(schedule.StatusInterval, schedule.Jitter))
 			logger.Infof("Starting periodic status collection at %s...", time.Now().Format("2006-01-02 15:04:05"))
-			if err := collectAndWriteStatus(ctx, cfg, statusFilePath); err != nil {
+			if err := collectAndWriteStatus(ctx, cfg, statusFilePath, state); err != nil {
 				logger.Errorf("Failed to collect status at %s: %v", time.Now().Format("2006-01-02 15:04:05"), err)
 				// Continue running even if status collection fails
 			} else {
 				logger.Infof("Status collection completed successfully at %s", time.Now().Format("2006-01-02 15:04:05"))
 			}
-		case <-bootstrapTicker.C:
+		case <-bootstrapTimer.C:
+			bootstrapTimer.Reset(jitteredInterval(schedule.RemediationInterval, schedule.Jitter))
+			if state.Paused() {
+				logger.Info("Skipping bootstrap health check, automatic remediation is paused")
+				continue
+			}
 			logger.Infof("Starting bootstrap health check at %s...", time.Now().Format("2006-01-02 15:04:05"))
-			if err := checkAndBootstrap(ctx, cfg); err != nil {
-				logger.Errorf("Auto-bootstrap check failed at %s: %v", time.Now().Format("2006-01-02 15:04:05"), err)
-				// Continue running even if bootstrap check fails
+			runBootstrapCheck(ctx, cfg, state)
+		case <-selfUpdateTick:
+			selfUpdateTimer.Reset(jitteredInterval(cfg.SelfUpdate.CheckInterval, schedule.Jitter))
+			if state.Paused() {
+				logger.Info("Skipping self-update check, automatic remediation is paused")
+				continue
+			}
+			if err := checkSelfUpdate(ctx, cfg); err != nil {
+				logger.Errorf("Self-update check failed: %v", err)
+			}
+		case <-state.reconcile:
+			// An explicit request runs even while automatic remediation is paused
+			logger.Infof("Starting requested reconcile at %s...", time.Now().Format("2006-01-02 15:04:05"))
+			runBootstrapCheck(ctx, cfg, state)
+		case <-configChanged:
+			cfg = reloadDaemonConfig(ctx, cfg, state)
+		case <-hangup:
+			cfg = reloadDaemonConfig(ctx, cfg, state)
+		}
+
+		if cfg.Agent.MetricsAddress != metricsAddress {
+			metricsAddress = cfg.Agent.MetricsAddress
+			stopMetrics(ctx, metricsServer)
+			metricsServer = startMetrics(ctx, metricsAddress, state.metrics)
+		}
+
+		if cfg.Agent.Daemon != schedule {
+			schedule = cfg.Agent.Daemon
+			logger.Infof("Daemon schedule changed (status: %s, bootstrap check: %s, jitter: %s), effective from the next run",
+				schedule.StatusInterval, schedule.RemediationInterval, schedule.Jitter)
+		}
+		if cfg.SelfUpdate.Auto != (selfUpdateTimer != nil) {
+			if selfUpdateTimer != nil {
+				selfUpdateTimer.Stop()
+				selfUpdateTimer, selfUpdateTick = nil, nil
 			} else {
-				logger.Infof("Bootstrap health check completed at %s", time.Now().Format("2006-01-02 15:04:05"))
+				selfUpdateTimer = time.NewTimer(jitteredInterval(cfg.SelfUpdate.CheckInterval, schedule.Jitter))
+				selfUpdateTick = selfUpdateTimer.C
+			}
+		}
+		if adminServer != nil {
+			adminServer.SetConfig(cfg)
+		}
+		state.metrics.SetConfig(cfg)
+	}
+}
+
+// reloadDaemonConfig reads the configuration file again and re-applies the components whose
+// settings changed. An invalid configuration is rejected: cfg stays active and the error is
+// recorded in state so that the node status reports it.
+func reloadDaemonConfig(ctx context.Context, cfg *config.Config, state *daemonState) *config.Config {
+	logger := logger.GetLoggerFromContext(ctx)
+	logger.Infof("Reloading configuration from %s...", configPath)
+	ctx, endLease := startWatchdogLease(ctx)
+	defer endLease()
+	defer notifySystemd(ctx, sdnotify.Status(monitoringStatus))
+
+	newCfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		logger.Errorf("Rejected configuration reload, keeping the previous configuration: %v", err)
+		state.setConfigError(err.Error())
+		return cfg
+	}
+	state.setConfigError("")
+
+	changes := reload.Diff(cfg, newCfg)
+	if len(changes) == 0 {
+		logger.Info("Configuration reloaded, nothing changed")
+		return newCfg
+	}
+
+	if newCfg.Agent.LogLevel != cfg.Agent.LogLevel {
+		if level, err := logrus.ParseLevel(newCfg.Agent.LogLevel); err == nil {
+			logger.SetLevel(level)
+		}
+	}
+
+	installer := bootstrapper.New(newCfg, logger)
+	reportStepsToSystemd(ctx, installer)
+	applied, err := reload.New(logger, installer).Apply(ctx, changes)
+	if err != nil {
+		logger.Errorf("Failed to re-apply the changed configuration: %v", err)
+	} else if len(applied) > 0 {
+		logger.Infof("Configuration reloaded, re-applied: %s", strings.Join(applied, ", "))
+	} else {
+		logger.Info("Configuration reloaded")
+	}
+	return newCfg
+}
+
+// jitteredInterval returns interval extended by a random delay of up to jitter
+func jitteredInterval(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	return interval + rand.N(jitter+1)
+}
+
+// daemonSettings returns the active daemon schedule for the status output
+func daemonSettings(cfg *config.Config) *status.DaemonSettings {
+	schedule := cfg.Agent.Daemon
+	return &status.DaemonSettings{
+		StatusInterval:      schedule.StatusInterval.String(),
+		RemediationInterval: schedule.RemediationInterval.String(),
+		StaleThreshold:      schedule.StaleThreshold.String(),
+		Jitter:              schedule.Jitter.String(),
+		InitialDelay:        schedule.InitialDelay.String(),
+	}
+}
+
+// runBootstrapCheck runs checkAndBootstrap and records its bootstrap run, if any, in state
+func runBootstrapCheck(ctx context.Context, cfg *config.Config, state *daemonState) {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	// Bootstraps, repairs and upgrades block the daemon loop; their steps keep the watchdog lease alive
+	ctx, endLease := startWatchdogLease(ctx)
+	defer endLease()
+	defer notifySystemd(ctx, sdnotify.Status(monitoringStatus))
+
+	result, err := checkAndBootstrap(ctx, cfg, state)
+	if result != nil {
+		state.setLastResult(result)
+	}
+	if err != nil {
+		logger.Errorf("Auto-bootstrap check failed at %s: %v", time.Now().Format("2006-01-02 15:04:05"), err)
+		// Continue running even if bootstrap check fails
+		return
+	}
+	if result == nil {
+		// No bootstrap ran, repair just the components that drifted
+		repairDrift(ctx, cfg, state)
+	} else {
+		state.setDrift(nil)
+	}
+	logger.Infof("Bootstrap health check completed at %s", time.Now().Format("2006-01-02 15:04:05"))
+}
+
+// repairDrift re-runs the components whose installed state drifted from their desired state and
+// records the drift that remains in state. Drift of binary versions alone is reported but left to
+// the upgrade command or agent.autoUpgrade.
+func repairDrift(ctx context.Context, cfg *config.Config, state *daemonState) {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	installer := bootstrapper.New(cfg, logger)
+	reportStepsToSystemd(ctx, installer)
+	drifted := installer.DetectDrift(ctx)
+	var repairs []string
+	for _, component := range drifted {
+		logger.Warnf("Component %s drifted from its desired state: %s", component.Name, component.Summary())
+		if component.Repairable() {
+			repairs = append(repairs, component.Name)
+		}
+	}
+
+	if len(repairs) > 0 {
+		if breaker, allowed := allowRemediation(ctx, cfg, "drift repair"); allowed {
+			logger.Infof("Repairing drifted components: %s", strings.Join(repairs, ", "))
+			_, err := reload.New(logger, installer).Repair(ctx, repairs)
+			if err != nil {
+				logger.Errorf("Failed to repair drifted components: %v", err)
 			}
+			recordRemediation(ctx, state, breaker, "drift-repair", err)
+			drifted = installer.DetectDrift(ctx)
 		}
 	}
+	state.setDrift(drifted)
+}
+
+// allowRemediation loads the remediation breaker and reports whether the daemon may start an
+// automatic remediation now. A breaker state that cannot be read does not block remediation.
+func allowRemediation(ctx context.Context, cfg *config.Config, operation string) (*remediation.Breaker, bool) {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	breaker, err := remediation.Load(cfg)
+	if err != nil {
+		logger.Warnf("Remediation limits are not enforced: %v", err)
+		return nil, true
+	}
+	allowed, reason, err := breaker.Allow(time.Now())
+	if err != nil {
+		logger.Warnf("Failed to save remediation state: %v", err)
+	}
+	if !allowed {
+		logger.Warnf("Skipping %s, %s", operation, reason)
+	}
+	return breaker, allowed
+}
+
+// recordRemediation records the outcome of an automatic remediation in breaker and the metrics
+func recordRemediation(ctx context.Context, state *daemonState, breaker *remediation.Breaker, operation string, err error) {
+	state.metrics.ObserveRemediation(operation, err)
+	if breaker == nil {
+		return
+	}
+	if recordErr := breaker.Record(time.Now(), err); recordErr != nil {
+		logger.GetLoggerFromContext(ctx).Warnf("Failed to save remediation state: %v", recordErr)
+	}
 }
 
-// checkAndBootstrap checks if the node needs re-bootstrapping and performs it if necessary
-func checkAndBootstrap(ctx context.Context, cfg *config.Config) error {
+// checkAndBootstrap checks if the node needs re-bootstrapping and performs it if necessary.
+// It returns the result of the bootstrap run, or nil if none was needed.
+func checkAndBootstrap(ctx context.Context, cfg *config.Config, state *daemonState) (*bootstrapper.ExecutionResult, error) {
 	logger := logger.GetLoggerFromContext(ctx)
 	// Create status collector to check bootstrap requirements
 	collector := status.NewCollector(cfg, logger, Version)
@@ -186,28 +2192,120 @@ func checkAndBootstrap(ctx context.Context, cfg *config.Config) error {
 	// Check if bootstrap is needed
 	needsBootstrap := collector.NeedsBootstrap(ctx)
 	if !needsBootstrap {
-		return nil // All good, no action needed
+		if cfg.Agent.AutoUpgrade {
+			return nil, autoUpgrade(ctx, cfg, state)
+		}
+		return nil, nil // All good, no action needed
+	}
+
+	breaker, allowed := allowRemediation(ctx, cfg, "auto-bootstrap")
+	if !allowed {
+		return nil, nil
 	}
 
 	logger.Info("Node requires re-bootstrapping, initiating auto-bootstrap...")
 
 	// Perform bootstrap
 	bootstrapExecutor := bootstrapper.New(cfg, logger)
+	reportStepsToSystemd(ctx, bootstrapExecutor)
 	result, err := bootstrapExecutor.Bootstrap(ctx)
+	saveDaemonReport(cfg, result, logger)
+	recordRunMetrics(cfg, "auto-bootstrap", result, logger)
 	if err != nil {
 		// Bootstrap failed - remove status file so next check will detect the problem
 		removeStatusFile(ctx)
-		return fmt.Errorf("auto-bootstrap failed: %s", err)
+		recordRemediation(ctx, state, breaker, "auto-bootstrap", err)
+		return result, fmt.Errorf("auto-bootstrap failed: %s", err)
 	}
 
 	// Handle and log the bootstrap result
 	if err := handleExecutionResult(result, "auto-bootstrap", logger); err != nil {
 		// Bootstrap execution failed - remove status file so next check will detect the problem
 		removeStatusFile(ctx)
-		return fmt.Errorf("auto-bootstrap execution failed: %s", err)
+		recordRemediation(ctx, state, breaker, "auto-bootstrap", err)
+		return result, fmt.Errorf("auto-bootstrap execution failed: %s", err)
 	}
 
+	recordRemediation(ctx, state, breaker, "auto-bootstrap", nil)
 	logger.Info("Auto-bootstrap completed successfully")
+	return result, nil
+}
+
+// checkSelfUpdate starts a detached self-update when the release manifest names another agent version.
+// The update runs outside the agent unit because it restarts that unit and must outlive it to roll back.
+func checkSelfUpdate(ctx context.Context, cfg *config.Config) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	updater, err := selfupdate.New(cfg, logger, Version)
+	if err != nil {
+		return err
+	}
+	result, err := updater.Run(ctx, selfupdate.Options{CheckOnly: true})
+	if err != nil {
+		return err
+	}
+	if !result.UpdateAvailable {
+		return nil
+	}
+
+	// The detached update always runs the installed agent with the unit's configuration
+	absConfigPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path %s: %w", configPath, err)
+	}
+	if absConfigPath != selfupdate.DefaultConfigPath {
+		return fmt.Errorf("automatic self-update requires the agent to run with %s, not %s; run self-update manually",
+			selfupdate.DefaultConfigPath, absConfigPath)
+	}
+
+	logger.Infof("Agent release %s is available, starting self-update in %s", result.ReleaseVersion, selfupdate.DetachedUnitName)
+	return selfupdate.Detach()
+}
+
+// nodeUpgrader is the part of upgrade.Upgrader used by auto-upgrade
+type nodeUpgrader interface {
+	DetectDrift(ctx context.Context) []upgrade.Drift
+	Run(ctx context.Context, opts upgrade.Options) (*upgrade.Result, error)
+}
+
+// newNodeUpgrader creates the upgrader of auto-upgrade; overridable for tests
+var newNodeUpgrader = func(ctx context.Context, cfg *config.Config, logger *logrus.Logger) (nodeUpgrader, error) {
+	installer := bootstrapper.New(cfg, logger)
+	reportStepsToSystemd(ctx, installer)
+	return upgrade.New(cfg, logger, installer, Version)
+}
+
+// autoUpgrade upgrades components whose installed version differs from the configured one. An
+// upgrade drains the node, so it counts against the remediation limits like any other repair.
+func autoUpgrade(ctx context.Context, cfg *config.Config, state *daemonState) error {
+	logger := logger.GetLoggerFromContext(ctx)
+
+	upgrader, err := newNodeUpgrader(ctx, cfg, logger)
+	if err != nil {
+		return err
+	}
+	if len(upgrader.DetectDrift(ctx)) == 0 {
+		return nil
+	}
+
+	breaker, allowed := allowRemediation(ctx, cfg, "auto-upgrade")
+	if !allowed {
+		return nil
+	}
+
+	logger.Info("Installed component versions differ from the configuration, initiating auto-upgrade...")
+	// Draining may take up to its timeout before the first component step renews the lease
+	renewWatchdogLease(ctx)
+	_, err = upgrader.Run(ctx, upgrade.Options{
+		DrainTimeout: upgrade.DefaultDrainTimeout,
+		ReadyTimeout: upgrade.DefaultReadyTimeout,
+	})
+	recordRemediation(ctx, state, breaker, "auto-upgrade", err)
+	if err != nil {
+		return fmt.Errorf("auto-upgrade failed: %w", err)
+	}
+
+	logger.Info("Auto-upgrade completed successfully")
 	return nil
 }
 
@@ -221,18 +2319,23 @@ func removeStatusFile(ctx context.Context) {
 	}
 }
 
-// collectAndWriteStatus collects current node status and writes it to the status file
-func collectAndWriteStatus(ctx context.Context, cfg *config.Config, statusFilePath string) error {
+// collectAndWriteStatus collects current node status, writes it to the status file and records it in state
+func collectAndWriteStatus(ctx context.Context, cfg *config.Config, statusFilePath string, state *daemonState) error {
 	logger := logger.GetLoggerFromContext(ctx)
 
 	// Create status collector
 	collector := status.NewCollector(cfg, logger, Version)
 
 	// Collect comprehensive status
+	start := time.Now()
 	nodeStatus, err := collector.CollectStatus(ctx)
+	state.metrics.ObserveCollection(time.Since(start), err)
 	if err != nil {
 		return fmt.Errorf("failed to collect node status: %w", err)
 	}
+	nodeStatus.Daemon = daemonSettings(cfg)
+	nodeStatus.Drift = state.Drift()
+	nodeStatus.ConfigError = state.ConfigError()
 
 	// Write status to JSON file
 	statusData, err := json.MarshalIndent(nodeStatus, "", "  ")
@@ -251,9 +2354,50 @@ func collectAndWriteStatus(ctx context.Context, cfg *config.Config, statusFilePa
 	}
 
 	logger.Debugf("Status written to %s", statusFilePath)
+	state.setStatus(nodeStatus)
+	state.metrics.SetStatus(nodeStatus)
 	return nil
 }
 
+// writeReport writes the execution report requested on the command line, if any.
+// Reporting problems are logged rather than returned so they never mask the run's own outcome.
+func writeReport(cfg *config.Config, result *bootstrapper.ExecutionResult, operation string, opts reportOptions, logger *logrus.Logger) {
+	if opts.file == "" || result == nil {
+		return
+	}
+
+	if err := report.New(operation, result, Version, cfg.Hash()).WriteFile(opts.file, opts.format); err != nil {
+		logger.Errorf("Failed to write %s report: %v", operation, err)
+		return
+	}
+	logger.Infof("%s report written to %s", operation, opts.file)
+}
+
+// saveDaemonReport keeps the report of an auto-bootstrap run in the agent state directory
+func saveDaemonReport(cfg *config.Config, result *bootstrapper.ExecutionResult, logger *logrus.Logger) {
+	if result == nil || cfg.Agent.StateDir == "" {
+		return
+	}
+
+	path, err := report.New("auto-bootstrap", result, Version, cfg.Hash()).
+		SaveToDir(report.GetReportsDir(cfg.Agent.StateDir), daemonReportsToKeep)
+	if err != nil {
+		logger.Errorf("Failed to save auto-bootstrap report: %v", err)
+		return
+	}
+	logger.Infof("Auto-bootstrap report saved to %s", path)
+}
+
+// recordRunMetrics adds a bootstrap or unbootstrap run to the run metrics the daemon exports
+func recordRunMetrics(cfg *config.Config, operation string, result *bootstrapper.ExecutionResult, logger *logrus.Logger) {
+	if cfg.Agent.StateDir == "" {
+		return
+	}
+	if err := status.RecordRun(cfg.Agent.StateDir, operation, result, time.Now()); err != nil {
+		logger.Warnf("Failed to record %s run metrics: %v", operation, err)
+	}
+}
+
 // handleExecutionResult processes and logs execution results
 func handleExecutionResult(result *bootstrapper.ExecutionResult, operation string, logger *logrus.Logger) error {
 	if result == nil {
@@ -266,8 +2410,8 @@ func handleExecutionResult(result *bootstrapper.ExecutionResult, operation strin
 		return nil
 	}
 
-	if operation == "unbootstrap" {
-		// For unbootstrap, log warnings but don't fail completely
+	if operation == "unbootstrap" || operation == "reset" {
+		// For unbootstrap and reset, log warnings but don't fail completely
 		logger.Warnf("%s completed with some failures: %s (duration: %v)",
 			operation, result.Error, result.Duration)
 		return nil
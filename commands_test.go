@@ -1,7 +1,28 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/arc"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/logger"
+	"go.goms.io/aks/AKSFlexNode/pkg/preflight"
+	"go.goms.io/aks/AKSFlexNode/pkg/remediation"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+	"go.goms.io/aks/AKSFlexNode/pkg/upgrade"
 )
 
 // TestNewAgentCommand verifies that the agent command is created properly with all required fields.
@@ -29,6 +50,161 @@ func TestNewAgentCommand(t *testing.T) {
 	if cmd.RunE == nil {
 		t.Error("RunE should be set")
 	}
+
+	for _, flag := range []string{"restart-from", "force", "rollback-on-failure", "report-file", "report-format", "no-bootstrap"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestNewBootstrapCommand verifies that the one-shot bootstrap command is created with its flags.
+// Test: Creates a bootstrap command and validates its structure and flags
+// Expected: Command should have Use="bootstrap", RunE set, the bootstrap and report flags and the wait flags
+func TestNewBootstrapCommand(t *testing.T) {
+	cmd := NewBootstrapCommand()
+
+	if cmd.Use != "bootstrap" {
+		t.Errorf("Expected Use to be 'bootstrap', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	if !cmd.SilenceErrors {
+		t.Error("Expected errors to be printed by main together with the exit code")
+	}
+
+	for _, flag := range []string{"restart-from", "force", "rollback-on-failure", "report-file", "report-format", "wait-ready", "wait-timeout"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+
+	if got := cmd.Flags().Lookup("wait-timeout").DefValue; got != "10m0s" {
+		t.Errorf("Expected default wait timeout '10m0s', got '%s'", got)
+	}
+}
+
+// TestNewDaemonCommand verifies that the daemon command is created properly.
+// Test: Creates a daemon command and validates its structure
+// Expected: Command should have Use="daemon", non-empty descriptions and RunE set
+func TestNewDaemonCommand(t *testing.T) {
+	cmd := NewDaemonCommand()
+
+	if cmd.Use != "daemon" {
+		t.Errorf("Expected Use to be 'daemon', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+}
+
+// TestBootstrapExitCode verifies the exit codes of failed bootstrap runs.
+// Test: Maps results with failed, timed out and missing steps to exit codes
+// Expected: Runs with a timed out step exit with the timeout code, all others with the failure code
+func TestBootstrapExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		result *bootstrapper.ExecutionResult
+		want   int
+	}{
+		{
+			name:   "no result",
+			result: nil,
+			want:   bootstrapExitFailed,
+		},
+		{
+			name: "failed step",
+			result: &bootstrapper.ExecutionResult{StepResults: []bootstrapper.StepResult{
+				{StepName: "ArcInstaller", Success: true},
+				{StepName: "ContainerdInstaller", Error: "download failed"},
+			}},
+			want: bootstrapExitFailed,
+		},
+		{
+			name: "timed out step",
+			result: &bootstrapper.ExecutionResult{StepResults: []bootstrapper.StepResult{
+				{StepName: "ArcInstaller", Success: true},
+				{StepName: "KubeletInstaller", Error: "timed out after 10m0s", TimedOut: true},
+			}},
+			want: bootstrapExitTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := bootstrapExitCode(tt.result); got != tt.want {
+				t.Errorf("bootstrapExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCanResumeDaemon verifies when a restarted agent skips its initial bootstrap.
+// Test: Restarts the agent, as self-update does, against journals, status health and resume options
+// Expected: Only a node that completed its bootstrap with the current configuration and was last
+// reported healthy resumes the daemon, so a self-update restart does not run the ServicesDisabled
+// step that stops kubelet
+func TestCanResumeDaemon(t *testing.T) {
+	ctx := logger.SetupLogger(context.Background(), "info", "")
+	logger.GetLoggerFromContext(ctx).SetOutput(io.Discard)
+
+	tests := []struct {
+		name       string
+		journal    *bootstrapper.JournalState
+		changeCfg  bool
+		unhealthy  bool
+		resumeOpts bootstrapper.ResumeOptions
+		wantResume bool
+	}{
+		{name: "self-update restart of a healthy node", journal: &bootstrapper.JournalState{Completed: true}, wantResume: true},
+		{name: "first start without journal"},
+		{name: "interrupted bootstrap", journal: &bootstrapper.JournalState{}},
+		{name: "changed configuration", journal: &bootstrapper.JournalState{Completed: true}, changeCfg: true},
+		{name: "unhealthy node", journal: &bootstrapper.JournalState{Completed: true}, unhealthy: true},
+		{name: "forced bootstrap", journal: &bootstrapper.JournalState{Completed: true},
+			resumeOpts: bootstrapper.ResumeOptions{Force: true}},
+		{name: "restart from a step", journal: &bootstrapper.JournalState{Completed: true},
+			resumeOpts: bootstrapper.ResumeOptions{RestartFrom: "KubeletInstaller"}},
+	}
+
+	oldNeedsBootstrap := needsBootstrap
+	defer func() { needsBootstrap = oldNeedsBootstrap }()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Agent: config.AgentConfig{StateDir: t.TempDir()}}
+			if tt.journal != nil {
+				tt.journal.ConfigHash = cfg.Hash()
+				data, err := json.Marshal(tt.journal)
+				if err != nil {
+					t.Fatalf("Failed to marshal journal: %v", err)
+				}
+				if err := os.WriteFile(bootstrapper.GetJournalPath(cfg.Agent.StateDir), data, 0o600); err != nil {
+					t.Fatalf("Failed to write journal: %v", err)
+				}
+			}
+			if tt.changeCfg {
+				cfg.Node.MaxPods = 42
+			}
+			needsBootstrap = func(context.Context, *config.Config) bool { return tt.unhealthy }
+
+			if got := canResumeDaemon(ctx, cfg, tt.resumeOpts); got != tt.wantResume {
+				t.Errorf("canResumeDaemon() = %v, want %v", got, tt.wantResume)
+			}
+		})
+	}
 }
 
 // TestNewUnbootstrapCommand verifies that the unbootstrap command is created properly with all required fields.
@@ -56,6 +232,756 @@ func TestNewUnbootstrapCommand(t *testing.T) {
 	if cmd.RunE == nil {
 		t.Error("RunE should be set")
 	}
+	for _, flag := range []string{"report-file", "report-format"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestNewResetCommand verifies that the reset command is created properly with all required fields.
+// Test: Creates a reset command and validates its structure and flags
+// Expected: Command should have Use="reset", descriptions, RunE, report flags and the Azure resources file flag
+func TestNewResetCommand(t *testing.T) {
+	cmd := NewResetCommand()
+
+	if cmd.Use != "reset" {
+		t.Errorf("Expected Use to be 'reset', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	for _, flag := range []string{"report-file", "report-format", "azure-resources-file"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestPrintAzureResources verifies the list of Azure resources a reset leaves behind.
+// Test: Prints two resources with and without the file they were written to
+// Expected: Every resource appears with its scope and cleanup command; the file is named when written
+func TestPrintAzureResources(t *testing.T) {
+	resources := []arc.AzureResource{
+		{
+			Type:           "Microsoft.Authorization/roleAssignments",
+			Name:           "Reader (Target Cluster)",
+			Scope:          "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks",
+			CleanupCommand: "az role assignment delete --assignee x --role y --scope z",
+		},
+		{
+			Type:           "Microsoft.HybridCompute/machines",
+			Name:           "node-1",
+			Scope:          "/subscriptions/sub/resourceGroups/rg",
+			CleanupCommand: "az connectedmachine delete --resource-group rg --name node-1 --yes",
+		},
+	}
+
+	var buf strings.Builder
+	printAzureResources(&buf, resources, "/var/lib/aks-flex-node/azure-resources.json")
+	output := buf.String()
+	for _, want := range []string{
+		`Microsoft.Authorization/roleAssignments "Reader (Target Cluster)"`,
+		"az role assignment delete --assignee x --role y --scope z",
+		`Microsoft.HybridCompute/machines "node-1"`,
+		"az connectedmachine delete --resource-group rg --name node-1 --yes",
+		"written to /var/lib/aks-flex-node/azure-resources.json",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	printAzureResources(&buf, resources, "")
+	if strings.Contains(buf.String(), "written to") {
+		t.Errorf("Expected no file to be named, got:\n%s", buf.String())
+	}
+}
+
+// TestNewPlanCommand verifies that the plan command is created properly with all required fields.
+// Test: Creates a plan command and validates its structure and flags
+// Expected: Command should be non-nil with Use="plan", non-empty descriptions, RunE set and output/unbootstrap flags defined
+func TestNewPlanCommand(t *testing.T) {
+	cmd := NewPlanCommand()
+
+	if cmd == nil {
+		t.Fatal("NewPlanCommand should not return nil")
+	}
+
+	if cmd.Use != "plan" {
+		t.Errorf("Expected Use to be 'plan', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" {
+		t.Error("Short description should not be empty")
+	}
+
+	if cmd.Long == "" {
+		t.Error("Long description should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	for _, flag := range []string{"output", "unbootstrap", "reset", "restart-from", "force"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+
+	if got := cmd.Flags().Lookup("output").DefValue; got != "text" {
+		t.Errorf("Expected default output format 'text', got '%s'", got)
+	}
+}
+
+// TestNewComponentCommand verifies that the component command exposes its subcommands.
+// Test: Creates a component command and looks up each subcommand
+// Expected: list, install, uninstall and status exist with RunE set; install, uninstall and status take a component name
+func TestNewComponentCommand(t *testing.T) {
+	cmd := NewComponentCommand()
+
+	if cmd == nil {
+		t.Fatal("NewComponentCommand should not return nil")
+	}
+
+	if cmd.Use != "component" {
+		t.Errorf("Expected Use to be 'component', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	for _, name := range []string{"list", "install", "uninstall", "status"} {
+		sub, _, err := cmd.Find([]string{name})
+		if err != nil || sub == nil || sub.Name() != name {
+			t.Errorf("Expected subcommand %s, got %v (err %v)", name, sub, err)
+			continue
+		}
+		if sub.RunE == nil {
+			t.Errorf("Expected subcommand %s to have RunE set", name)
+		}
+		if name != "list" && sub.Args(sub, nil) == nil {
+			t.Errorf("Expected subcommand %s to require a component name", name)
+		}
+	}
+
+	install, _, _ := cmd.Find([]string{"install"})
+	for _, flag := range []string{"skip-prerequisites", "report-file", "report-format"} {
+		if install.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected install flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestRunComponentList verifies the component list output.
+// Test: Renders the component list
+// Expected: Every registered component appears in the output
+func TestRunComponentList(t *testing.T) {
+	var out strings.Builder
+	if err := runComponentList(&out); err != nil {
+		t.Fatalf("runComponentList failed: %v", err)
+	}
+	for _, name := range bootstrapper.ComponentNames() {
+		if !strings.Contains(out.String(), name) {
+			t.Errorf("Expected component %s in list output:\n%s", name, out.String())
+		}
+	}
+}
+
+// TestNewStatusCommand verifies that the status command is created with its output flags.
+// Test: Creates a status command and validates its structure
+// Expected: Command should have Use="status", RunE set, silenced usage, and --output/--live flags
+func TestNewStatusCommand(t *testing.T) {
+	cmd := NewStatusCommand()
+
+	if cmd.Use != "status" {
+		t.Errorf("Expected Use to be 'status', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	if !cmd.SilenceUsage {
+		t.Error("Usage should not be printed for unhealthy exit codes")
+	}
+
+	for _, flag := range []string{"output", "live"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+
+	if got := cmd.Flags().Lookup("output").DefValue; got != "table" {
+		t.Errorf("Expected default output format 'table', got '%s'", got)
+	}
+}
+
+// TestPrintHealthReport verifies the status output formats.
+// Test: Prints a stale, degraded health report as table, JSON and YAML
+// Expected: Every format includes the health and the daemon schedule, and the table marks the status as stale
+func TestPrintHealthReport(t *testing.T) {
+	healthReport := &status.HealthReport{
+		Health:     status.HealthDegraded,
+		Reasons:    []string{"containerd is not running"},
+		Source:     status.SourceDaemon,
+		StatusFile: "/run/aks-flex-node/status.json",
+		AgeSeconds: 600,
+		Stale:      true,
+		Status: &status.NodeStatus{
+			KubeletVersion: "v1.32.7",
+			KubeletReady:   "Ready",
+			Daemon: &status.DaemonSettings{
+				StatusInterval:      "1m0s",
+				RemediationInterval: "2m0s",
+				StaleThreshold:      "5m0s",
+				Jitter:              "30s",
+				InitialDelay:        "0s",
+			},
+		},
+	}
+
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{output: "table", want: []string{"HEALTH", "degraded", "containerd is not running", "10m0s ago, stale", "remediation every 2m0s", "jitter 30s"}},
+		{output: "json", want: []string{`"health": "degraded"`, `"stale": true`, `"statusInterval": "1m0s"`}},
+		{output: "yaml", want: []string{"health: degraded", "stale: true", "kubeletVersion: v1.32.7", "staleThreshold: 5m0s"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			var out strings.Builder
+			if err := printHealthReport(&out, healthReport, tt.output); err != nil {
+				t.Fatalf("printHealthReport failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
+
+// TestJitteredInterval verifies the randomized daemon intervals.
+// Test: Computes intervals with and without jitter many times
+// Expected: Without jitter the interval is unchanged, with jitter it stays within interval and interval plus jitter
+func TestJitteredInterval(t *testing.T) {
+	if got := jitteredInterval(time.Minute, 0); got != time.Minute {
+		t.Errorf("Expected no jitter to keep the interval, got %s", got)
+	}
+
+	for i := 0; i < 1000; i++ {
+		got := jitteredInterval(time.Minute, 30*time.Second)
+		if got < time.Minute || got > 90*time.Second {
+			t.Fatalf("Expected interval within [1m, 1m30s], got %s", got)
+		}
+	}
+}
+
+// TestNewDoctorCommand verifies that the doctor command is created with its flags.
+// Test: Creates a doctor command and validates its structure
+// Expected: Command should have Use="doctor", RunE set, and --output/--check flags
+func TestNewDoctorCommand(t *testing.T) {
+	cmd := NewDoctorCommand()
+
+	if cmd.Use != "doctor" {
+		t.Errorf("Expected Use to be 'doctor', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	for _, flag := range []string{"output", "check"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestPrintPreflightReport verifies the doctor text output.
+// Test: Prints a report with a passing and a failing check
+// Expected: Both checks, the remediation of the failing check, and the summary are printed
+func TestPrintPreflightReport(t *testing.T) {
+	preflightReport := &preflight.Report{
+		Results: []preflight.Result{
+			{Name: "swap", Status: preflight.StatusFail, Message: "swap is enabled on /swap.img", Remediation: "Run 'swapoff -a'"},
+			{Name: "cgroup", Status: preflight.StatusPass, Message: "cgroup v2 is in use"},
+		},
+		Passed: 1,
+		Failed: 1,
+	}
+
+	var out strings.Builder
+	if err := printPreflightReport(&out, preflightReport, "text"); err != nil {
+		t.Fatalf("printPreflightReport failed: %v", err)
+	}
+	for _, want := range []string{"FAIL", "swap is enabled on /swap.img", "-> Run 'swapoff -a'", "PASS", "1 passed, 0 warnings, 1 failed, 0 skipped"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestNewConfigCommand verifies that the config command exposes validate and show.
+// Test: Creates the config command and looks up its subcommands and flags
+// Expected: validate and show exist with RunE set, and show has --effective and --output flags
+func TestNewConfigCommand(t *testing.T) {
+	cmd := NewConfigCommand()
+
+	if cmd.Use != "config" {
+		t.Errorf("Expected Use to be 'config', got '%s'", cmd.Use)
+	}
+
+	for _, name := range []string{"validate", "show"} {
+		sub, _, err := cmd.Find([]string{name})
+		if err != nil || sub.Name() != name {
+			t.Fatalf("Expected subcommand %s, got %v", name, err)
+		}
+		if sub.RunE == nil {
+			t.Errorf("RunE should be set for %s", name)
+		}
+	}
+
+	show, _, _ := cmd.Find([]string{"show"})
+	for _, flag := range []string{"effective", "output"} {
+		if show.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined on show", flag)
+		}
+	}
+}
+
+// TestNewSelfUpdateCommand verifies that the self-update command is created with its flags.
+// Test: Creates a self-update command and validates its structure
+// Expected: Command should have Use="self-update", RunE set, and check, force, restart and timeout flags
+func TestNewSelfUpdateCommand(t *testing.T) {
+	cmd := NewSelfUpdateCommand()
+
+	if cmd.Use != "self-update" {
+		t.Errorf("Expected Use to be 'self-update', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	for _, flag := range []string{"check", "force", "no-restart", "health-timeout"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestNewAdminCommand verifies that the admin command is created with its subcommands.
+// Test: Creates an admin command and looks up each subcommand
+// Expected: Every subcommand exists with RunE set, and log-level requires exactly one argument
+func TestNewAdminCommand(t *testing.T) {
+	cmd := NewAdminCommand()
+
+	if cmd.Use != "admin" {
+		t.Errorf("Expected Use to be 'admin', got '%s'", cmd.Use)
+	}
+
+	for _, name := range []string{"steps", "reconcile", "pause", "resume", "log-level"} {
+		sub, _, err := cmd.Find([]string{name})
+		if err != nil || sub.Name() != name {
+			t.Fatalf("Expected subcommand %s, got %v", name, err)
+		}
+		if sub.RunE == nil {
+			t.Errorf("RunE should be set for %s", name)
+		}
+	}
+
+	steps, _, _ := cmd.Find([]string{"steps"})
+	if steps.Flags().Lookup("output") == nil {
+		t.Error("Expected flag --output to be defined on steps")
+	}
+
+	logLevel, _, _ := cmd.Find([]string{"log-level"})
+	if err := logLevel.Args(logLevel, nil); err == nil {
+		t.Error("Expected log-level to require a level argument")
+	}
+}
+
+// TestDaemonState verifies the daemon state shared with the admin API.
+// Test: Requests reconciles twice before the loop consumes one, then pauses and resumes
+// Expected: The second request merges with the pending one, and the paused flag follows the calls
+func TestDaemonState(t *testing.T) {
+	state := newDaemonState(nil)
+
+	if !state.RequestReconcile() {
+		t.Error("Expected the first reconcile request to be accepted")
+	}
+	if state.RequestReconcile() {
+		t.Error("Expected a second request to merge with the pending one")
+	}
+	<-state.reconcile
+	if !state.RequestReconcile() {
+		t.Error("Expected a request to be accepted after the pending one ran")
+	}
+
+	state.SetPaused(true)
+	if !state.Paused() {
+		t.Error("Expected state to be paused")
+	}
+	state.SetPaused(false)
+	if state.Paused() {
+		t.Error("Expected state to be resumed")
+	}
+}
+
+// TestReloadDaemonConfig verifies that the daemon rejects an invalid configuration and accepts a valid one.
+// Test: Reloads an invalid configuration file, then a valid one that only changes the log level
+// Expected: The invalid file keeps the previous configuration and records the error; the valid file
+// replaces the configuration and clears the error
+func TestReloadDaemonConfig(t *testing.T) {
+	const validConfig = `{
+		"azure": {
+			"subscriptionId": "12345678-1234-1234-1234-123456789012",
+			"tenantId": "12345678-1234-1234-1234-123456789012",
+			"cloud": "AzurePublicCloud",
+			"targetCluster": {
+				"resourceId": "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+				"location": "eastus"
+			}
+		},
+		"agent": {"logLevel": "%s"}
+	}`
+
+	oldPath := configPath
+	defer func() { configPath = oldPath }()
+	configPath = filepath.Join(t.TempDir(), "config.json")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+	}
+
+	writeConfig(fmt.Sprintf(validConfig, "info"))
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("Failed to load config: %v", err)
+	}
+	ctx := logger.SetupLogger(context.Background(), "info", "")
+	testLogger := logger.GetLoggerFromContext(ctx)
+	testLogger.SetOutput(io.Discard)
+	state := newDaemonState(nil)
+
+	writeConfig(fmt.Sprintf(validConfig, "verbose"))
+	if got := reloadDaemonConfig(ctx, cfg, state); got != cfg {
+		t.Error("Expected the previous configuration to stay active after an invalid reload")
+	}
+	if !strings.Contains(state.ConfigError(), "agent.logLevel") {
+		t.Errorf("Expected the validation error to be recorded, got %q", state.ConfigError())
+	}
+
+	writeConfig(fmt.Sprintf(validConfig, "debug"))
+	got := reloadDaemonConfig(ctx, cfg, state)
+	if got == cfg || got.Agent.LogLevel != "debug" {
+		t.Errorf("Expected the reloaded configuration to be active, got log level %q", got.Agent.LogLevel)
+	}
+	if state.ConfigError() != "" {
+		t.Errorf("Expected the error to be cleared, got %q", state.ConfigError())
+	}
+	if testLogger.GetLevel() != logrus.DebugLevel {
+		t.Errorf("Expected the log level to change to debug, got %s", testLogger.GetLevel())
+	}
+}
+
+// TestNewRemediationCommand verifies that the remediation command is created with its subcommands.
+// Test: Creates a remediation command and looks up the status and reset subcommands
+// Expected: Both subcommands exist with RunE set, and status has an --output flag
+func TestNewRemediationCommand(t *testing.T) {
+	cmd := NewRemediationCommand()
+
+	if cmd.Use != "remediation" {
+		t.Errorf("Expected Use to be 'remediation', got '%s'", cmd.Use)
+	}
+
+	for _, name := range []string{"status", "reset"} {
+		sub, _, err := cmd.Find([]string{name})
+		if err != nil || sub.Name() != name {
+			t.Fatalf("Expected subcommand %s, got %v", name, err)
+		}
+		if sub.RunE == nil {
+			t.Errorf("RunE should be set for %s", name)
+		}
+	}
+
+	statusCmd, _, _ := cmd.Find([]string{"status"})
+	if statusCmd.Flags().Lookup("output") == nil {
+		t.Error("Expected flag --output to be defined on status")
+	}
+}
+
+// TestRemediationReset verifies that a tripped remediation breaker is shown and can be reset.
+// Test: Writes a tripped breaker state, prints it, resets it and prints it again
+// Expected: The first status reports the stop and its reason, the reset clears the persisted state
+func TestRemediationReset(t *testing.T) {
+	dir := t.TempDir()
+	stateDir := filepath.Join(dir, "state")
+	content := fmt.Sprintf(`{
+		"azure": {
+			"subscriptionId": "12345678-1234-1234-1234-123456789012",
+			"tenantId": "12345678-1234-1234-1234-123456789012",
+			"cloud": "AzurePublicCloud",
+			"targetCluster": {
+				"resourceId": "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+				"location": "eastus"
+			}
+		},
+		"agent": {"stateDir": %q, "adminSocket": %q}
+	}`, stateDir, filepath.Join(dir, "admin.sock"))
+
+	oldPath := configPath
+	defer func() { configPath = oldPath }()
+	configPath = filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.MkdirAll(stateDir, 0o750); err != nil {
+		t.Fatalf("Failed to create state dir: %v", err)
+	}
+	tripped := `{"tripped": true, "trippedAt": "2026-01-02T03:04:05Z", "reason": "3 remediation attempts within 1h0m0s did not keep the node healthy"}`
+	if err := os.WriteFile(remediation.Path(stateDir), []byte(tripped), 0o600); err != nil {
+		t.Fatalf("Failed to write remediation state: %v", err)
+	}
+
+	var out strings.Builder
+	if err := runRemediationStatus(&out, "table"); err != nil {
+		t.Fatalf("Unexpected status error: %v", err)
+	}
+	if !strings.Contains(out.String(), "stopped") || !strings.Contains(out.String(), "did not keep the node healthy") {
+		t.Errorf("Expected the stopped breaker and its reason, got:\n%s", out.String())
+	}
+
+	ctx := logger.SetupLogger(context.Background(), "info", "")
+	logger.GetLoggerFromContext(ctx).SetOutput(io.Discard)
+	out.Reset()
+	if err := runRemediationReset(ctx, &out); err != nil {
+		t.Fatalf("Unexpected reset error: %v", err)
+	}
+	if strings.Contains(out.String(), "Reconcile scheduled") {
+		t.Error("Expected no reconcile without a running daemon")
+	}
+
+	state, err := remediation.ReadState(remediation.Path(stateDir))
+	if err != nil {
+		t.Fatalf("Failed to read remediation state: %v", err)
+	}
+	if state.Tripped || state.Reason != "" {
+		t.Errorf("Expected a reset breaker, got %+v", state)
+	}
+}
+
+// TestWatchdogLease verifies that the watchdog is pinged only while long work makes progress.
+// Test: Enables a short systemd watchdog on a test notify socket, starts a lease with a short stall
+// timeout, lets it stall and then renews it
+// Expected: WATCHDOG=1 pings arrive until the stall timeout passes, stop, and resume on renewal
+func TestWatchdogLease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	oldStallTimeout := watchdogStallTimeout
+	defer func() { watchdogStallTimeout = oldStallTimeout }()
+	watchdogStallTimeout = 200 * time.Millisecond
+
+	ctx := logger.SetupLogger(context.Background(), "info", "")
+	logger.GetLoggerFromContext(ctx).SetOutput(io.Discard)
+	ctx, endLease := startWatchdogLease(ctx)
+	defer endLease()
+
+	// readPing waits up to timeout for the next notification
+	buffer := make([]byte, 64)
+	readPing := func(timeout time.Duration) (string, error) {
+		if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+			t.Fatalf("Failed to set read deadline: %v", err)
+		}
+		n, err := conn.Read(buffer)
+		return string(buffer[:n]), err
+	}
+
+	if got, err := readPing(5 * time.Second); err != nil || got != "WATCHDOG=1" {
+		t.Fatalf("Expected a watchdog ping, got %q, %v", got, err)
+	}
+
+	// Drain the pings sent before the lease stalled, then expect silence
+	time.Sleep(watchdogStallTimeout)
+	for {
+		if _, err := readPing(50 * time.Millisecond); err != nil {
+			break
+		}
+	}
+	if got, err := readPing(100 * time.Millisecond); err == nil {
+		t.Fatalf("Expected no pings after the lease stalled, got %q", got)
+	}
+
+	renewWatchdogLease(ctx)
+	if got, err := readPing(5 * time.Second); err != nil || got != "WATCHDOG=1" {
+		t.Errorf("Expected a watchdog ping after renewal, got %q, %v", got, err)
+	}
+}
+
+// fakeUpgrader reports a version drift and counts upgrade runs
+type fakeUpgrader struct {
+	runs int
+	err  error
+}
+
+func (f *fakeUpgrader) DetectDrift(ctx context.Context) []upgrade.Drift {
+	return []upgrade.Drift{{Component: "kubelet", Installed: "1.31.0", Desired: "1.32.7"}}
+}
+
+func (f *fakeUpgrader) Run(ctx context.Context, opts upgrade.Options) (*upgrade.Result, error) {
+	f.runs++
+	return &upgrade.Result{}, f.err
+}
+
+// TestAutoUpgradeRemediationLimits verifies that auto-upgrade is subject to the remediation breaker.
+// Test: Runs auto-upgrade with a tripped breaker, then with a closed breaker and a failing upgrade
+// Expected: The tripped breaker skips the upgrade; the failed upgrade is recorded and backs off
+func TestAutoUpgradeRemediationLimits(t *testing.T) {
+	stateDir := t.TempDir()
+	cfg := &config.Config{Agent: config.AgentConfig{
+		StateDir: stateDir,
+		Remediation: config.RemediationConfig{
+			MaxAttempts: 3, Window: time.Hour, Backoff: 2 * time.Minute, MaxBackoff: 30 * time.Minute,
+		},
+	}}
+	ctx := logger.SetupLogger(context.Background(), "info", "")
+	logger.GetLoggerFromContext(ctx).SetOutput(io.Discard)
+
+	upgrader := &fakeUpgrader{err: errors.New("drain timed out")}
+	oldNewUpgrader := newNodeUpgrader
+	defer func() { newNodeUpgrader = oldNewUpgrader }()
+	newNodeUpgrader = func(context.Context, *config.Config, *logrus.Logger) (nodeUpgrader, error) { return upgrader, nil }
+
+	tripped := `{"tripped": true, "reason": "3 remediation attempts within 1h0m0s did not keep the node healthy"}`
+	if err := os.WriteFile(remediation.Path(stateDir), []byte(tripped), 0o600); err != nil {
+		t.Fatalf("Failed to write remediation state: %v", err)
+	}
+	if err := autoUpgrade(ctx, cfg, newDaemonState(nil)); err != nil {
+		t.Fatalf("Expected a skipped upgrade without error, got %v", err)
+	}
+	if upgrader.runs != 0 {
+		t.Fatalf("Expected a tripped breaker to skip the upgrade, ran %d times", upgrader.runs)
+	}
+
+	if err := os.Remove(remediation.Path(stateDir)); err != nil {
+		t.Fatalf("Failed to reset remediation state: %v", err)
+	}
+	if err := autoUpgrade(ctx, cfg, newDaemonState(nil)); err == nil {
+		t.Fatal("Expected the failed upgrade to be reported")
+	}
+	if upgrader.runs != 1 {
+		t.Fatalf("Expected one upgrade run, got %d", upgrader.runs)
+	}
+	state, err := remediation.ReadState(remediation.Path(stateDir))
+	if err != nil {
+		t.Fatalf("Failed to read remediation state: %v", err)
+	}
+	if state.ConsecutiveFailures != 1 || state.NextAttempt == nil {
+		t.Errorf("Expected the failure to be recorded with a backoff, got %+v", state)
+	}
+
+	// The next check falls within the backoff and must not drain the node again
+	if err := autoUpgrade(ctx, cfg, newDaemonState(nil)); err != nil {
+		t.Fatalf("Expected a skipped upgrade without error, got %v", err)
+	}
+	if upgrader.runs != 1 {
+		t.Errorf("Expected the backoff to skip the upgrade, got %d runs", upgrader.runs)
+	}
+}
+
+// TestNewUpgradeCommand verifies that the upgrade command is created with its flags.
+// Test: Creates an upgrade command and validates its structure
+// Expected: Command should have Use="upgrade", RunE set, and dry-run, drain and timeout flags
+func TestNewUpgradeCommand(t *testing.T) {
+	cmd := NewUpgradeCommand()
+
+	if cmd.Use != "upgrade" {
+		t.Errorf("Expected Use to be 'upgrade', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	for _, flag := range []string{"dry-run", "skip-drain", "drain-timeout", "ready-timeout"} {
+		if cmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+		}
+	}
+}
+
+// TestNewCollectLogsCommand verifies that the collect-logs command is created with its flags.
+// Test: Creates a collect-logs command and validates its structure and defaults
+// Expected: Command should have Use="collect-logs", RunE set, and output-dir, max-size and since flags
+func TestNewCollectLogsCommand(t *testing.T) {
+	cmd := NewCollectLogsCommand()
+
+	if cmd.Use != "collect-logs" {
+		t.Errorf("Expected Use to be 'collect-logs', got '%s'", cmd.Use)
+	}
+
+	if cmd.Short == "" || cmd.Long == "" {
+		t.Error("Descriptions should not be empty")
+	}
+
+	if cmd.RunE == nil {
+		t.Error("RunE should be set")
+	}
+
+	defaults := map[string]string{
+		"output-dir": ".",
+		"max-size":   "100",
+		"since":      "24h0m0s",
+	}
+	for flag, want := range defaults {
+		f := cmd.Flags().Lookup(flag)
+		if f == nil {
+			t.Errorf("Expected flag --%s to be defined", flag)
+			continue
+		}
+		if f.DefValue != want {
+			t.Errorf("Expected --%s default %q, got %q", flag, want, f.DefValue)
+		}
+	}
 }
 
 // TestNewVersionCommand verifies that the version command is created properly with all required fields.
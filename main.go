@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -30,7 +31,20 @@ func main() {
 
 	// Add commands
 	rootCmd.AddCommand(NewAgentCommand())
+	rootCmd.AddCommand(NewBootstrapCommand())
+	rootCmd.AddCommand(NewDaemonCommand())
 	rootCmd.AddCommand(NewUnbootstrapCommand())
+	rootCmd.AddCommand(NewResetCommand())
+	rootCmd.AddCommand(NewPlanCommand())
+	rootCmd.AddCommand(NewComponentCommand())
+	rootCmd.AddCommand(NewStatusCommand())
+	rootCmd.AddCommand(NewAdminCommand())
+	rootCmd.AddCommand(NewRemediationCommand())
+	rootCmd.AddCommand(NewDoctorCommand())
+	rootCmd.AddCommand(NewConfigCommand())
+	rootCmd.AddCommand(NewUpgradeCommand())
+	rootCmd.AddCommand(NewSelfUpdateCommand())
+	rootCmd.AddCommand(NewCollectLogsCommand())
 	rootCmd.AddCommand(NewVersionCommand())
 
 	// Set up context with signal handling
@@ -54,6 +68,19 @@ func main() {
 			return nil
 		}
 
+		// Support bundles are collected even without a usable config
+		if cmd.Name() == "collect-logs" {
+			return nil
+		}
+
+		// Config subcommands load the config themselves to report problems instead of failing on them
+		if cmd.Parent() != nil && cmd.Parent().Name() == "config" {
+			if configPath == "" {
+				return fmt.Errorf("config path is required for config %s command", cmd.Name())
+			}
+			return nil
+		}
+
 		// For other commands, config is required
 		if configPath == "" {
 			return fmt.Errorf("config path is required for %s command", cmd.Name())
@@ -73,6 +100,13 @@ func main() {
 
 	// Execute command with context
 	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			if exitErr.err != nil {
+				fmt.Fprintln(os.Stderr, exitErr.err)
+			}
+			os.Exit(exitErr.code)
+		}
 		fmt.Fprintf(os.Stderr, "Command execution failed: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,118 @@
+package adminapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+)
+
+// Client talks to the admin API of a running daemon
+type Client struct {
+	http *http.Client
+}
+
+// NewClient creates a client for the daemon listening on socketPath
+func NewClient(socketPath string) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: clientTimeout,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var dialer net.Dialer
+					return dialer.DialContext(ctx, "unix", socketPath)
+				},
+			},
+		},
+	}
+}
+
+// Status returns the node status most recently collected by the daemon
+func (c *Client) Status(ctx context.Context) (*status.NodeStatus, error) {
+	var nodeStatus status.NodeStatus
+	if err := c.do(ctx, http.MethodGet, "/status", nil, &nodeStatus); err != nil {
+		return nil, err
+	}
+	return &nodeStatus, nil
+}
+
+// Steps returns the result of the daemon's most recent bootstrap run
+func (c *Client) Steps(ctx context.Context) (*bootstrapper.ExecutionResult, error) {
+	var result bootstrapper.ExecutionResult
+	if err := c.do(ctx, http.MethodGet, "/steps", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Reconcile asks the daemon to check and repair the node now
+func (c *Client) Reconcile(ctx context.Context) (*ReconcileResponse, error) {
+	var response ReconcileResponse
+	if err := c.do(ctx, http.MethodPost, "/reconcile", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// SetPaused pauses or resumes the daemon's automatic remediation
+func (c *Client) SetPaused(ctx context.Context, paused bool) error {
+	path := "/resume"
+	if paused {
+		path = "/pause"
+	}
+	return c.do(ctx, http.MethodPost, path, nil, &RemediationResponse{})
+}
+
+// SetLogLevel changes the daemon's log level
+func (c *Client) SetLogLevel(ctx context.Context, level string) (string, error) {
+	var response LogLevel
+	if err := c.do(ctx, http.MethodPut, "/loglevel", LogLevel{Level: level}, &response); err != nil {
+		return "", err
+	}
+	return response.Level, nil
+}
+
+// do sends a request with an optional JSON body and decodes a successful JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	// The host is ignored; every request goes to the socket
+	req, err := http.NewRequestWithContext(ctx, method, "http://aks-flex-node"+path, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("admin API request %s %s failed: %w", method, path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var apiErr errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Error == "" {
+			apiErr.Error = resp.Status
+		}
+		return fmt.Errorf("admin API request %s %s failed: %s", method, path, apiErr.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode admin API response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package adminapi
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+)
+
+const (
+	// socketName is the file name of the admin socket next to the daemon's status file
+	socketName = "admin.sock"
+
+	// socketMode restricts the socket to the daemon user and its group; connecting requires write access
+	socketMode os.FileMode = 0660
+
+	// clientTimeout bounds a single admin API request
+	clientTimeout = 10 * time.Second
+)
+
+// socketCandidates are the default socket locations of a daemon running as the service user and
+// of one running as a regular user, in the order clients look for them
+var socketCandidates = []string{
+	filepath.Join("/run/aks-flex-node", socketName),
+	filepath.Join("/tmp/aks-flex-node", socketName),
+}
+
+// SocketPath returns the socket the daemon listens on: agent.adminSocket if set,
+// otherwise admin.sock in the directory of the status file
+func SocketPath(cfg *config.Config) string {
+	if cfg != nil && cfg.Agent.AdminSocket != "" {
+		return cfg.Agent.AdminSocket
+	}
+	return filepath.Join(filepath.Dir(status.GetStatusFilePath()), socketName)
+}
+
+// FindSocket returns the socket of a running daemon and whether one exists. A configured
+// agent.adminSocket is the only candidate; otherwise the default locations are searched.
+func FindSocket(cfg *config.Config) (string, bool) {
+	candidates := socketCandidates
+	if cfg != nil && cfg.Agent.AdminSocket != "" {
+		candidates = []string{cfg.Agent.AdminSocket}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && info.Mode()&os.ModeSocket != 0 {
+			return candidate, true
+		}
+	}
+	return "", false
+}
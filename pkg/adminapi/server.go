@@ -0,0 +1,258 @@
+// Package adminapi serves a local HTTP API for the running agent daemon on a Unix socket.
+// Access is controlled by the socket's file permissions: only the daemon user and members of
+// its group can connect.
+package adminapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+)
+
+// Controller is the running daemon as seen by the admin API
+type Controller interface {
+	// Status returns the most recently collected node status, nil before the first collection
+	Status() *status.NodeStatus
+	// LastResult returns the result of the most recent bootstrap run, nil if none ran yet
+	LastResult() *bootstrapper.ExecutionResult
+	// RequestReconcile asks the daemon to check and repair the node now; it returns false
+	// if a requested reconcile is still pending
+	RequestReconcile() bool
+	// SetPaused pauses or resumes automatic remediation
+	SetPaused(paused bool)
+	// Paused reports whether automatic remediation is paused
+	Paused() bool
+}
+
+// ReconcileResponse is the answer to POST /reconcile
+type ReconcileResponse struct {
+	Accepted bool   `json:"accepted"`
+	Message  string `json:"message"`
+}
+
+// RemediationResponse is the answer to POST /pause and POST /resume
+type RemediationResponse struct {
+	Paused bool `json:"paused"`
+}
+
+// LogLevel is the body of GET and PUT /loglevel
+type LogLevel struct {
+	Level string `json:"level"`
+}
+
+// errorResponse is the body of failed requests
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the admin API of a daemon
+type Server struct {
+	mu         sync.Mutex
+	config     *config.Config
+	logger     *logrus.Logger
+	controller Controller
+	path       string
+	listener   net.Listener
+	server     *http.Server
+	now        func() time.Time
+}
+
+// NewServer creates an admin API server for controller; call Listen to start accepting connections
+func NewServer(cfg *config.Config, logger *logrus.Logger, controller Controller) *Server {
+	s := &Server{
+		config:     cfg,
+		logger:     logger,
+		controller: controller,
+		now:        time.Now,
+	}
+	s.server = &http.Server{Handler: s.Handler(), ReadHeaderTimeout: clientTimeout}
+	return s
+}
+
+// SetConfig replaces the configuration used to assess the node, after the daemon reloaded it
+func (s *Server) SetConfig(cfg *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = cfg
+}
+
+// currentConfig returns the configuration used to assess the node
+func (s *Server) currentConfig() *config.Config {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// Listen creates the socket at path with owner and group access only, replacing a stale socket
+// left by an earlier daemon
+func (s *Server) Listen(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create admin socket directory: %w", err)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale admin socket %s: %w", path, err)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("failed to listen on admin socket %s: %w", path, err)
+	}
+	if err := os.Chmod(path, socketMode); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to restrict admin socket %s: %w", path, err)
+	}
+
+	s.path = path
+	s.listener = listener
+	return nil
+}
+
+// Serve handles requests until Close is called
+func (s *Server) Serve() error {
+	s.logger.Infof("Admin API listening on %s", s.path)
+	if err := s.server.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("admin API failed: %w", err)
+	}
+	return nil
+}
+
+// Close stops the server and removes the socket
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout)
+	defer cancel()
+	err := s.server.Shutdown(ctx)
+	if s.path != "" {
+		if rerr := os.Remove(s.path); rerr != nil && !errors.Is(rerr, os.ErrNotExist) {
+			err = errors.Join(err, rerr)
+		}
+	}
+	return err
+}
+
+// Handler returns the routes of the admin API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /steps", s.handleSteps)
+	mux.HandleFunc("POST /reconcile", s.handleReconcile)
+	mux.HandleFunc("POST /pause", s.handlePause)
+	mux.HandleFunc("POST /resume", s.handleResume)
+	mux.HandleFunc("GET /loglevel", s.handleGetLogLevel)
+	mux.HandleFunc("PUT /loglevel", s.handleSetLogLevel)
+	return mux
+}
+
+// handleHealthz reports that the daemon is running
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz reports whether the node is healthy according to the latest collected status
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	nodeStatus := s.controller.Status()
+	if nodeStatus == nil {
+		writeError(w, http.StatusServiceUnavailable, "no status collected yet")
+		return
+	}
+
+	report := status.Assess(nodeStatus, s.currentConfig(), status.SourceAPI, s.now())
+	code := http.StatusOK
+	if report.Health != status.HealthHealthy {
+		code = http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, report)
+}
+
+// handleStatus returns the latest collected node status
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	nodeStatus := s.controller.Status()
+	if nodeStatus == nil {
+		writeError(w, http.StatusNotFound, "no status collected yet")
+		return
+	}
+	writeJSON(w, http.StatusOK, nodeStatus)
+}
+
+// handleSteps returns the result of the most recent bootstrap run
+func (s *Server) handleSteps(w http.ResponseWriter, r *http.Request) {
+	result := s.controller.LastResult()
+	if result == nil {
+		writeError(w, http.StatusNotFound, "no bootstrap has run since the daemon started")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// handleReconcile asks the daemon to check and repair the node now
+func (s *Server) handleReconcile(w http.ResponseWriter, r *http.Request) {
+	if !s.controller.RequestReconcile() {
+		writeJSON(w, http.StatusAccepted, ReconcileResponse{Accepted: false, Message: "a reconcile is already pending"})
+		return
+	}
+	s.logger.Info("Reconcile requested through the admin API")
+	writeJSON(w, http.StatusAccepted, ReconcileResponse{Accepted: true, Message: "reconcile scheduled"})
+}
+
+// handlePause pauses automatic remediation
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	s.controller.SetPaused(true)
+	s.logger.Info("Automatic remediation paused through the admin API")
+	writeJSON(w, http.StatusOK, RemediationResponse{Paused: true})
+}
+
+// handleResume resumes automatic remediation
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	s.controller.SetPaused(false)
+	s.logger.Info("Automatic remediation resumed through the admin API")
+	writeJSON(w, http.StatusOK, RemediationResponse{Paused: false})
+}
+
+// handleGetLogLevel returns the daemon's log level
+func (s *Server) handleGetLogLevel(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, LogLevel{Level: s.logger.GetLevel().String()})
+}
+
+// handleSetLogLevel changes the daemon's log level until it restarts
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var body LogLevel
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	level, err := logrus.ParseLevel(body.Level)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s.logger.SetLevel(level)
+	s.logger.Infof("Log level set to %s through the admin API", level)
+	writeJSON(w, http.StatusOK, LogLevel{Level: level.String()})
+}
+
+// writeJSON writes value as the JSON body of a response with the given status code
+func writeJSON(w http.ResponseWriter, code int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, errorResponse{Error: message})
+}
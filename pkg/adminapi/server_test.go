@@ -0,0 +1,178 @@
+package adminapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+)
+
+// fakeController is an in-memory daemon
+type fakeController struct {
+	status     *status.NodeStatus
+	result     *bootstrapper.ExecutionResult
+	reconciles int
+	paused     bool
+}
+
+func (f *fakeController) Status() *status.NodeStatus                { return f.status }
+func (f *fakeController) LastResult() *bootstrapper.ExecutionResult { return f.result }
+func (f *fakeController) SetPaused(paused bool)                     { f.paused = paused }
+func (f *fakeController) Paused() bool                              { return f.paused }
+
+func (f *fakeController) RequestReconcile() bool {
+	f.reconciles++
+	return f.reconciles == 1
+}
+
+// readyStatus returns the status of a Ready node updated at now
+func readyStatus(now time.Time) *status.NodeStatus {
+	return &status.NodeStatus{
+		KubeletVersion:    "v1.32.7",
+		RuncVersion:       "1.1.12",
+		ContainerdVersion: "1.7.20",
+		KubeletRunning:    true,
+		KubeletReady:      "Ready",
+		ContainerdRunning: true,
+		LastUpdated:       now,
+	}
+}
+
+// TestHandler verifies the admin API routes.
+// Test: Sends requests to each route against a fake daemon with and without collected state
+// Expected: Each request returns the expected status code and body
+func TestHandler(t *testing.T) {
+	now := time.Now()
+	notReady := readyStatus(now)
+	notReady.KubeletReady = "NotReady"
+
+	tests := []struct {
+		name       string
+		controller *fakeController
+		method     string
+		path       string
+		body       string
+		wantCode   int
+		wantBody   string
+		wantPaused bool
+	}{
+		{name: "healthz", controller: &fakeController{}, method: http.MethodGet, path: "/healthz", wantCode: http.StatusOK, wantBody: `"ok"`},
+		{name: "readyz before first collection", controller: &fakeController{}, method: http.MethodGet, path: "/readyz", wantCode: http.StatusServiceUnavailable, wantBody: "no status collected yet"},
+		{name: "readyz healthy", controller: &fakeController{status: readyStatus(now)}, method: http.MethodGet, path: "/readyz", wantCode: http.StatusOK, wantBody: `"health":"healthy"`},
+		{name: "readyz degraded", controller: &fakeController{status: notReady}, method: http.MethodGet, path: "/readyz", wantCode: http.StatusServiceUnavailable, wantBody: `"health":"degraded"`},
+		{name: "status", controller: &fakeController{status: readyStatus(now)}, method: http.MethodGet, path: "/status", wantCode: http.StatusOK, wantBody: `"kubeletVersion":"v1.32.7"`},
+		{name: "status missing", controller: &fakeController{}, method: http.MethodGet, path: "/status", wantCode: http.StatusNotFound},
+		{name: "steps", controller: &fakeController{result: &bootstrapper.ExecutionResult{Success: true, StepCount: 3}}, method: http.MethodGet, path: "/steps", wantCode: http.StatusOK, wantBody: `"step_count":3`},
+		{name: "steps missing", controller: &fakeController{}, method: http.MethodGet, path: "/steps", wantCode: http.StatusNotFound},
+		{name: "reconcile", controller: &fakeController{}, method: http.MethodPost, path: "/reconcile", wantCode: http.StatusAccepted, wantBody: `"accepted":true`},
+		{name: "reconcile already pending", controller: &fakeController{reconciles: 1}, method: http.MethodPost, path: "/reconcile", wantCode: http.StatusAccepted, wantBody: `"accepted":false`},
+		{name: "pause", controller: &fakeController{}, method: http.MethodPost, path: "/pause", wantCode: http.StatusOK, wantBody: `"paused":true`, wantPaused: true},
+		{name: "resume", controller: &fakeController{paused: true}, method: http.MethodPost, path: "/resume", wantCode: http.StatusOK, wantBody: `"paused":false`},
+		{name: "reconcile with GET", controller: &fakeController{}, method: http.MethodGet, path: "/reconcile", wantCode: http.StatusMethodNotAllowed},
+		{name: "get log level", controller: &fakeController{}, method: http.MethodGet, path: "/loglevel", wantCode: http.StatusOK, wantBody: `"level":"info"`},
+		{name: "set log level", controller: &fakeController{}, method: http.MethodPut, path: "/loglevel", body: `{"level":"debug"}`, wantCode: http.StatusOK, wantBody: `"level":"debug"`},
+		{name: "set invalid log level", controller: &fakeController{}, method: http.MethodPut, path: "/loglevel", body: `{"level":"loud"}`, wantCode: http.StatusBadRequest, wantBody: "not a valid logrus Level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			server := NewServer(nil, logger, tt.controller)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Errorf("%s %s returned %d, want %d: %s", tt.method, tt.path, rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("%s %s body %q does not contain %q", tt.method, tt.path, rec.Body.String(), tt.wantBody)
+			}
+			if tt.controller.paused != tt.wantPaused {
+				t.Errorf("paused = %v, want %v", tt.controller.paused, tt.wantPaused)
+			}
+		})
+	}
+}
+
+// TestClientOverSocket verifies the client against a server listening on a Unix socket.
+// Test: Starts a server on a socket, then calls every client method
+// Expected: The socket is restricted to owner and group, calls reach the daemon, and Close removes the socket
+func TestClientOverSocket(t *testing.T) {
+	// Unix socket paths are limited to ~108 bytes, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "adminapi")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	socket := filepath.Join(dir, socketName)
+
+	// A stale socket file from an earlier daemon must not prevent listening
+	if err := os.WriteFile(socket, nil, 0600); err != nil {
+		t.Fatalf("failed to create stale socket: %v", err)
+	}
+
+	controller := &fakeController{
+		status: readyStatus(time.Now()),
+		result: &bootstrapper.ExecutionResult{Success: true, StepCount: 2},
+	}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	server := NewServer(nil, logger, controller)
+	if err := server.Listen(socket); err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	go func() { _ = server.Serve() }()
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Fatalf("socket not created: %v", err)
+	}
+	if info.Mode().Perm() != socketMode {
+		t.Errorf("socket mode = %v, want %v", info.Mode().Perm(), socketMode)
+	}
+
+	ctx := context.Background()
+	client := NewClient(socket)
+
+	nodeStatus, err := client.Status(ctx)
+	if err != nil || nodeStatus.KubeletReady != "Ready" {
+		t.Errorf("Status() = %+v, %v", nodeStatus, err)
+	}
+	result, err := client.Steps(ctx)
+	if err != nil || result.StepCount != 2 {
+		t.Errorf("Steps() = %+v, %v", result, err)
+	}
+	reconcile, err := client.Reconcile(ctx)
+	if err != nil || !reconcile.Accepted {
+		t.Errorf("Reconcile() = %+v, %v", reconcile, err)
+	}
+	if err := client.SetPaused(ctx, true); err != nil || !controller.paused {
+		t.Errorf("SetPaused(true) error = %v, paused = %v", err, controller.paused)
+	}
+	if level, err := client.SetLogLevel(ctx, "warn"); err != nil || level != "warning" || logger.GetLevel() != logrus.WarnLevel {
+		t.Errorf("SetLogLevel() = %q, %v", level, err)
+	}
+	if _, err := client.SetLogLevel(ctx, "loud"); err == nil || !strings.Contains(err.Error(), "not a valid logrus Level") {
+		t.Errorf("SetLogLevel(loud) error = %v, want the server's message", err)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after Close: %v", err)
+	}
+}
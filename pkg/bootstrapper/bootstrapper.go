@@ -2,22 +2,20 @@ package bootstrapper
 
 import (
 	"context"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"go.goms.io/aks/AKSFlexNode/pkg/components/arc"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/cni"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/containerd"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/kube_binaries"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/kubelet"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/npd"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/runc"
 	"go.goms.io/aks/AKSFlexNode/pkg/components/services"
-	"go.goms.io/aks/AKSFlexNode/pkg/components/system_configuration"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 )
 
-// Bootstrapper executes bootstrap steps sequentially
+// servicesStopTimeout bounds stopping kubelet and containerd before the node is reconfigured
+const servicesStopTimeout = 5 * time.Minute
+
+// Bootstrapper executes bootstrap steps as a dependency graph
 type Bootstrapper struct {
 	*BaseExecutor
 }
@@ -29,38 +27,101 @@ func New(cfg *config.Config, logger *logrus.Logger) *Bootstrapper {
 	}
 }
 
-// Bootstrap executes all bootstrap steps sequentially
+// Bootstrap executes all bootstrap steps, running independent steps in parallel
 func (b *Bootstrapper) Bootstrap(ctx context.Context) (*ExecutionResult, error) {
-	// Define the bootstrap steps in order - using modules directly
-	steps := []Executor{
-		arc.NewInstaller(b.logger),                  // Setup Arc
-		services.NewUnInstaller(b.logger),           // Stop kubelet before setup
-		system_configuration.NewInstaller(b.logger), // Configure system (early)
-		runc.NewInstaller(b.logger),                 // Install runc
-		containerd.NewInstaller(b.logger),           // Install containerd
-		kube_binaries.NewInstaller(b.logger),        // Install k8s binaries
-		cni.NewInstaller(b.logger),                  // Setup CNI (after container runtime)
-		kubelet.NewInstaller(b.logger),              // Configure kubelet service with Arc MSI auth
-		npd.NewInstaller(b.logger),                  // Install Node Problem Detector
-		services.NewInstaller(b.logger),             // Start services
-	}
+	return b.ExecuteGraph(ctx, b.bootstrapSteps(), "bootstrap")
+}
+
+// PlanBootstrap reports the changes Bootstrap would make without applying them
+func (b *Bootstrapper) PlanBootstrap(ctx context.Context) (*plan.Result, error) {
+	return b.PlanGraph(ctx, b.bootstrapSteps(), "bootstrap")
+}
+
+// bootstrapSteps returns the bootstrap step graph built from the component registry
+func (b *Bootstrapper) bootstrapSteps() []Step {
+	steps := installSteps(Components(), b.logger)
 
-	return b.ExecuteSteps(ctx, steps, "bootstrap")
+	// Stop kubelet and containerd once Arc is connected, before the node is reconfigured
+	for _, step := range steps {
+		if _, ok := step.Executor.(*arc.Installer); ok {
+			stopperLogger, logs := newStepLogger(b.logger)
+			stopper := Step{Executor: services.NewUnInstaller(stopperLogger), Timeout: servicesStopTimeout, logs: logs}
+			return insertAfter(steps, step.GetName(), stopper)
+		}
+	}
+	return steps
 }
 
-// Unbootstrap executes all cleanup steps sequentially (in reverse order of bootstrap)
+// Unbootstrap executes all cleanup steps in reverse dependency order of bootstrap
 func (b *Bootstrapper) Unbootstrap(ctx context.Context) (*ExecutionResult, error) {
-	steps := []Executor{
-		services.NewUnInstaller(b.logger),             // Stop services first
-		npd.NewUnInstaller(b.logger),                  // Uninstall Node Problem Detector
-		kubelet.NewUnInstaller(b.logger),              // Clean kubelet configuration
-		cni.NewUnInstaller(b.logger),                  // Clean CNI configs
-		kube_binaries.NewUnInstaller(b.logger),        // Uninstall k8s binaries
-		containerd.NewUnInstaller(b.logger),           // Uninstall containerd binary
-		runc.NewUnInstaller(b.logger),                 // Uninstall runc binary
-		system_configuration.NewUnInstaller(b.logger), // Clean system settings
-		arc.NewUnInstaller(b.logger),                  // Uninstall Arc (after cleanup)
+	b.removeJournal()
+	return b.ExecuteGraph(ctx, b.unbootstrapSteps(), "unbootstrap")
+}
+
+// Reset removes everything the components installed on this machine without calling Azure.
+// Components whose uninstaller cleans up Azure resources use their local uninstaller instead,
+// so the Azure resources of this machine are left in place.
+func (b *Bootstrapper) Reset(ctx context.Context) (*ExecutionResult, error) {
+	b.removeJournal()
+	return b.ExecuteGraph(ctx, b.resetSteps(), "reset")
+}
+
+// PlanReset reports the changes Reset would make without applying them
+func (b *Bootstrapper) PlanReset(ctx context.Context) (*plan.Result, error) {
+	return b.PlanGraph(ctx, b.resetSteps(), "reset")
+}
+
+// removeJournal drops the bootstrap journal; components are about to be removed, so
+// checkpoints of earlier bootstrap runs no longer hold
+func (b *Bootstrapper) removeJournal() {
+	if b.config != nil && b.config.Agent.StateDir != "" {
+		if err := RemoveJournal(b.config.Agent.StateDir); err != nil {
+			b.logger.Warnf("%v", err)
+		}
 	}
+}
+
+// PlanUnbootstrap reports the changes Unbootstrap would make without applying them
+func (b *Bootstrapper) PlanUnbootstrap(ctx context.Context) (*plan.Result, error) {
+	return b.PlanGraph(ctx, b.unbootstrapSteps(), "unbootstrap")
+}
+
+// unbootstrapSteps returns the cleanup step graph built from the component registry.
+// Dependencies are declared in install order; ExecuteGraph walks them in reverse.
+func (b *Bootstrapper) unbootstrapSteps() []Step {
+	return uninstallSteps(Components(), b.logger)
+}
 
-	return b.ExecuteSteps(ctx, steps, "unbootstrap")
+// resetSteps returns the unbootstrap step graph with local uninstallers in place of those calling Azure
+func (b *Bootstrapper) resetSteps() []Step {
+	components := Components()
+	for i, component := range components {
+		if component.NewLocalUnInstaller != nil {
+			components[i].NewUnInstaller = component.NewLocalUnInstaller
+		}
+	}
+	return uninstallSteps(components, b.logger)
+}
+
+// insertAfter adds inserted as a step that runs right after the named step;
+// steps that depended on the named step run after the inserted step instead
+func insertAfter(steps []Step, anchor string, inserted Step) []Step {
+	result := make([]Step, 0, len(steps)+1)
+	for _, step := range steps {
+		deps := make([]string, 0, len(step.DependsOn))
+		for _, dep := range step.DependsOn {
+			if dep == anchor {
+				dep = inserted.GetName()
+			}
+			deps = append(deps, dep)
+		}
+		step.DependsOn = deps
+
+		result = append(result, step)
+		if step.GetName() == anchor {
+			inserted.DependsOn = []string{anchor}
+			result = append(result, inserted)
+		}
+	}
+	return result
 }
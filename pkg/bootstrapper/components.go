@@ -0,0 +1,392 @@
+package bootstrapper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/components/arc"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/artifact"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/cni"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/containerd"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/kube_binaries"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/kubelet"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/npd"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/runc"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/services"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/system_configuration"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+)
+
+// Component names used in the registry
+const (
+	ComponentArc                 = "arc"
+	ComponentSystemConfiguration = "system-configuration"
+	ComponentRunc                = "runc"
+	ComponentContainerd          = "containerd"
+	ComponentKubeBinaries        = "kube-binaries"
+	ComponentCNI                 = "cni"
+	ComponentKubelet             = "kubelet"
+	ComponentNPD                 = "npd"
+	ComponentServices            = "services"
+
+	// artifactComponentPrefix prefixes the component names of config-defined artifacts
+	artifactComponentPrefix = "artifact-"
+)
+
+// Step types of single component operations
+const (
+	componentInstallType   = "component-install"
+	componentUninstallType = "component-uninstall"
+)
+
+// Component pairs the installer and uninstaller of a single node component
+type Component struct {
+	Name        string
+	Description string
+
+	// DependsOn lists the components that must be installed before this one
+	DependsOn []string
+
+	NewInstaller   func(logger *logrus.Logger) Executor
+	NewUnInstaller func(logger *logrus.Logger) Executor
+
+	// NewLocalUnInstaller replaces NewUnInstaller for a local reset when the uninstaller calls Azure
+	NewLocalUnInstaller func(logger *logrus.Logger) Executor
+
+	// InstallTimeout and UninstallTimeout are the default step timeouts,
+	// overridable per step name through agent.stepTimeouts
+	InstallTimeout   time.Duration
+	UninstallTimeout time.Duration
+}
+
+// Components returns the registered node components in install order, including the
+// artifacts defined in the loaded configuration
+func Components() []Component {
+	components := builtinComponents()
+	if cfg := config.GetConfig(); cfg != nil && len(cfg.Artifacts) > 0 {
+		components = withArtifacts(components, cfg.Artifacts)
+	}
+	return components
+}
+
+// withArtifacts inserts a component per artifact right before services, so that everything
+// the artifacts provide is in place before kubelet is started
+func withArtifacts(components []Component, artifacts []config.ArtifactConfig) []Component {
+	result := make([]Component, 0, len(components)+len(artifacts))
+	for _, component := range components {
+		if component.Name == ComponentServices {
+			for _, definition := range artifacts {
+				definition := definition
+				result = append(result, Component{
+					Name:        artifactComponentPrefix + definition.Name,
+					Description: "Artifact installed to " + definition.Target,
+					DependsOn:   []string{ComponentSystemConfiguration},
+					NewInstaller: func(logger *logrus.Logger) Executor {
+						return artifact.NewInstaller(logger, definition)
+					},
+					NewUnInstaller: func(logger *logrus.Logger) Executor {
+						return artifact.NewUnInstaller(logger, definition)
+					},
+					InstallTimeout:   10 * time.Minute,
+					UninstallTimeout: 5 * time.Minute,
+				})
+				component.DependsOn = append(append([]string(nil), component.DependsOn...), artifactComponentPrefix+definition.Name)
+			}
+		}
+		result = append(result, component)
+	}
+	return result
+}
+
+// builtinComponents returns the built-in node components in install order
+func builtinComponents() []Component {
+	return []Component{
+		{
+			Name:                ComponentArc,
+			Description:         "Azure Arc machine registration and RBAC role assignments",
+			NewInstaller:        func(logger *logrus.Logger) Executor { return arc.NewInstaller(logger) },
+			NewUnInstaller:      func(logger *logrus.Logger) Executor { return arc.NewUnInstaller(logger) },
+			NewLocalUnInstaller: func(logger *logrus.Logger) Executor { return arc.NewLocalUnInstaller(logger) },
+			InstallTimeout:      20 * time.Minute,
+			UninstallTimeout:    10 * time.Minute,
+		},
+		{
+			Name:             ComponentSystemConfiguration,
+			Description:      "Kernel sysctl settings and resolv.conf",
+			DependsOn:        []string{ComponentArc},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return system_configuration.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return system_configuration.NewUnInstaller(logger) },
+			InstallTimeout:   5 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:             ComponentRunc,
+			Description:      "runc low-level container runtime",
+			DependsOn:        []string{ComponentSystemConfiguration},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return runc.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return runc.NewUnInstaller(logger) },
+			InstallTimeout:   10 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:             ComponentContainerd,
+			Description:      "containerd container runtime and its systemd unit",
+			DependsOn:        []string{ComponentSystemConfiguration},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return containerd.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return containerd.NewUnInstaller(logger) },
+			InstallTimeout:   15 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:             ComponentKubeBinaries,
+			Description:      "kubelet, kubectl and kubeadm binaries",
+			DependsOn:        []string{ComponentSystemConfiguration},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return kube_binaries.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return kube_binaries.NewUnInstaller(logger) },
+			InstallTimeout:   20 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:             ComponentCNI,
+			Description:      "CNI plugins and bridge network configuration",
+			DependsOn:        []string{ComponentContainerd},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return cni.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return cni.NewUnInstaller(logger) },
+			InstallTimeout:   15 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:        ComponentKubelet,
+			Description: "kubelet configuration, Arc token script and systemd unit",
+			// kubelet also waits for CNI so the two apt based installs never contend for the dpkg lock
+			DependsOn:        []string{ComponentContainerd, ComponentKubeBinaries, ComponentCNI},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return kubelet.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return kubelet.NewUnInstaller(logger) },
+			InstallTimeout:   10 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:             ComponentNPD,
+			Description:      "Node Problem Detector",
+			DependsOn:        []string{ComponentKubelet},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return npd.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return npd.NewUnInstaller(logger) },
+			InstallTimeout:   10 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+		{
+			Name:             ComponentServices,
+			Description:      "Enables and starts containerd, kubelet and node-problem-detector",
+			DependsOn:        []string{ComponentRunc, ComponentCNI, ComponentKubelet, ComponentNPD},
+			NewInstaller:     func(logger *logrus.Logger) Executor { return services.NewInstaller(logger) },
+			NewUnInstaller:   func(logger *logrus.Logger) Executor { return services.NewUnInstaller(logger) },
+			InstallTimeout:   10 * time.Minute,
+			UninstallTimeout: 5 * time.Minute,
+		},
+	}
+}
+
+// LookupComponent returns the registered component with the given name
+func LookupComponent(name string) (Component, bool) {
+	for _, component := range Components() {
+		if component.Name == name {
+			return component, true
+		}
+	}
+	return Component{}, false
+}
+
+// ComponentNames returns the names of all registered components in install order
+func ComponentNames() []string {
+	components := Components()
+	names := make([]string, 0, len(components))
+	for _, component := range components {
+		names = append(names, component.Name)
+	}
+	return names
+}
+
+// ResolveComponents returns the named components together with everything they transitively
+// depend on, in install order
+func ResolveComponents(names ...string) ([]Component, error) {
+	byName := make(map[string]Component)
+	for _, component := range Components() {
+		byName[component.Name] = component
+	}
+
+	needed := make(map[string]bool)
+	queue := append([]string(nil), names...)
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if needed[name] {
+			continue
+		}
+		component, ok := byName[name]
+		if !ok {
+			return nil, unknownComponentError(name)
+		}
+		needed[name] = true
+		queue = append(queue, component.DependsOn...)
+	}
+
+	var resolved []Component
+	for _, component := range Components() {
+		if needed[component.Name] {
+			resolved = append(resolved, component)
+		}
+	}
+	return resolved, nil
+}
+
+// unknownComponentError reports a component name that is not in the registry
+func unknownComponentError(name string) error {
+	names := ComponentNames()
+	sort.Strings(names)
+	return fmt.Errorf("unknown component %q, valid components are: %s", name, strings.Join(names, ", "))
+}
+
+// ComponentState describes whether a component is currently installed on this machine
+type ComponentState struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	DependsOn   []string `json:"depends_on,omitempty"`
+	StepName    string   `json:"step_name"`
+	Installed   bool     `json:"installed"`
+}
+
+// InstallComponent installs a single component. Unless withPrerequisites is false the components it
+// depends on are installed first where they are not already complete. The named component itself is
+// always re-run, even if it reports itself as installed.
+func (b *Bootstrapper) InstallComponent(ctx context.Context, name string, withPrerequisites bool) (*ExecutionResult, error) {
+	component, ok := LookupComponent(name)
+	if !ok {
+		return nil, unknownComponentError(name)
+	}
+
+	components := []Component{component}
+	if withPrerequisites {
+		var err error
+		if components, err = ResolveComponents(name); err != nil {
+			return nil, err
+		}
+	}
+
+	steps := installSteps(components, b.logger)
+	target := steps[len(steps)-1].GetName()
+
+	previous := b.resume
+	b.SetResumeOptions(ResumeOptions{RestartFrom: target})
+	defer b.SetResumeOptions(previous)
+
+	return b.ExecuteGraph(ctx, steps, componentInstallType)
+}
+
+// UninstallComponent removes a single component. Components that depend on it are left in place.
+func (b *Bootstrapper) UninstallComponent(ctx context.Context, name string) (*ExecutionResult, error) {
+	component, ok := LookupComponent(name)
+	if !ok {
+		return nil, unknownComponentError(name)
+	}
+	return b.ExecuteGraph(ctx, uninstallSteps([]Component{component}, b.logger), componentUninstallType)
+}
+
+// ComponentStatus reports whether the named component's installer considers it complete
+func (b *Bootstrapper) ComponentStatus(ctx context.Context, name string) (*ComponentState, error) {
+	component, ok := LookupComponent(name)
+	if !ok {
+		return nil, unknownComponentError(name)
+	}
+
+	installer := component.NewInstaller(b.logger)
+	return &ComponentState{
+		Name:        component.Name,
+		Description: component.Description,
+		DependsOn:   component.DependsOn,
+		StepName:    installer.GetName(),
+		Installed:   installer.IsCompleted(ctx),
+	}, nil
+}
+
+// DetectDrift compares the desired state of every component that reports one with the machine and
+// returns the components that drifted from it in install order
+func (b *Bootstrapper) DetectDrift(ctx context.Context) []drift.Component {
+	var drifted []drift.Component
+	for _, component := range Components() {
+		fingerprinter, ok := component.NewInstaller(b.logger).(Fingerprinter)
+		if !ok {
+			continue
+		}
+		fingerprint, err := fingerprinter.Fingerprint(ctx)
+		if err != nil {
+			b.logger.Warnf("Skipping drift detection of %s: %v", component.Name, err)
+			continue
+		}
+		if differences := drift.Compare(fingerprint); len(differences) > 0 {
+			drifted = append(drifted, drift.Component{
+				Name:        component.Name,
+				Fingerprint: fingerprint.Hash(),
+				Differences: differences,
+			})
+		}
+	}
+	return drifted
+}
+
+// installSteps builds the install graph of the given components; every installer is paired
+// with its component's uninstaller so that it can be rolled back.
+// Dependencies on components outside the list are ignored.
+func installSteps(components []Component, logger *logrus.Logger) []Step {
+	installers := make(map[string]Executor, len(components))
+	steps := make([]Step, 0, len(components))
+	for _, component := range components {
+		// The installer and its rollback share a logger so a timed out step can report its last lines
+		stepLogger, logs := newStepLogger(logger)
+		installer := component.NewInstaller(stepLogger)
+		installers[component.Name] = installer
+		steps = append(steps, Step{
+			Executor:        installer,
+			DependsOn:       componentDeps(component, installers),
+			Timeout:         component.InstallTimeout,
+			Rollback:        component.NewUnInstaller(stepLogger),
+			RollbackTimeout: component.UninstallTimeout,
+			logs:            logs,
+		})
+	}
+	return steps
+}
+
+// uninstallSteps builds the uninstall graph of the given components with dependencies in install order
+func uninstallSteps(components []Component, logger *logrus.Logger) []Step {
+	uninstallers := make(map[string]Executor, len(components))
+	steps := make([]Step, 0, len(components))
+	for _, component := range components {
+		stepLogger, logs := newStepLogger(logger)
+		uninstaller := component.NewUnInstaller(stepLogger)
+		uninstallers[component.Name] = uninstaller
+		steps = append(steps, Step{
+			Executor:  uninstaller,
+			DependsOn: componentDeps(component, uninstallers),
+			Timeout:   component.UninstallTimeout,
+			logs:      logs,
+		})
+	}
+	return steps
+}
+
+// componentDeps resolves a component's dependencies to the step names of already created executors
+func componentDeps(component Component, executors map[string]Executor) []string {
+	var deps []string
+	for _, dep := range component.DependsOn {
+		if executor, ok := executors[dep]; ok {
+			deps = append(deps, executor.GetName())
+		}
+	}
+	return deps
+}
@@ -0,0 +1,195 @@
+package bootstrapper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// TestComponents verifies the component registry.
+// Test: Walks every registered component
+// Expected: Names are unique, installers and uninstallers are paired and dependencies refer to earlier components
+func TestComponents(t *testing.T) {
+	logger := logrus.New()
+	seen := make(map[string]bool)
+
+	for _, component := range Components() {
+		if seen[component.Name] {
+			t.Errorf("Duplicate component %s", component.Name)
+		}
+		for _, dep := range component.DependsOn {
+			if !seen[dep] {
+				t.Errorf("Component %s depends on %s which is not registered before it", component.Name, dep)
+			}
+		}
+		seen[component.Name] = true
+
+		if component.Description == "" {
+			t.Errorf("Component %s has no description", component.Name)
+		}
+		if component.NewInstaller(logger) == nil || component.NewUnInstaller(logger) == nil {
+			t.Errorf("Component %s must provide both an installer and an uninstaller", component.Name)
+		}
+		if found, ok := LookupComponent(component.Name); !ok || found.Name != component.Name {
+			t.Errorf("LookupComponent(%s) did not find the component", component.Name)
+		}
+	}
+
+	if _, ok := LookupComponent("missing"); ok {
+		t.Error("LookupComponent should not find unknown components")
+	}
+}
+
+// TestFingerprinters verifies which components report their desired state for drift detection.
+// Test: Checks the installer of every built-in component for the Fingerprinter interface
+// Expected: Every component except Arc, whose state lives in Azure, reports a fingerprint
+func TestFingerprinters(t *testing.T) {
+	logger := logrus.New()
+	for _, component := range builtinComponents() {
+		_, ok := component.NewInstaller(logger).(Fingerprinter)
+		if want := component.Name != ComponentArc; ok != want {
+			t.Errorf("Component %s implements Fingerprinter = %v, want %v", component.Name, ok, want)
+		}
+	}
+}
+
+// TestBootstrapSteps verifies the bootstrap graph built from the registry.
+// Test: Builds the bootstrap steps and inspects dependencies and rollback pairing
+// Expected: Services are stopped right after Arc, every component installer can be rolled back and the graph is valid
+func TestBootstrapSteps(t *testing.T) {
+	logger := logrus.New()
+	b := New(nil, logger)
+	steps := b.bootstrapSteps()
+
+	if len(steps) != len(Components())+1 {
+		t.Fatalf("Expected %d steps, got %d", len(Components())+1, len(steps))
+	}
+	if steps[1].GetName() != "ServicesDisabled" || steps[1].DependsOn[0] != steps[0].GetName() {
+		t.Errorf("Expected services to be stopped right after %s, got %s after %v", steps[0].GetName(), steps[1].GetName(), steps[1].DependsOn)
+	}
+	if steps[2].DependsOn[0] != "ServicesDisabled" {
+		t.Errorf("Expected %s to run after ServicesDisabled, got %v", steps[2].GetName(), steps[2].DependsOn)
+	}
+	for _, step := range steps {
+		if step.GetName() != "ServicesDisabled" && step.Rollback == nil {
+			t.Errorf("Expected step %s to be paired with an uninstaller", step.GetName())
+		}
+	}
+
+	nodes, err := buildStepNodes(steps)
+	if err != nil {
+		t.Fatalf("Invalid bootstrap graph: %v", err)
+	}
+	if _, err := topologicalOrder(nodes); err != nil {
+		t.Fatalf("Invalid bootstrap graph: %v", err)
+	}
+
+	if _, err := buildStepNodes(b.unbootstrapSteps()); err != nil {
+		t.Fatalf("Invalid unbootstrap graph: %v", err)
+	}
+}
+
+// TestResetSteps verifies that a reset never runs uninstallers calling Azure.
+// Test: Builds the reset graph and compares it with the unbootstrap graph
+// Expected: Arc is cleaned up by its local uninstaller, every other step matches unbootstrap
+func TestResetSteps(t *testing.T) {
+	b := New(nil, logrus.New())
+	resetSteps := b.resetSteps()
+	unbootstrapSteps := b.unbootstrapSteps()
+
+	if len(resetSteps) != len(unbootstrapSteps) {
+		t.Fatalf("Expected %d reset steps, got %d", len(unbootstrapSteps), len(resetSteps))
+	}
+	for i, step := range resetSteps {
+		want := unbootstrapSteps[i].GetName()
+		if want == "ArcUnbootstrap" {
+			want = "ArcLocalReset"
+		}
+		if step.GetName() != want {
+			t.Errorf("Expected reset step %d to be %s, got %s", i, want, step.GetName())
+		}
+	}
+
+	if _, err := buildStepNodes(resetSteps); err != nil {
+		t.Fatalf("Invalid reset graph: %v", err)
+	}
+}
+
+// TestResolveComponents verifies prerequisite resolution of component operations.
+// Test: Resolves single components with and without dependencies and an unknown name
+// Expected: Components come back with their transitive dependencies in install order; unknown names fail
+func TestResolveComponents(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    []string
+		wantErr bool
+	}{
+		{name: ComponentArc, want: []string{ComponentArc}},
+		{name: ComponentContainerd, want: []string{ComponentArc, ComponentSystemConfiguration, ComponentContainerd}},
+		{
+			name: ComponentKubelet,
+			want: []string{ComponentArc, ComponentSystemConfiguration, ComponentContainerd, ComponentKubeBinaries, ComponentCNI, ComponentKubelet},
+		},
+		{name: "docker", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			components, err := ResolveComponents(tt.name)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error for unknown component")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveComponents failed: %v", err)
+			}
+
+			var got []string
+			for _, component := range components {
+				got = append(got, component.Name)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ResolveComponents(%s) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWithArtifacts verifies how config-defined artifacts join the component registry.
+// Test: Adds two artifacts to the built-in components
+// Expected: Artifacts are registered right before services, after system configuration, and services waits for them
+func TestWithArtifacts(t *testing.T) {
+	components := withArtifacts(builtinComponents(), []config.ArtifactConfig{
+		{Name: "cred-provider", Target: "/usr/local/bin/cred-provider"},
+		{Name: "site-ca", Target: "/usr/local/share/ca-certificates/site.crt"},
+	})
+
+	names := make([]string, 0, len(components))
+	for _, component := range components {
+		names = append(names, component.Name)
+	}
+	tail := strings.Join(names[len(names)-3:], ",")
+	if tail != "artifact-cred-provider,artifact-site-ca,services" {
+		t.Errorf("Expected artifacts right before services, got %v", names)
+	}
+
+	artifactComponent := components[len(components)-3]
+	if len(artifactComponent.DependsOn) != 1 || artifactComponent.DependsOn[0] != ComponentSystemConfiguration {
+		t.Errorf("Expected artifact to depend on system configuration, got %v", artifactComponent.DependsOn)
+	}
+	if got := artifactComponent.NewInstaller(logrus.New()).GetName(); got != "ArtifactInstaller_cred-provider" {
+		t.Errorf("Unexpected artifact step name %s", got)
+	}
+
+	services := components[len(components)-1]
+	if !strings.Contains(strings.Join(services.DependsOn, ","), "artifact-cred-provider,artifact-site-ca") {
+		t.Errorf("Expected services to depend on artifacts, got %v", services.DependsOn)
+	}
+	if builtin, _ := LookupComponent(ComponentServices); len(builtin.DependsOn) != 4 {
+		t.Errorf("Built-in services dependencies must not be modified, got %v", builtin.DependsOn)
+	}
+}
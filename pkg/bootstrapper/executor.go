@@ -4,10 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 )
 
 // executor is a common base interface for all executors
@@ -32,6 +35,20 @@ type StepExecutor interface {
 	Validate(ctx context.Context) error
 }
 
+// Retryable is implemented by executors whose Execute can safely run again after a failure.
+// Executors that do not implement it run exactly once.
+type Retryable interface {
+	// RetryPolicy returns how failed executions of the step are retried
+	RetryPolicy() retry.Policy
+}
+
+// Fingerprinter is implemented by installers that can describe the state they install, so that
+// the daemon can detect when the machine drifted from it
+type Fingerprinter interface {
+	// Fingerprint returns the files, binary versions and units Execute installs with the current configuration
+	Fingerprint(ctx context.Context) (drift.Fingerprint, error)
+}
+
 // ExecutionResult represents the result of bootstrap or unbootstrap process
 type ExecutionResult struct {
 	Success     bool          `json:"success"`
@@ -39,22 +56,42 @@ type ExecutionResult struct {
 	Duration    time.Duration `json:"duration"`
 	StepResults []StepResult  `json:"step_results"`
 	Error       string        `json:"error,omitempty"`
+
+	// RollbackResults holds the uninstall steps run to undo a failed bootstrap, in execution order
+	RollbackResults []StepResult `json:"rollback_results,omitempty"`
+
+	// Hooks holds the outcomes of run-level event hooks, in execution order
+	Hooks []HookResult `json:"hooks,omitempty"`
 }
 
 // StepResult represents the result of a single step
 type StepResult struct {
-	StepName string        `json:"step_name"`
-	Success  bool          `json:"success"`
-	Duration time.Duration `json:"duration"`
-	Error    string        `json:"error,omitempty"`
+	StepName   string        `json:"step_name"`
+	Success    bool          `json:"success"`
+	Duration   time.Duration `json:"duration"`
+	Error      string        `json:"error,omitempty"`
+	ErrorClass retry.Class   `json:"error_class,omitempty"`
+	SkipReason string        `json:"skip_reason,omitempty"`
+	Attempts   int           `json:"attempts,omitempty"`
+	LastError  string        `json:"last_error,omitempty"` // error of the most recent failed attempt, kept even if a retry succeeded
+	TimedOut   bool          `json:"timed_out,omitempty"`
+	LogTail    []string      `json:"log_tail,omitempty"` // last log lines of a failed step
+	Hooks      []HookResult  `json:"hooks,omitempty"`    // outcomes of the step's pre and post hooks
 }
 
 // BaseExecutor provides common functionality for bootstrap and unbootstrap operations
 type BaseExecutor struct {
-	config *config.Config
-	logger *logrus.Logger
+	config            *config.Config
+	logger            *logrus.Logger
+	resume            ResumeOptions
+	rollbackOnFailure bool
+	stepObserver      StepObserver
 }
 
+// StepObserver is told about every step a run starts, for example to report progress to systemd.
+// Independent steps run concurrently, so the observer must be safe for concurrent use.
+type StepObserver func(stepType, stepName string)
+
 // NewBaseExecutor creates a new base executor
 func NewBaseExecutor(cfg *config.Config, logger *logrus.Logger) *BaseExecutor {
 	return &BaseExecutor{
@@ -63,89 +100,517 @@ func NewBaseExecutor(cfg *config.Config, logger *logrus.Logger) *BaseExecutor {
 	}
 }
 
-// ExecuteSteps executes a list of steps and returns results
+// SetResumeOptions overrides how the next bootstrap run uses the checkpoint journal
+func (be *BaseExecutor) SetResumeOptions(opts ResumeOptions) {
+	be.resume = opts
+}
+
+// SetRollbackOnFailure enables rolling back the steps a failed bootstrap run changed,
+// in addition to the agent.rollbackOnFailure configuration setting
+func (be *BaseExecutor) SetRollbackOnFailure(enabled bool) {
+	be.rollbackOnFailure = enabled
+}
+
+// SetStepObserver registers observer to be called whenever a step starts
+func (be *BaseExecutor) SetStepObserver(observer StepObserver) {
+	be.stepObserver = observer
+}
+
+// Step is a node in the execution graph: an executor plus the names (GetName values)
+// of the steps that must complete before it can start
+type Step struct {
+	Executor
+
+	// DependsOn lists the steps this step runs after, in bootstrap order
+	DependsOn []string
+
+	// Timeout bounds a single run of the step including retries; zero uses the default step timeout.
+	// Both timeouts can be overridden by step name through agent.stepTimeouts.
+	Timeout time.Duration
+
+	// Rollback optionally undoes the step when a later bootstrap step fails and rollback is enabled
+	Rollback        Executor
+	RollbackTimeout time.Duration
+
+	// logs records the last log lines of the step and its rollback
+	logs *logTail
+}
+
+// stepNode is the resolved form of a Step with dependencies expressed as indexes
+type stepNode struct {
+	step            Executor
+	timeout         time.Duration
+	rollback        Executor
+	rollbackTimeout time.Duration
+	logs            *logTail
+	deps            []int
+}
+
+// ExecuteSteps executes a list of steps one after another and returns results
 func (be *BaseExecutor) ExecuteSteps(ctx context.Context, steps []Executor, stepType string) (*ExecutionResult, error) {
+	nodes := make([]stepNode, len(steps))
+	for i, step := range steps {
+		nodes[i] = stepNode{step: step}
+		if i > 0 {
+			nodes[i].deps = []int{i - 1}
+		}
+	}
+
+	return be.executeNodes(ctx, nodes, stepType)
+}
+
+// ExecuteGraph executes steps according to their declared dependencies, running independent
+// steps concurrently up to the configured parallelism limit.
+// Dependencies are always declared in bootstrap order; for unbootstrap the graph is walked
+// in reverse so that a step is torn down only after everything that depends on it.
+func (be *BaseExecutor) ExecuteGraph(ctx context.Context, steps []Step, stepType string) (*ExecutionResult, error) {
+	nodes, err := buildStepNodes(steps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s step graph: %w", stepType, err)
+	}
+
+	if uninstalling(stepType) {
+		nodes = reverseStepNodes(nodes)
+	}
+
+	return be.executeNodes(ctx, nodes, stepType)
+}
+
+// executeNodes schedules the resolved step graph and collects results in topological order
+func (be *BaseExecutor) executeNodes(ctx context.Context, nodes []stepNode, stepType string) (*ExecutionResult, error) {
 	be.logger.Infof("Starting AKS node %s", stepType)
 
+	order, err := topologicalOrder(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s step graph: %w", stepType, err)
+	}
+
+	resume, err := be.planResume(nodes, stepType)
+	if err != nil {
+		return nil, err
+	}
+
 	startTime := time.Now()
 	result := &ExecutionResult{
 		StepResults: make([]StepResult, 0),
 	}
 
-	// Execute each step
-	for _, step := range steps {
-		stepResult := be.executeStep(ctx, step, stepType)
-		result.StepResults = append(result.StepResults, stepResult)
+	if err := be.runEventHooks(ctx, stepType+"-start", stepType, result); err != nil {
+		result.Duration = time.Since(startTime)
+		result.Error = err.Error()
+		return result, fmt.Errorf("%s aborted: %w", stepType, err)
+	}
+
+	// Cancelling this context stops sibling steps when bootstrap fails fast
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if overall := be.overallTimeout(); overall > 0 {
+		var cancelDeadline context.CancelFunc
+		runCtx, cancelDeadline = context.WithTimeout(runCtx, overall)
+		defer cancelDeadline()
+	}
 
-		if !stepResult.Success {
-			if stepType == "bootstrap" {
-				// Bootstrap fails fast on first error
-				result.Success = false
-				result.Error = stepResult.Error
-				result.Duration = time.Since(startTime)
-				result.StepCount = len(result.StepResults)
+	pending := make([]int, len(nodes))
+	dependents := make([][]int, len(nodes))
+	for i, node := range nodes {
+		pending[i] = len(node.deps)
+		for _, dep := range node.deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
 
-				be.logger.Errorf("Bootstrap failed at step %s: %s (completedSteps: %d, totalSteps: %d)",
-					stepResult.StepName, stepResult.Error, len(result.StepResults), len(steps))
+	ready := make([]int, 0, len(nodes))
+	for i := range nodes {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
 
-				return result, fmt.Errorf("bootstrap failed at step %s: %w", stepResult.StepName, errors.New(stepResult.Error))
+	type completion struct {
+		index  int
+		result StepResult
+	}
+
+	limit := be.maxParallelSteps()
+	stepResults := make(map[int]StepResult, len(nodes))
+	doneCh := make(chan completion)
+	running := 0
+	failedIndex := -1
+	var changed []int // successfully executed steps in completion order
+
+	for running > 0 || (len(ready) > 0 && failedIndex < 0) {
+		// Start as many ready steps as the parallelism limit allows, lowest declaration first
+		sort.Ints(ready)
+		for len(ready) > 0 && running < limit && failedIndex < 0 {
+			index := ready[0]
+			ready = ready[1:]
+			running++
+			go func(index int) {
+				doneCh <- completion{index: index, result: be.runNode(runCtx, nodes[index], stepType, index, resume)}
+			}(index)
+		}
+
+		done := <-doneCh
+		running--
+		stepResults[done.index] = done.result
+		if done.result.Success && done.result.SkipReason == "" {
+			changed = append(changed, done.index)
+		}
+
+		if !done.result.Success {
+			if installing(stepType) {
+				// Bootstrap fails fast on first error and cancels steps still in flight
+				if failedIndex < 0 {
+					failedIndex = done.index
+					cancel()
+				}
+				continue
 			}
 			// Unbootstrap continues even if some steps fail for best effort cleanup
 			be.logger.Warnf("Cleanup step %s failed: %s (continuing with remaining steps)",
-				stepResult.StepName, stepResult.Error)
+				done.result.StepName, done.result.Error)
+		}
+
+		for _, dependent := range dependents[done.index] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
 		}
 	}
 
+	// Report results in a stable topological order regardless of completion order
+	for _, index := range order {
+		if stepResult, ok := stepResults[index]; ok {
+			result.StepResults = append(result.StepResults, stepResult)
+		}
+	}
+	result.StepCount = len(result.StepResults)
+	result.Duration = time.Since(startTime)
+
+	if failedIndex >= 0 {
+		failed := stepResults[failedIndex]
+		result.Success = false
+		result.Error = failed.Error
+
+		be.logger.Errorf("%s failed at step %s: %s (completedSteps: %d, totalSteps: %d)",
+			stepType, failed.StepName, failed.Error, result.StepCount, len(nodes))
+
+		if be.rollbackEnabled() {
+			result.RollbackResults = be.rollbackNodes(ctx, nodes, changed, resume.journal)
+		}
+		if err := be.runEventHooks(ctx, stepType+"-failure", stepType, result); err != nil {
+			be.logger.Warnf("%v", err)
+		}
+
+		return result, fmt.Errorf("%s failed at step %s: %w", stepType, failed.StepName, errors.New(failed.Error))
+	}
+
 	// Calculate final result
 	successfulSteps := be.countSuccessfulSteps(result.StepResults)
-	result.Success = successfulSteps == len(steps)
-	result.Duration = time.Since(startTime)
-	result.StepCount = len(result.StepResults)
+	result.Success = successfulSteps == len(nodes)
 
 	if result.Success {
+		if err := be.runEventHooks(ctx, stepType+"-success", stepType, result); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			return result, fmt.Errorf("%s completed but %w", stepType, err)
+		}
+		if resume.journal != nil {
+			resume.journal.markCompleted()
+		}
 		be.logger.Infof("AKS node %s completed successfully (duration: %v, stepCount: %d)",
 			stepType, result.Duration, result.StepCount)
-	} else if stepType == "unbootstrap" {
+		return result, nil
+	}
+
+	if uninstalling(stepType) {
 		be.logger.Warnf("AKS node %s completed with some failures (duration: %v, successfulSteps: %d, totalSteps: %d)",
-			stepType, result.Duration, successfulSteps, len(steps))
+			stepType, result.Duration, successfulSteps, len(nodes))
 		result.Error = fmt.Sprintf("completed with %d failed steps out of %d total steps",
-			len(steps)-successfulSteps, len(steps))
+			len(nodes)-successfulSteps, len(nodes))
+	}
+	if err := be.runEventHooks(ctx, stepType+"-failure", stepType, result); err != nil {
+		be.logger.Warnf("%v", err)
 	}
 
 	return result, nil
 }
 
-// executeStep executes a single step and returns the result
-func (be *BaseExecutor) executeStep(ctx context.Context, step Executor, stepType string) StepResult {
+// runNode executes a single graph node, honouring the resume plan and recording progress in the journal
+func (be *BaseExecutor) runNode(ctx context.Context, node stepNode, stepType string, index int, resume *resumePlan) StepResult {
+	step := node.step
+	if reason, ok := resume.skip[index]; ok {
+		be.logger.Infof("%s step: %s skipped, %s", stepType, step.GetName(), reason)
+		result := be.createStepResult(step.GetName(), time.Now(), true, "")
+		result.SkipReason = reason
+		return result
+	}
+
+	if resume.journal != nil {
+		resume.journal.recordStart(step.GetName(), time.Now())
+	}
+	result := be.executeStepWithHooks(ctx, node, stepType, resume.force[index])
+	if resume.journal != nil {
+		resume.journal.recordEnd(result)
+	}
+	return result
+}
+
+// executeStepWithHooks runs the step between its configured pre-step and post-step hooks.
+// A failing pre-step hook with the fail policy prevents the step from running; a failing post-step
+// hook with the fail policy marks an otherwise successful step as failed.
+func (be *BaseExecutor) executeStepWithHooks(ctx context.Context, node stepNode, stepType string, force bool) StepResult {
+	stepName := node.step.GetName()
+	startTime := time.Now()
+	input := HookInput{Event: HookEventPreStep, StepType: stepType, StepName: stepName}
+
+	preHooks, err := be.runHooks(ctx, be.hooks().PreStepHooks(stepName), input)
+	if err != nil {
+		result := be.createStepResult(stepName, startTime, false, err.Error())
+		result.Hooks = preHooks
+		return result
+	}
+
+	result := be.executeStep(ctx, node.step, stepType, force, node.timeout, node.logs)
+
+	input.Event = HookEventPostStep
+	input.StepResult = &result
+	postHooks, err := be.runHooks(ctx, be.hooks().PostStepHooks(stepName), input)
+	if err != nil && result.Success {
+		result.Success = false
+		result.Error = err.Error()
+		result.SkipReason = ""
+	}
+
+	result.Hooks = append(preHooks, postHooks...)
+	return result
+}
+
+// installing reports whether steps of stepType install components; such runs validate
+// preconditions and stop at the first failure
+func installing(stepType string) bool {
+	return stepType == "bootstrap" || stepType == componentInstallType
+}
+
+// uninstalling reports whether steps of stepType remove components; such runs walk the graph
+// in reverse and continue past failures
+func uninstalling(stepType string) bool {
+	return stepType == "unbootstrap" || stepType == "reset" || stepType == componentUninstallType
+}
+
+// maxParallelSteps returns the configured parallelism limit, never less than one
+func (be *BaseExecutor) maxParallelSteps() int {
+	if be.config == nil || be.config.Agent.MaxParallelSteps < 1 {
+		return 1
+	}
+	return be.config.Agent.MaxParallelSteps
+}
+
+// buildStepNodes resolves step dependencies by name into indexes
+func buildStepNodes(steps []Step) ([]stepNode, error) {
+	indexByName := make(map[string]int, len(steps))
+	for i, step := range steps {
+		name := step.GetName()
+		if _, exists := indexByName[name]; exists {
+			return nil, fmt.Errorf("duplicate step name %s", name)
+		}
+		indexByName[name] = i
+	}
+
+	nodes := make([]stepNode, len(steps))
+	for i, step := range steps {
+		nodes[i] = stepNode{
+			step:            step.Executor,
+			timeout:         step.Timeout,
+			rollback:        step.Rollback,
+			rollbackTimeout: step.RollbackTimeout,
+			logs:            step.logs,
+		}
+		for _, dep := range step.DependsOn {
+			depIndex, ok := indexByName[dep]
+			if !ok {
+				return nil, fmt.Errorf("step %s depends on unknown step %s", step.GetName(), dep)
+			}
+			nodes[i].deps = append(nodes[i].deps, depIndex)
+		}
+	}
+
+	return nodes, nil
+}
+
+// reverseStepNodes flips every dependency edge so that dependents run before their dependencies
+func reverseStepNodes(nodes []stepNode) []stepNode {
+	reversed := make([]stepNode, len(nodes))
+	for i, node := range nodes {
+		reversed[i] = node
+		reversed[i].deps = nil
+	}
+	for i, node := range nodes {
+		for _, dep := range node.deps {
+			reversed[dep].deps = append(reversed[dep].deps, i)
+		}
+	}
+	return reversed
+}
+
+// topologicalOrder returns node indexes in dependency order, preferring declaration order
+// among steps that are ready at the same time, and rejects cyclic graphs
+func topologicalOrder(nodes []stepNode) ([]int, error) {
+	pending := make([]int, len(nodes))
+	dependents := make([][]int, len(nodes))
+	for i, node := range nodes {
+		pending[i] = len(node.deps)
+		for _, dep := range node.deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	ready := make([]int, 0, len(nodes))
+	for i := range nodes {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	order := make([]int, 0, len(nodes))
+	for len(ready) > 0 {
+		sort.Ints(ready)
+		index := ready[0]
+		ready = ready[1:]
+		order = append(order, index)
+		for _, dependent := range dependents[index] {
+			pending[dependent]--
+			if pending[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) != len(nodes) {
+		return nil, fmt.Errorf("dependency cycle detected")
+	}
+	return order, nil
+}
+
+// executeStep executes a single step under its timeout and returns the result.
+// A step that outlives its timeout or the overall deadline is abandoned and reported as timed out,
+// together with its last log lines; it may still be running in the background at that point.
+func (be *BaseExecutor) executeStep(ctx context.Context, step Executor, stepType string, force bool,
+	timeout time.Duration, logs *logTail) StepResult {
+	stepName := step.GetName()
+	startTime := time.Now()
+	timeout = be.stepTimeout(stepName, timeout)
+
+	if ctx.Err() != nil {
+		return be.timedOutStepResult(ctx, stepName, stepType, startTime, timeout, logs)
+	}
+
+	stepCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		stepCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	done := make(chan StepResult, 1)
+	go func() {
+		done <- be.runStep(stepCtx, step, stepType, force)
+	}()
+
+	var result StepResult
+	select {
+	case result = <-done:
+	case <-stepCtx.Done():
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// Cancelled by a failing sibling or the caller: wait for the step to wind down
+			result = <-done
+			break
+		}
+		select {
+		case result = <-done:
+		case <-time.After(stepAbandonGrace):
+			be.logger.Warnf("%s step: %s did not stop after its deadline, leaving it running in the background", stepType, stepName)
+			return be.timedOutStepResult(ctx, stepName, stepType, startTime, timeout, logs)
+		}
+	}
+
+	if !result.Success && errors.Is(stepCtx.Err(), context.DeadlineExceeded) {
+		timedOut := be.timedOutStepResult(ctx, stepName, stepType, startTime, timeout, logs)
+		timedOut.Error = fmt.Sprintf("%s: %s", timedOut.Error, result.Error)
+		timedOut.Attempts = result.Attempts
+		timedOut.LastError = result.LastError
+		return timedOut
+	}
+	if !result.Success {
+		result.LogTail = logs.Lines()
+	}
+	return result
+}
+
+// runStep checks, validates and executes a single step.
+// When force is set the step runs even if it reports itself as completed.
+func (be *BaseExecutor) runStep(ctx context.Context, step Executor, stepType string, force bool) StepResult {
 	stepName := step.GetName()
 	startTime := time.Now()
 
 	be.logger.Infof("Executing %s step %s", stepType, stepName)
+	if be.stepObserver != nil {
+		be.stepObserver(stepType, stepName)
+	}
 
 	// Check if step is already completed
-	if step.IsCompleted(ctx) {
+	if !force && step.IsCompleted(ctx) {
 		be.logger.Infof("%s step: %s already completed", stepType, stepName)
-		return be.createStepResult(stepName, startTime, true, "")
+		result := be.createStepResult(stepName, startTime, true, "")
+		result.SkipReason = "already completed"
+		return result
 	}
 
-	var err error
-	if bootstrapStep, ok := step.(StepExecutor); ok && stepType == "bootstrap" {
+	if bootstrapStep, ok := step.(StepExecutor); ok && installing(stepType) {
 		// Validate preconditions for bootstrap steps
 		if validationErr := bootstrapStep.Validate(ctx); validationErr != nil {
 			be.logger.Errorf("%s step %s validation failed with error: %s", stepType, stepName, validationErr)
-			return be.createStepResult(stepName, startTime, false, fmt.Sprintf("validation failed: %v", validationErr))
+			result := be.createStepResult(stepName, startTime, false, fmt.Sprintf("validation failed: %v", validationErr))
+			// Unmet preconditions are not fixed by retrying unless the step says otherwise
+			result.ErrorClass = retry.Classify(validationErr)
+			if result.ErrorClass == retry.ClassUnclassified {
+				result.ErrorClass = retry.ClassNeedsUserAction
+			}
+			return result
 		}
 	}
 
-	// Execute the step
-	err = step.Execute(ctx)
+	// Execute the step, retrying failed attempts according to the step's policy
+	policy := retry.NoRetry
+	if retryable, ok := step.(Retryable); ok {
+		policy = retryable.RetryPolicy()
+	}
+
+	var lastErr error
+	attempts, err := retry.Do(ctx, policy, step.Execute, func(attempt int, attemptErr error, delay time.Duration) {
+		lastErr = attemptErr
+		be.logger.Warnf("%s step: %s attempt %d/%d failed (%s): %s, retrying in %s",
+			stepType, stepName, attempt, policy.Attempts(), retry.Classify(attemptErr), attemptErr, delay.Round(time.Millisecond))
+	})
 	if err != nil {
-		be.logger.Errorf("%s step: %s failed with error: %s with duration %s", stepType, stepName, err, time.Since(startTime))
-		return be.createStepResult(stepName, startTime, false, err.Error())
+		lastErr = err
 	}
 
-	be.logger.Infof("%s step: %s completed successfully with duration %s", stepType, stepName, time.Since(startTime))
-	return be.createStepResult(stepName, startTime, true, "")
+	var result StepResult
+	if err != nil {
+		be.logger.Errorf("%s step: %s failed with error: %s after %d attempt(s) with duration %s", stepType, stepName, err, attempts, time.Since(startTime))
+		result = be.createStepResult(stepName, startTime, false, err.Error())
+		result.ErrorClass = retry.Classify(err)
+	} else {
+		be.logger.Infof("%s step: %s completed successfully with duration %s", stepType, stepName, time.Since(startTime))
+		result = be.createStepResult(stepName, startTime, true, "")
+	}
+
+	result.Attempts = attempts
+	if lastErr != nil {
+		result.LastError = lastErr.Error()
+	}
+	return result
 }
 
 // createStepResult creates a StepResult with consistent formatting
@@ -3,11 +3,13 @@ package bootstrapper
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 )
 
 // mockExecutor is a mock implementation of Executor interface for testing bootstrap execution flow.
@@ -247,6 +249,40 @@ func TestExecuteSteps_SkipsCompletedSteps(t *testing.T) {
 	}
 }
 
+// TestExecuteSteps_StepObserver verifies that the step observer is told about every started step.
+// Test: Executes three steps, one of them already completed, with an observer registered
+// Expected: The observer sees every step in order with the run's step type
+func TestExecuteSteps_StepObserver(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	executor := NewBaseExecutor(&config.Config{}, logger)
+
+	var observed []string
+	executor.SetStepObserver(func(stepType, stepName string) {
+		observed = append(observed, stepType+"/"+stepName)
+	})
+
+	steps := []Executor{
+		&mockExecutor{name: "step1"},
+		&mockExecutor{name: "step2", isCompleted: true},
+		&mockExecutor{name: "step3"},
+	}
+	if _, err := executor.ExecuteSteps(context.Background(), steps, "bootstrap"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []string{"bootstrap/step1", "bootstrap/step2", "bootstrap/step3"}
+	if len(observed) != len(want) {
+		t.Fatalf("Expected observed steps %v, got %v", want, observed)
+	}
+	for i := range want {
+		if observed[i] != want[i] {
+			t.Errorf("Expected observed steps %v, got %v", want, observed)
+			break
+		}
+	}
+}
+
 // TestExecuteSteps_ValidationFailure verifies bootstrap fails when validation fails.
 // Test: Executes a step that fails validation (before execution)
 // Expected: Step never executes, returns error indicating validation failure
@@ -375,3 +411,368 @@ func TestExecutionResult(t *testing.T) {
 		t.Errorf("Expected 3 step results, got %d", len(result.StepResults))
 	}
 }
+
+// graphMockExecutor is a mock Executor for graph scheduling tests.
+// It records start/finish order in a shared recorder and can block until its context is cancelled.
+type graphMockExecutor struct {
+	name           string
+	shouldFail     bool
+	waitForCancel  bool
+	delay          time.Duration
+	recorder       *executionRecorder
+	cancelObserved bool
+}
+
+func (m *graphMockExecutor) Execute(ctx context.Context) error {
+	m.recorder.start(m.name)
+	defer m.recorder.finish(m.name)
+
+	if m.waitForCancel {
+		<-ctx.Done()
+		m.cancelObserved = true
+		return ctx.Err()
+	}
+	if m.delay > 0 {
+		time.Sleep(m.delay)
+	}
+	if m.shouldFail {
+		return errors.New("mock execution error")
+	}
+	return nil
+}
+
+func (m *graphMockExecutor) IsCompleted(ctx context.Context) bool {
+	return false
+}
+
+func (m *graphMockExecutor) GetName() string {
+	return m.name
+}
+
+// executionRecorder tracks the order and concurrency of graph step execution
+type executionRecorder struct {
+	mu         sync.Mutex
+	started    []string
+	finished   []string
+	running    int
+	maxRunning int
+}
+
+func (r *executionRecorder) start(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started = append(r.started, name)
+	r.running++
+	if r.running > r.maxRunning {
+		r.maxRunning = r.running
+	}
+}
+
+func (r *executionRecorder) finish(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = append(r.finished, name)
+	r.running--
+}
+
+func (r *executionRecorder) indexOf(list []string, name string) int {
+	for i, n := range list {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// TestExecuteGraph_RespectsDependencies verifies that steps only start after their dependencies finish.
+// Test: Diamond graph a -> (b, c) -> d executed with parallelism
+// Expected: a finishes before b and c start, d starts after b and c finish, results are in topological order
+func TestExecuteGraph_RespectsDependencies(t *testing.T) {
+	cfg := &config.Config{Agent: config.AgentConfig{MaxParallelSteps: 4}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	executor := NewBaseExecutor(cfg, logger)
+
+	recorder := &executionRecorder{}
+	steps := []Step{
+		{Executor: &graphMockExecutor{name: "a", recorder: recorder}},
+		{Executor: &graphMockExecutor{name: "b", recorder: recorder, delay: 20 * time.Millisecond}, DependsOn: []string{"a"}},
+		{Executor: &graphMockExecutor{name: "c", recorder: recorder, delay: 20 * time.Millisecond}, DependsOn: []string{"a"}},
+		{Executor: &graphMockExecutor{name: "d", recorder: recorder}, DependsOn: []string{"b", "c"}},
+	}
+
+	result, err := executor.ExecuteGraph(context.Background(), steps, "bootstrap")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !result.Success || result.StepCount != 4 {
+		t.Fatalf("Expected success with 4 steps, got success=%v stepCount=%d", result.Success, result.StepCount)
+	}
+
+	for _, dependent := range []string{"b", "c"} {
+		if recorder.indexOf(recorder.finished, "a") > recorder.indexOf(recorder.started, dependent) {
+			t.Errorf("Step %s started before its dependency a finished", dependent)
+		}
+	}
+	if recorder.maxRunning < 2 {
+		t.Errorf("Expected b and c to run concurrently, max concurrency was %d", recorder.maxRunning)
+	}
+
+	wantOrder := []string{"a", "b", "c", "d"}
+	for i, stepResult := range result.StepResults {
+		if stepResult.StepName != wantOrder[i] {
+			t.Errorf("StepResults[%d] = %s, want %s", i, stepResult.StepName, wantOrder[i])
+		}
+	}
+}
+
+// TestExecuteGraph_ParallelismLimit verifies the scheduler never exceeds the configured limit.
+// Test: Five independent steps with MaxParallelSteps=2
+// Expected: At most 2 steps run at the same time and all steps succeed
+func TestExecuteGraph_ParallelismLimit(t *testing.T) {
+	cfg := &config.Config{Agent: config.AgentConfig{MaxParallelSteps: 2}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	executor := NewBaseExecutor(cfg, logger)
+
+	recorder := &executionRecorder{}
+	steps := make([]Step, 0, 5)
+	for _, name := range []string{"s1", "s2", "s3", "s4", "s5"} {
+		steps = append(steps, Step{Executor: &graphMockExecutor{name: name, recorder: recorder, delay: 10 * time.Millisecond}})
+	}
+
+	result, err := executor.ExecuteGraph(context.Background(), steps, "bootstrap")
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if !result.Success || result.StepCount != 5 {
+		t.Fatalf("Expected success with 5 steps, got success=%v stepCount=%d", result.Success, result.StepCount)
+	}
+	if recorder.maxRunning > 2 {
+		t.Errorf("Expected at most 2 concurrent steps, got %d", recorder.maxRunning)
+	}
+}
+
+// TestExecuteGraph_FailFastCancelsSiblings verifies bootstrap fail-fast behaviour in a parallel graph.
+// Test: Two independent steps where one fails while the other is still running, plus a dependent step
+// Expected: Running sibling is cancelled, dependent step never starts, error is returned
+func TestExecuteGraph_FailFastCancelsSiblings(t *testing.T) {
+	cfg := &config.Config{Agent: config.AgentConfig{MaxParallelSteps: 4}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	executor := NewBaseExecutor(cfg, logger)
+
+	recorder := &executionRecorder{}
+	sibling := &graphMockExecutor{name: "sibling", recorder: recorder, waitForCancel: true}
+	failing := &graphMockExecutor{name: "failing", recorder: recorder, shouldFail: true, delay: 10 * time.Millisecond}
+	dependent := &graphMockExecutor{name: "dependent", recorder: recorder}
+	steps := []Step{
+		{Executor: sibling},
+		{Executor: failing},
+		{Executor: dependent, DependsOn: []string{"failing"}},
+	}
+
+	result, err := executor.ExecuteGraph(context.Background(), steps, "bootstrap")
+	if err == nil {
+		t.Fatal("Expected error from failing step")
+	}
+	if result.Success {
+		t.Error("Result should not be successful")
+	}
+	if !sibling.cancelObserved {
+		t.Error("Running sibling should have been cancelled")
+	}
+	if recorder.indexOf(recorder.started, "dependent") != -1 {
+		t.Error("Dependent step should not have started")
+	}
+	if result.StepCount != 2 {
+		t.Errorf("Expected 2 step results, got %d", result.StepCount)
+	}
+}
+
+// TestExecuteGraph_UnbootstrapRunsInReverse verifies unbootstrap walks the dependency graph backwards.
+// Test: Chain a -> b -> c declared in bootstrap order, executed as unbootstrap with a failing middle step
+// Expected: Steps execute c, b, a; the failure does not stop a from running
+func TestExecuteGraph_UnbootstrapRunsInReverse(t *testing.T) {
+	cfg := &config.Config{Agent: config.AgentConfig{MaxParallelSteps: 4}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	executor := NewBaseExecutor(cfg, logger)
+
+	recorder := &executionRecorder{}
+	steps := []Step{
+		{Executor: &graphMockExecutor{name: "a", recorder: recorder}},
+		{Executor: &graphMockExecutor{name: "b", recorder: recorder, shouldFail: true}, DependsOn: []string{"a"}},
+		{Executor: &graphMockExecutor{name: "c", recorder: recorder}, DependsOn: []string{"b"}},
+	}
+
+	result, err := executor.ExecuteGraph(context.Background(), steps, "unbootstrap")
+	if err != nil {
+		t.Fatalf("Unbootstrap should not return error, got: %v", err)
+	}
+	if result.Success {
+		t.Error("Result should not be successful when a step fails")
+	}
+
+	wantOrder := []string{"c", "b", "a"}
+	if len(recorder.started) != len(wantOrder) {
+		t.Fatalf("Expected %d steps to run, got %v", len(wantOrder), recorder.started)
+	}
+	for i, name := range wantOrder {
+		if recorder.started[i] != name {
+			t.Errorf("started[%d] = %s, want %s", i, recorder.started[i], name)
+		}
+	}
+}
+
+// TestExecuteGraph_InvalidGraph verifies graph validation before any step runs.
+// Test: Graphs with a cycle, an unknown dependency and a duplicate step name
+// Expected: ExecuteGraph returns an error and no step executes
+func TestExecuteGraph_InvalidGraph(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(r *executionRecorder) []Step
+	}{
+		{
+			name: "cycle",
+			steps: func(r *executionRecorder) []Step {
+				return []Step{
+					{Executor: &graphMockExecutor{name: "a", recorder: r}, DependsOn: []string{"b"}},
+					{Executor: &graphMockExecutor{name: "b", recorder: r}, DependsOn: []string{"a"}},
+				}
+			},
+		},
+		{
+			name: "unknown dependency",
+			steps: func(r *executionRecorder) []Step {
+				return []Step{
+					{Executor: &graphMockExecutor{name: "a", recorder: r}, DependsOn: []string{"missing"}},
+				}
+			},
+		},
+		{
+			name: "duplicate name",
+			steps: func(r *executionRecorder) []Step {
+				return []Step{
+					{Executor: &graphMockExecutor{name: "a", recorder: r}},
+					{Executor: &graphMockExecutor{name: "a", recorder: r}},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.ErrorLevel)
+			executor := NewBaseExecutor(&config.Config{}, logger)
+
+			recorder := &executionRecorder{}
+			if _, err := executor.ExecuteGraph(context.Background(), tt.steps(recorder), "bootstrap"); err == nil {
+				t.Error("Expected error for invalid graph")
+			}
+			if len(recorder.started) != 0 {
+				t.Errorf("No step should run for an invalid graph, got %v", recorder.started)
+			}
+		})
+	}
+}
+
+// retryMockExecutor fails a fixed number of times with the given error before succeeding
+type retryMockExecutor struct {
+	name     string
+	failures int
+	err      error
+	policy   retry.Policy
+	calls    int
+}
+
+func (m *retryMockExecutor) Execute(ctx context.Context) error {
+	m.calls++
+	if m.calls <= m.failures {
+		return m.err
+	}
+	return nil
+}
+
+func (m *retryMockExecutor) IsCompleted(ctx context.Context) bool {
+	return false
+}
+
+func (m *retryMockExecutor) GetName() string {
+	return m.name
+}
+
+func (m *retryMockExecutor) RetryPolicy() retry.Policy {
+	return m.policy
+}
+
+// TestExecuteSteps_RetryPolicy verifies that executeStep applies the step's retry policy.
+// Test: Runs steps that fail with transient or permanent errors under a three-attempt policy
+// Expected: Transient failures are retried; attempts, last error and error class are recorded in StepResult
+func TestExecuteSteps_RetryPolicy(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		name          string
+		failures      int
+		err           error
+		wantSuccess   bool
+		wantAttempts  int
+		wantClass     retry.Class
+		wantLastError bool
+	}{
+		{"transient error recovered", 2, retry.Transient(errors.New("connection reset")), true, 3, "", true},
+		{"transient error exhausted", 5, retry.Transient(errors.New("connection reset")), false, 3, retry.ClassTransient, true},
+		{"permanent error not retried", 5, retry.Permanent(errors.New("404 not found")), false, 1, retry.ClassPermanent, true},
+		{"no failures", 0, nil, true, 1, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetLevel(logrus.ErrorLevel)
+			executor := NewBaseExecutor(&config.Config{}, logger)
+
+			step := &retryMockExecutor{name: "step", failures: tt.failures, err: tt.err, policy: policy}
+			result, _ := executor.ExecuteSteps(context.Background(), []Executor{step}, "bootstrap")
+			if result == nil || len(result.StepResults) != 1 {
+				t.Fatalf("Expected one step result, got %+v", result)
+			}
+
+			stepResult := result.StepResults[0]
+			if stepResult.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", stepResult.Success, tt.wantSuccess)
+			}
+			if stepResult.Attempts != tt.wantAttempts || step.calls != tt.wantAttempts {
+				t.Errorf("Attempts = %d (calls %d), want %d", stepResult.Attempts, step.calls, tt.wantAttempts)
+			}
+			if stepResult.ErrorClass != tt.wantClass {
+				t.Errorf("ErrorClass = %q, want %q", stepResult.ErrorClass, tt.wantClass)
+			}
+			if (stepResult.LastError != "") != tt.wantLastError {
+				t.Errorf("LastError = %q, want present: %v", stepResult.LastError, tt.wantLastError)
+			}
+		})
+	}
+}
+
+// TestExecuteSteps_NoRetryWithoutPolicy verifies that executors without a retry policy run once.
+// Test: Runs a failing mockExecutor that does not implement Retryable
+// Expected: The step executes exactly once and records a single attempt
+func TestExecuteSteps_NoRetryWithoutPolicy(t *testing.T) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	executor := NewBaseExecutor(&config.Config{}, logger)
+
+	result, err := executor.ExecuteSteps(context.Background(), []Executor{&mockExecutor{name: "step", shouldFail: true}}, "bootstrap")
+	if err == nil {
+		t.Fatal("Expected bootstrap to fail")
+	}
+	if result.StepResults[0].Attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", result.StepResults[0].Attempts)
+	}
+	if result.StepResults[0].ErrorClass != retry.ClassUnclassified {
+		t.Errorf("Expected unclassified error, got %q", result.StepResults[0].ErrorClass)
+	}
+}
@@ -0,0 +1,152 @@
+package bootstrapper
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// Hook events passed to hook executables
+const (
+	HookEventPreStep  = "pre-step"
+	HookEventPostStep = "post-step"
+)
+
+const (
+	// hookOutputLimit is the number of trailing output bytes of a hook kept in its result
+	hookOutputLimit = 4096
+
+	// hookWaitDelay bounds waiting for output of processes a timed out hook left behind
+	hookWaitDelay = 5 * time.Second
+)
+
+// HookInput is the JSON document a hook receives on stdin
+type HookInput struct {
+	Event      string           `json:"event"`
+	StepType   string           `json:"step_type"`
+	StepName   string           `json:"step_name,omitempty"`
+	StepResult *StepResult      `json:"step_result,omitempty"` // post-step hooks only
+	Result     *ExecutionResult `json:"result,omitempty"`      // success and failure events only
+}
+
+// HookResult records the outcome of a single hook execution
+type HookResult struct {
+	Event    string        `json:"event"`
+	StepName string        `json:"step_name,omitempty"`
+	Command  string        `json:"command"`
+	Policy   string        `json:"policy"`
+	Success  bool          `json:"success"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+	Output   string        `json:"output,omitempty"` // trailing combined stdout and stderr
+}
+
+// hooks returns the configured lifecycle hooks
+func (be *BaseExecutor) hooks() config.HooksConfig {
+	if be.config == nil {
+		return config.HooksConfig{}
+	}
+	return be.config.Hooks
+}
+
+// runEventHooks runs the hooks of a run-level event such as bootstrap-start, recording their outcomes in result
+func (be *BaseExecutor) runEventHooks(ctx context.Context, event, stepType string, result *ExecutionResult) error {
+	input := HookInput{Event: event, StepType: stepType}
+	if !strings.HasSuffix(event, "-start") {
+		input.Result = result
+	}
+	hookResults, err := be.runHooks(ctx, be.hooks().EventHooks(event), input)
+	result.Hooks = append(result.Hooks, hookResults...)
+	return err
+}
+
+// runHooks runs hooks one after another. A failing hook with the fail policy stops the remaining
+// hooks and is returned as error; a failing hook with the warn policy is only logged.
+func (be *BaseExecutor) runHooks(ctx context.Context, hooks []config.HookConfig, input HookInput) ([]HookResult, error) {
+	var results []HookResult
+	for _, hook := range hooks {
+		result := be.runHook(ctx, hook, input)
+		results = append(results, result)
+		if result.Success {
+			continue
+		}
+
+		if hook.Policy == config.HookPolicyWarn {
+			be.logger.Warnf("%s hook %s failed: %s (continuing, policy is warn)", input.Event, hook.Command, result.Error)
+			continue
+		}
+		be.logger.Errorf("%s hook %s failed: %s", input.Event, hook.Command, result.Error)
+		return results, fmt.Errorf("%s hook %s failed: %s", input.Event, hook.Command, result.Error)
+	}
+	return results, nil
+}
+
+// runHook executes a single hook with the input as JSON on stdin
+func (be *BaseExecutor) runHook(ctx context.Context, hook config.HookConfig, input HookInput) HookResult {
+	startTime := time.Now()
+	result := HookResult{
+		Event:    input.Event,
+		StepName: input.StepName,
+		Command:  strings.TrimSpace(hook.Command + " " + strings.Join(hook.Args, " ")),
+		Policy:   hook.Policy,
+	}
+	if result.Policy == "" {
+		result.Policy = config.HookPolicyFail
+	}
+
+	data, err := json.Marshal(input)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to marshal hook input: %v", err)
+		return result
+	}
+
+	hookCtx, cancel := ctx, context.CancelFunc(func() {})
+	if hook.Timeout > 0 {
+		hookCtx, cancel = context.WithTimeout(ctx, hook.Timeout)
+	}
+	defer cancel()
+
+	be.logger.Infof("Running %s hook %s", input.Event, result.Command)
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(hookCtx, hook.Command, hook.Args...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = hookWaitDelay
+	cmd.Env = append(os.Environ(),
+		"AKS_FLEX_NODE_HOOK_EVENT="+input.Event,
+		"AKS_FLEX_NODE_STEP_TYPE="+input.StepType,
+		"AKS_FLEX_NODE_STEP_NAME="+input.StepName,
+	)
+
+	err = cmd.Run()
+	result.Duration = time.Since(startTime)
+	result.Output = tailString(strings.TrimSpace(output.String()), hookOutputLimit)
+
+	switch {
+	case errors.Is(hookCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil:
+		result.Error = fmt.Sprintf("timed out after %s", hook.Timeout)
+	case err != nil:
+		result.Error = err.Error()
+	default:
+		result.Success = true
+	}
+	return result
+}
+
+// tailString returns the last limit bytes of s
+func tailString(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return s[len(s)-limit:]
+}
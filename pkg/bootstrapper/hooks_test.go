@@ -0,0 +1,169 @@
+package bootstrapper
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// writeHookScript creates an executable shell script that copies its stdin to a file and exits with exitCode
+func writeHookScript(t *testing.T, exitCode int) (script, stdinFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	script = filepath.Join(dir, "hook.sh")
+	stdinFile = filepath.Join(dir, "stdin.json")
+	content := "#!/bin/sh\ncat > " + stdinFile + "\necho hook output\nexit " + strconv.Itoa(exitCode) + "\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatalf("Failed to write hook script: %v", err)
+	}
+	return script, stdinFile
+}
+
+// newHookTestExecutor creates a BaseExecutor with the given hooks and no journal
+func newHookTestExecutor(hooks config.HooksConfig) *BaseExecutor {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewBaseExecutor(&config.Config{Hooks: hooks}, logger)
+}
+
+// TestExecuteGraph_PreStepHookPolicy verifies how failing pre-step hooks affect their step.
+// Test: Runs a step whose pre-step hook exits non-zero with the fail and the warn policy
+// Expected: With fail the step never runs and bootstrap fails; with warn the step runs and succeeds.
+// In both cases the hook outcome is recorded on the step result.
+func TestExecuteGraph_PreStepHookPolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantSuccess bool
+		wantStarted int
+	}{
+		{policy: config.HookPolicyFail, wantSuccess: false, wantStarted: 0},
+		{policy: config.HookPolicyWarn, wantSuccess: true, wantStarted: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			script, _ := writeHookScript(t, 1)
+			executor := newHookTestExecutor(config.HooksConfig{
+				PreStep: map[string][]config.HookConfig{
+					"step": {{Command: script, Timeout: time.Minute, Policy: tt.policy}},
+				},
+			})
+
+			recorder := &executionRecorder{}
+			steps := []Step{{Executor: &graphMockExecutor{name: "step", recorder: recorder}}}
+			result, err := executor.ExecuteGraph(context.Background(), steps, "bootstrap")
+
+			if (err == nil) != tt.wantSuccess || result.Success != tt.wantSuccess {
+				t.Fatalf("Expected success=%v, got success=%v err=%v", tt.wantSuccess, result.Success, err)
+			}
+			if len(recorder.started) != tt.wantStarted {
+				t.Errorf("Expected %d step executions, got %v", tt.wantStarted, recorder.started)
+			}
+
+			hooks := result.StepResults[0].Hooks
+			if len(hooks) != 1 || hooks[0].Success || hooks[0].Event != HookEventPreStep || hooks[0].Output != "hook output" {
+				t.Errorf("Expected one failed pre-step hook with its output recorded, got %+v", hooks)
+			}
+		})
+	}
+}
+
+// TestExecuteGraph_PostStepHookInput verifies the input of post-step hooks.
+// Test: Runs a successful step with a post-step hook that saves its stdin
+// Expected: The hook receives the step name and step result as JSON
+func TestExecuteGraph_PostStepHookInput(t *testing.T) {
+	script, stdinFile := writeHookScript(t, 0)
+	executor := newHookTestExecutor(config.HooksConfig{
+		PostStep: map[string][]config.HookConfig{
+			"step": {{Command: script, Timeout: time.Minute}},
+		},
+	})
+
+	steps := []Step{{Executor: &graphMockExecutor{name: "step", recorder: &executionRecorder{}}}}
+	if _, err := executor.ExecuteGraph(context.Background(), steps, "bootstrap"); err != nil {
+		t.Fatalf("Expected bootstrap to succeed, got: %v", err)
+	}
+
+	data, err := os.ReadFile(stdinFile)
+	if err != nil {
+		t.Fatalf("Expected hook to record its stdin: %v", err)
+	}
+	var input HookInput
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatalf("Expected hook stdin to be JSON, got %q: %v", data, err)
+	}
+	if input.Event != HookEventPostStep || input.StepName != "step" || input.StepResult == nil || !input.StepResult.Success {
+		t.Errorf("Unexpected hook input: %+v", input)
+	}
+}
+
+// TestExecuteGraph_EventHooks verifies run-level event hooks.
+// Test: Runs a successful and a failing bootstrap with hooks on every bootstrap event
+// Expected: start plus success or failure hooks run in that order and are recorded on the execution result;
+// a failing bootstrap-start hook aborts the run before any step executes
+func TestExecuteGraph_EventHooks(t *testing.T) {
+	tests := []struct {
+		name        string
+		failStep    bool
+		failStart   bool
+		wantEvents  []string
+		wantStarted int
+	}{
+		{name: "success", wantEvents: []string{"bootstrap-start", "bootstrap-success"}, wantStarted: 1},
+		{name: "failure", failStep: true, wantEvents: []string{"bootstrap-start", "bootstrap-failure"}, wantStarted: 1},
+		{name: "failing start hook aborts", failStart: true, wantEvents: []string{"bootstrap-start"}, wantStarted: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			okScript, _ := writeHookScript(t, 0)
+			startScript := okScript
+			if tt.failStart {
+				startScript, _ = writeHookScript(t, 1)
+			}
+			executor := newHookTestExecutor(config.HooksConfig{
+				Events: map[string][]config.HookConfig{
+					"bootstrap-start":   {{Command: startScript, Timeout: time.Minute}},
+					"bootstrap-success": {{Command: okScript, Timeout: time.Minute}},
+					"bootstrap-failure": {{Command: okScript, Timeout: time.Minute}},
+				},
+			})
+
+			recorder := &executionRecorder{}
+			steps := []Step{{Executor: &graphMockExecutor{name: "step", recorder: recorder, shouldFail: tt.failStep}}}
+			result, _ := executor.ExecuteGraph(context.Background(), steps, "bootstrap")
+
+			var events []string
+			for _, hook := range result.Hooks {
+				events = append(events, hook.Event)
+			}
+			if strings.Join(events, ",") != strings.Join(tt.wantEvents, ",") {
+				t.Errorf("Expected hooks %v, got %v", tt.wantEvents, events)
+			}
+			if len(recorder.started) != tt.wantStarted {
+				t.Errorf("Expected %d step executions, got %v", tt.wantStarted, recorder.started)
+			}
+		})
+	}
+}
+
+// TestRunHook_Timeout verifies that a hook exceeding its timeout is stopped and reported.
+// Test: Runs a hook that sleeps longer than its timeout
+// Expected: The hook fails with a timed out error
+func TestRunHook_Timeout(t *testing.T) {
+	executor := newHookTestExecutor(config.HooksConfig{})
+	hook := config.HookConfig{Command: "sleep", Args: []string{"10"}, Timeout: 50 * time.Millisecond}
+
+	result := executor.runHook(context.Background(), hook, HookInput{Event: HookEventPreStep, StepType: "bootstrap"})
+	if result.Success || !strings.HasPrefix(result.Error, "timed out after 50ms") {
+		t.Errorf("Expected timed out hook, got %+v", result)
+	}
+}
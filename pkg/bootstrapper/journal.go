@@ -0,0 +1,309 @@
+package bootstrapper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+const (
+	// journalFileName is the name of the bootstrap checkpoint journal inside the agent state directory
+	journalFileName = "bootstrap-journal.json"
+
+	// Journal step outcomes
+	JournalOutcomeRunning    = "running"
+	JournalOutcomeSucceeded  = "succeeded"
+	JournalOutcomeFailed     = "failed"
+	JournalOutcomeRolledBack = "rolled_back"
+)
+
+// JournalEntry records the progress of a single bootstrap step
+type JournalEntry struct {
+	StepName   string    `json:"step_name"`
+	ConfigHash string    `json:"config_hash"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// JournalState is the persisted content of the bootstrap journal
+type JournalState struct {
+	ConfigHash string         `json:"config_hash"`
+	StartedAt  time.Time      `json:"started_at"`
+	Completed  bool           `json:"completed"`
+	Steps      []JournalEntry `json:"steps"`
+}
+
+// Journal is a durable checkpoint log of a bootstrap run.
+// Every update is written atomically so a crash or reboot never leaves a partial file behind.
+type Journal struct {
+	path   string
+	logger *logrus.Logger
+
+	mu    sync.Mutex
+	state JournalState
+}
+
+// GetJournalPath returns the bootstrap journal path inside the given state directory
+func GetJournalPath(stateDir string) string {
+	return filepath.Join(stateDir, journalFileName)
+}
+
+// LoadJournalState reads a previously persisted journal.
+// Returns nil without error when no journal exists yet.
+func LoadJournalState(path string) (*JournalState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal %s: %w", path, err)
+	}
+
+	state := &JournalState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// RemoveJournal deletes the bootstrap journal from the given state directory
+func RemoveJournal(stateDir string) error {
+	if err := os.Remove(GetJournalPath(stateDir)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove bootstrap journal: %w", err)
+	}
+	return nil
+}
+
+// newJournal starts a fresh journal for the current run, carrying over the given entries
+func newJournal(path, configHash string, carried []JournalEntry, logger *logrus.Logger) *Journal {
+	return &Journal{
+		path:   path,
+		logger: logger,
+		state: JournalState{
+			ConfigHash: configHash,
+			StartedAt:  time.Now(),
+			Steps:      append([]JournalEntry{}, carried...),
+		},
+	}
+}
+
+// succeededEntry returns the last successful journal entry for the step, if any
+func (s *JournalState) succeededEntry(stepName string) (JournalEntry, bool) {
+	for i := len(s.Steps) - 1; i >= 0; i-- {
+		if s.Steps[i].StepName == stepName {
+			return s.Steps[i], s.Steps[i].Outcome == JournalOutcomeSucceeded
+		}
+	}
+	return JournalEntry{}, false
+}
+
+// recordStart marks the step as running
+func (j *Journal) recordStart(stepName string, startTime time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state.Steps = append(j.state.Steps, JournalEntry{
+		StepName:   stepName,
+		ConfigHash: j.state.ConfigHash,
+		StartTime:  startTime,
+		Outcome:    JournalOutcomeRunning,
+	})
+	j.saveLocked()
+}
+
+// recordEnd stores the outcome of the step's most recent run
+func (j *Journal) recordEnd(result StepResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := len(j.state.Steps) - 1; i >= 0; i-- {
+		if j.state.Steps[i].StepName != result.StepName {
+			continue
+		}
+		j.state.Steps[i].EndTime = time.Now()
+		j.state.Steps[i].Error = result.Error
+		if result.Success {
+			j.state.Steps[i].Outcome = JournalOutcomeSucceeded
+		} else {
+			j.state.Steps[i].Outcome = JournalOutcomeFailed
+		}
+		break
+	}
+	j.saveLocked()
+}
+
+// recordRollback marks the step's most recent run as undone by a rollback
+func (j *Journal) recordRollback(stepName string, endTime time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	for i := len(j.state.Steps) - 1; i >= 0; i-- {
+		if j.state.Steps[i].StepName == stepName {
+			j.state.Steps[i].EndTime = endTime
+			j.state.Steps[i].Outcome = JournalOutcomeRolledBack
+			break
+		}
+	}
+	j.saveLocked()
+}
+
+// markCompleted records that every step of the run finished successfully
+func (j *Journal) markCompleted() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state.Completed = true
+	j.saveLocked()
+}
+
+// saveLocked persists the journal; failures are logged but never fail the bootstrap
+func (j *Journal) saveLocked() {
+	data, err := json.MarshalIndent(j.state, "", "  ")
+	if err != nil {
+		j.logger.Warnf("Failed to marshal bootstrap journal: %v", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0750); err != nil {
+		j.logger.Warnf("Failed to create state directory for bootstrap journal: %v", err)
+		return
+	}
+
+	if err := utils.WriteFileAtomic(j.path, data, 0600); err != nil {
+		j.logger.Warnf("Failed to write bootstrap journal %s: %v", j.path, err)
+	}
+}
+
+// ResumeOptions control how a bootstrap run uses the journal of a previous run
+type ResumeOptions struct {
+	// RestartFrom re-runs the named step and every step that depends on it,
+	// trusting the journal for all other steps even if the configuration changed
+	RestartFrom string
+
+	// Force ignores the journal and re-runs every step even if it reports as completed
+	Force bool
+}
+
+// resumePlan is the per-step outcome of applying the journal and ResumeOptions to a step graph
+type resumePlan struct {
+	journal *Journal
+	skip    map[int]string // node index -> reason the step is skipped
+	force   map[int]bool   // node index -> step bypasses IsCompleted
+}
+
+// planResume loads the previous journal and decides which steps can be skipped or must be forced
+func (be *BaseExecutor) planResume(nodes []stepNode, stepType string) (*resumePlan, error) {
+	plan := &resumePlan{
+		skip:  make(map[int]string),
+		force: make(map[int]bool),
+	}
+	if stepType != "bootstrap" && stepType != componentInstallType {
+		return plan, nil
+	}
+
+	restartIndex := -1
+	if be.resume.RestartFrom != "" {
+		for i, node := range nodes {
+			if node.step.GetName() == be.resume.RestartFrom {
+				restartIndex = i
+				break
+			}
+		}
+		if restartIndex < 0 {
+			return nil, fmt.Errorf("unknown restart-from step %s", be.resume.RestartFrom)
+		}
+		for index := range descendants(nodes, restartIndex) {
+			plan.force[index] = true
+		}
+	}
+
+	if be.resume.Force {
+		be.logger.Info("Force requested, ignoring bootstrap journal and re-running all steps")
+		for i := range nodes {
+			plan.force[i] = true
+		}
+	}
+
+	// Only full bootstrap runs are checkpointed; component installs just honour forced steps
+	if stepType != "bootstrap" || be.config == nil || be.config.Agent.StateDir == "" {
+		return plan, nil
+	}
+
+	path := GetJournalPath(be.config.Agent.StateDir)
+	configHash := be.config.Hash()
+
+	var carried []JournalEntry
+	if !be.resume.Force {
+		previous, err := LoadJournalState(path)
+		if err != nil {
+			be.logger.Warnf("Ignoring unreadable bootstrap journal: %v", err)
+		}
+
+		switch {
+		case previous == nil:
+		case restartIndex >= 0:
+			be.logger.Infof("Restarting bootstrap from step %s using journal of previous run", be.resume.RestartFrom)
+			carried = be.skipJournaledSteps(nodes, previous, plan)
+		case previous.Completed:
+			be.logger.Debug("Previous bootstrap run completed, starting a fresh journal")
+		case previous.ConfigHash != configHash:
+			be.logger.Info("Configuration changed since the interrupted bootstrap run, not resuming from journal")
+		default:
+			be.logger.Info("Resuming interrupted bootstrap run from journal")
+			carried = be.skipJournaledSteps(nodes, previous, plan)
+		}
+	}
+
+	plan.journal = newJournal(path, configHash, carried, be.logger)
+	return plan, nil
+}
+
+// skipJournaledSteps marks steps that succeeded in the previous run as skipped unless they are forced,
+// returning the journal entries to carry into the new run
+func (be *BaseExecutor) skipJournaledSteps(nodes []stepNode, previous *JournalState, plan *resumePlan) []JournalEntry {
+	var carried []JournalEntry
+	for i, node := range nodes {
+		if plan.force[i] {
+			continue
+		}
+		if entry, ok := previous.succeededEntry(node.step.GetName()); ok {
+			plan.skip[i] = fmt.Sprintf("completed in previous run at %s", entry.EndTime.Format(time.RFC3339))
+			carried = append(carried, entry)
+		}
+	}
+	return carried
+}
+
+// descendants returns the given node and every node that transitively depends on it
+func descendants(nodes []stepNode, root int) map[int]bool {
+	dependents := make([][]int, len(nodes))
+	for i, node := range nodes {
+		for _, dep := range node.deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	result := map[int]bool{root: true}
+	queue := []int{root}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[current] {
+			if !result[dependent] {
+				result[dependent] = true
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	return result
+}
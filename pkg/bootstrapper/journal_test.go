@@ -0,0 +1,160 @@
+package bootstrapper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// newJournalTestExecutor creates a BaseExecutor whose journal lives in a temporary state directory
+func newJournalTestExecutor(t *testing.T) *BaseExecutor {
+	t.Helper()
+	cfg := &config.Config{Agent: config.AgentConfig{StateDir: t.TempDir(), MaxParallelSteps: 1}}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewBaseExecutor(cfg, logger)
+}
+
+// journalTestSteps builds the chain a -> b -> c with the given step failing
+func journalTestSteps(recorder *executionRecorder, failing string) []Step {
+	return []Step{
+		{Executor: &graphMockExecutor{name: "a", recorder: recorder, shouldFail: failing == "a"}},
+		{Executor: &graphMockExecutor{name: "b", recorder: recorder, shouldFail: failing == "b"}, DependsOn: []string{"a"}},
+		{Executor: &graphMockExecutor{name: "c", recorder: recorder, shouldFail: failing == "c"}, DependsOn: []string{"b"}},
+	}
+}
+
+// TestJournal_ResumesInterruptedRun verifies that a failed run is resumed from the first incomplete step.
+// Test: First run fails at step b, second run succeeds with the same config
+// Expected: Second run skips a with a skip reason and executes only b and c; journal is marked completed
+func TestJournal_ResumesInterruptedRun(t *testing.T) {
+	executor := newJournalTestExecutor(t)
+
+	if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(&executionRecorder{}, "b"), "bootstrap"); err == nil {
+		t.Fatal("Expected first run to fail")
+	}
+
+	recorder := &executionRecorder{}
+	result, err := executor.ExecuteGraph(context.Background(), journalTestSteps(recorder, ""), "bootstrap")
+	if err != nil {
+		t.Fatalf("Expected resumed run to succeed, got: %v", err)
+	}
+
+	if len(recorder.started) != 2 || recorder.started[0] != "b" || recorder.started[1] != "c" {
+		t.Errorf("Expected only b and c to execute, got %v", recorder.started)
+	}
+	if result.StepResults[0].SkipReason == "" {
+		t.Error("Expected skipped step a to carry a skip reason")
+	}
+
+	state, err := LoadJournalState(GetJournalPath(executor.config.Agent.StateDir))
+	if err != nil || state == nil {
+		t.Fatalf("Expected journal to be readable, got state=%v err=%v", state, err)
+	}
+	if !state.Completed {
+		t.Error("Expected journal to be marked completed")
+	}
+}
+
+// TestJournal_ResumeOverrides verifies how config changes, completed runs and resume options affect resumption.
+// Test: Run a -> b -> c to a failure at c, then re-run under different conditions
+// Expected: A config unchanged apart from agent settings resumes; force and restart-from override the journal
+func TestJournal_ResumeOverrides(t *testing.T) {
+	tests := []struct {
+		name         string
+		changeConfig func(cfg *config.Config)
+		opts         ResumeOptions
+		wantStarted  []string
+	}{
+		{
+			name:        "unchanged config resumes",
+			wantStarted: []string{"c"},
+		},
+		{
+			name:         "changed log level resumes",
+			changeConfig: func(cfg *config.Config) { cfg.Agent.LogLevel = "debug" },
+			wantStarted:  []string{"c"},
+		},
+		{
+			name:         "changed config starts over",
+			changeConfig: func(cfg *config.Config) { cfg.Kubernetes.Version = "1.33.1" },
+			wantStarted:  []string{"a", "b", "c"},
+		},
+		{
+			name:        "force re-runs everything",
+			opts:        ResumeOptions{Force: true},
+			wantStarted: []string{"a", "b", "c"},
+		},
+		{
+			name:         "restart-from re-runs step and dependents despite config change",
+			changeConfig: func(cfg *config.Config) { cfg.Kubernetes.Version = "1.33.1" },
+			opts:         ResumeOptions{RestartFrom: "b"},
+			wantStarted:  []string{"b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := newJournalTestExecutor(t)
+			if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(&executionRecorder{}, "c"), "bootstrap"); err == nil {
+				t.Fatal("Expected first run to fail")
+			}
+
+			if tt.changeConfig != nil {
+				tt.changeConfig(executor.config)
+			}
+			executor.SetResumeOptions(tt.opts)
+
+			recorder := &executionRecorder{}
+			if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(recorder, ""), "bootstrap"); err != nil {
+				t.Fatalf("Expected second run to succeed, got: %v", err)
+			}
+
+			if len(recorder.started) != len(tt.wantStarted) {
+				t.Fatalf("Expected steps %v to execute, got %v", tt.wantStarted, recorder.started)
+			}
+			for i, name := range tt.wantStarted {
+				if recorder.started[i] != name {
+					t.Errorf("started[%d] = %s, want %s", i, recorder.started[i], name)
+				}
+			}
+		})
+	}
+}
+
+// TestJournal_CompletedRunIsNotResumed verifies that a successful run does not short-circuit later runs.
+// Test: Run a successful bootstrap twice with the same config
+// Expected: The second run executes every step again
+func TestJournal_CompletedRunIsNotResumed(t *testing.T) {
+	executor := newJournalTestExecutor(t)
+
+	if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(&executionRecorder{}, ""), "bootstrap"); err != nil {
+		t.Fatalf("Expected first run to succeed, got: %v", err)
+	}
+
+	recorder := &executionRecorder{}
+	if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(recorder, ""), "bootstrap"); err != nil {
+		t.Fatalf("Expected second run to succeed, got: %v", err)
+	}
+	if len(recorder.started) != 3 {
+		t.Errorf("Expected all steps to execute, got %v", recorder.started)
+	}
+}
+
+// TestJournal_UnknownRestartFrom verifies restart-from validation.
+// Test: Sets RestartFrom to a step that is not part of the graph
+// Expected: ExecuteGraph returns an error without executing any step
+func TestJournal_UnknownRestartFrom(t *testing.T) {
+	executor := newJournalTestExecutor(t)
+	executor.SetResumeOptions(ResumeOptions{RestartFrom: "missing"})
+
+	recorder := &executionRecorder{}
+	if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(recorder, ""), "bootstrap"); err == nil {
+		t.Error("Expected error for unknown restart-from step")
+	}
+	if len(recorder.started) != 0 {
+		t.Errorf("Expected no steps to execute, got %v", recorder.started)
+	}
+}
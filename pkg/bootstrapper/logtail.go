@@ -0,0 +1,69 @@
+package bootstrapper
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logTailSize is the number of log lines kept per step for timeout reports
+const logTailSize = 20
+
+// logTail is a logrus hook that keeps the most recent log lines of a step
+type logTail struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+// Levels implements logrus.Hook
+func (t *logTail) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (t *logTail) Fire(entry *logrus.Entry) error {
+	line := fmt.Sprintf("%s [%s] %s", entry.Time.Format("15:04:05"), entry.Level, entry.Message)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lines = append(t.lines, line)
+	if len(t.lines) > logTailSize {
+		t.lines = t.lines[len(t.lines)-logTailSize:]
+	}
+	return nil
+}
+
+// Lines returns a copy of the recorded lines, oldest first
+func (t *logTail) Lines() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.lines...)
+}
+
+// newStepLogger returns a logger that writes exactly like base and additionally records
+// its last lines, so that a step which times out can report what it was doing
+func newStepLogger(base *logrus.Logger) (*logrus.Logger, *logTail) {
+	tail := &logTail{}
+	if base == nil {
+		base = logrus.StandardLogger()
+	}
+
+	hooks := make(logrus.LevelHooks)
+	for level, levelHooks := range base.Hooks {
+		hooks[level] = append([]logrus.Hook(nil), levelHooks...)
+	}
+	hooks.Add(tail)
+
+	return &logrus.Logger{
+		Out:          base.Out,
+		Hooks:        hooks,
+		Formatter:    base.Formatter,
+		ReportCaller: base.ReportCaller,
+		Level:        base.GetLevel(),
+		ExitFunc:     base.ExitFunc,
+	}, tail
+}
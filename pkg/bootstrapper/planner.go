@@ -0,0 +1,76 @@
+package bootstrapper
+
+import (
+	"context"
+	"fmt"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+)
+
+// Planner is implemented by executors that can describe their changes without applying them
+type Planner interface {
+	// Plan returns the actions Execute would perform on this machine
+	Plan(ctx context.Context) ([]plan.Action, error)
+}
+
+// PlanGraph computes what ExecuteGraph would do for the given steps without executing any of them.
+// Steps are listed in the order they would run, honouring the checkpoint journal and resume options.
+func (be *BaseExecutor) PlanGraph(ctx context.Context, steps []Step, stepType string) (*plan.Result, error) {
+	nodes, err := buildStepNodes(steps)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s step graph: %w", stepType, err)
+	}
+
+	if uninstalling(stepType) {
+		nodes = reverseStepNodes(nodes)
+	}
+
+	order, err := topologicalOrder(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s step graph: %w", stepType, err)
+	}
+
+	resume, err := be.planResume(nodes, stepType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &plan.Result{Operation: stepType}
+	for _, index := range order {
+		step := nodes[index].step
+		stepPlan := plan.StepPlan{StepName: step.GetName()}
+		for _, dep := range nodes[index].deps {
+			stepPlan.DependsOn = append(stepPlan.DependsOn, nodes[dep].step.GetName())
+		}
+
+		switch reason, skipped := resume.skip[index]; {
+		case skipped:
+			stepPlan.SkipReason = reason
+		case !resume.force[index] && step.IsCompleted(ctx):
+			stepPlan.SkipReason = "already completed"
+		default:
+			be.planStep(ctx, step, &stepPlan)
+		}
+
+		result.Steps = append(result.Steps, stepPlan)
+	}
+
+	return result, nil
+}
+
+// planStep fills the step plan with the actions of a step that would be executed
+func (be *BaseExecutor) planStep(ctx context.Context, step Executor, stepPlan *plan.StepPlan) {
+	planner, ok := step.(Planner)
+	if !ok {
+		be.logger.Debugf("Step %s does not support planning", step.GetName())
+		return
+	}
+
+	stepPlan.Supported = true
+	actions, err := planner.Plan(ctx)
+	if err != nil {
+		stepPlan.Error = err.Error()
+		return
+	}
+	stepPlan.Actions = actions
+}
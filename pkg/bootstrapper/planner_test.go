@@ -0,0 +1,89 @@
+package bootstrapper
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+)
+
+// planningMockExecutor is a mock executor that implements Planner
+type planningMockExecutor struct {
+	graphMockExecutor
+	completed bool
+	actions   []plan.Action
+	planErr   error
+}
+
+func (m *planningMockExecutor) IsCompleted(ctx context.Context) bool {
+	return m.completed
+}
+
+func (m *planningMockExecutor) Plan(ctx context.Context) ([]plan.Action, error) {
+	return m.actions, m.planErr
+}
+
+// TestPlanGraph verifies that planning walks the graph without executing any step.
+// Test: Plans a graph with a planner, a completed step, an unsupported step and a failing planner
+// Expected: Steps are listed in order with actions, skip reasons, support flags and errors; nothing executes
+func TestPlanGraph(t *testing.T) {
+	executor := newJournalTestExecutor(t)
+	recorder := &executionRecorder{}
+	writeAction := plan.Command("echo", "hello")
+
+	steps := []Step{
+		{Executor: &planningMockExecutor{graphMockExecutor: graphMockExecutor{name: "a", recorder: recorder}, actions: []plan.Action{writeAction}}},
+		{Executor: &planningMockExecutor{graphMockExecutor: graphMockExecutor{name: "b", recorder: recorder}, completed: true}, DependsOn: []string{"a"}},
+		{Executor: &graphMockExecutor{name: "c", recorder: recorder}, DependsOn: []string{"a"}},
+		{Executor: &planningMockExecutor{graphMockExecutor: graphMockExecutor{name: "d", recorder: recorder}, planErr: errors.New("boom")}, DependsOn: []string{"b", "c"}},
+	}
+
+	result, err := executor.PlanGraph(context.Background(), steps, "bootstrap")
+	if err != nil {
+		t.Fatalf("PlanGraph failed: %v", err)
+	}
+	if len(recorder.started) != 0 {
+		t.Errorf("Expected no steps to execute, got %v", recorder.started)
+	}
+	if len(result.Steps) != 4 {
+		t.Fatalf("Expected 4 planned steps, got %d", len(result.Steps))
+	}
+
+	a, b, c, d := result.Steps[0], result.Steps[1], result.Steps[2], result.Steps[3]
+	if a.StepName != "a" || !a.Supported || len(a.Actions) != 1 || a.Actions[0].Target != "echo hello" {
+		t.Errorf("Unexpected plan for a: %+v", a)
+	}
+	if b.SkipReason != "already completed" || len(b.DependsOn) != 1 || b.DependsOn[0] != "a" {
+		t.Errorf("Unexpected plan for b: %+v", b)
+	}
+	if c.Supported {
+		t.Errorf("Expected c to be reported as not supporting planning: %+v", c)
+	}
+	if d.Error != "boom" {
+		t.Errorf("Expected planner error for d, got %+v", d)
+	}
+}
+
+// TestPlanGraph_UnbootstrapReversesOrder verifies that unbootstrap plans list steps in teardown order.
+// Test: Plans the chain a -> b as unbootstrap
+// Expected: b is planned before a and depends on nothing
+func TestPlanGraph_UnbootstrapReversesOrder(t *testing.T) {
+	executor := newJournalTestExecutor(t)
+	recorder := &executionRecorder{}
+	steps := []Step{
+		{Executor: &graphMockExecutor{name: "a", recorder: recorder}},
+		{Executor: &graphMockExecutor{name: "b", recorder: recorder}, DependsOn: []string{"a"}},
+	}
+
+	result, err := executor.PlanGraph(context.Background(), steps, "unbootstrap")
+	if err != nil {
+		t.Fatalf("PlanGraph failed: %v", err)
+	}
+	if result.Steps[0].StepName != "b" || result.Steps[1].StepName != "a" {
+		t.Errorf("Expected order [b a], got [%s %s]", result.Steps[0].StepName, result.Steps[1].StepName)
+	}
+	if len(result.Steps[0].DependsOn) != 0 || len(result.Steps[1].DependsOn) != 1 {
+		t.Errorf("Unexpected dependencies: %+v", result.Steps)
+	}
+}
@@ -0,0 +1,40 @@
+package bootstrapper
+
+import (
+	"context"
+	"time"
+)
+
+// rollbackEnabled reports whether a failed bootstrap should undo the steps it changed
+func (be *BaseExecutor) rollbackEnabled() bool {
+	return be.rollbackOnFailure || (be.config != nil && be.config.Agent.RollbackOnFailure)
+}
+
+// rollbackNodes runs the rollback executor of every changed step in reverse completion order.
+// Like unbootstrap it is best effort: a failing rollback step is recorded and the rest still run.
+func (be *BaseExecutor) rollbackNodes(ctx context.Context, nodes []stepNode, changed []int, journal *Journal) []StepResult {
+	var results []StepResult
+	for i := len(changed) - 1; i >= 0; i-- {
+		node := nodes[changed[i]]
+		if node.rollback == nil {
+			be.logger.Debugf("Step %s has no rollback, leaving its changes in place", node.step.GetName())
+			continue
+		}
+
+		be.logger.Infof("Rolling back step %s with %s", node.step.GetName(), node.rollback.GetName())
+		result := be.executeStep(ctx, node.rollback, "rollback", false, node.rollbackTimeout, node.logs)
+		if !result.Success {
+			be.logger.Warnf("Rollback step %s failed: %s (continuing with remaining steps)", result.StepName, result.Error)
+		}
+		results = append(results, result)
+
+		// A rolled back step must run again on the next bootstrap even if the journal would resume
+		if journal != nil {
+			journal.recordRollback(node.step.GetName(), time.Now())
+		}
+	}
+
+	be.logger.Infof("Rollback completed: %d of %d steps undone successfully",
+		be.countSuccessfulSteps(results), len(results))
+	return results
+}
@@ -0,0 +1,82 @@
+package bootstrapper
+
+import (
+	"context"
+	"testing"
+)
+
+// rollbackTestSteps builds the chain a -> b -> c where c fails and every step can be rolled back
+func rollbackTestSteps(recorder, rollbackRecorder *executionRecorder, completed string) []Step {
+	step := func(name string, deps ...string) Step {
+		return Step{
+			Executor:  &planningMockExecutor{graphMockExecutor: graphMockExecutor{name: name, recorder: recorder, shouldFail: name == "c"}, completed: name == completed},
+			DependsOn: deps,
+			Rollback:  &graphMockExecutor{name: "undo-" + name, recorder: rollbackRecorder},
+		}
+	}
+	return []Step{step("a"), step("b", "a"), step("c", "b")}
+}
+
+// TestRollback_UndoesChangedStepsInReverse verifies opt-in rollback of a failed bootstrap.
+// Test: Runs a -> b -> c with c failing, with and without rollback enabled, and with a already completed
+// Expected: Only steps executed by this run are rolled back, newest first, and results are reported separately
+func TestRollback_UndoesChangedStepsInReverse(t *testing.T) {
+	tests := []struct {
+		name         string
+		enabled      bool
+		completed    string
+		wantRollback []string
+	}{
+		{name: "disabled", enabled: false, wantRollback: nil},
+		{name: "enabled", enabled: true, wantRollback: []string{"undo-b", "undo-a"}},
+		{name: "skips steps this run did not change", enabled: true, completed: "a", wantRollback: []string{"undo-b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := newJournalTestExecutor(t)
+			executor.SetRollbackOnFailure(tt.enabled)
+
+			rollbackRecorder := &executionRecorder{}
+			result, err := executor.ExecuteGraph(context.Background(), rollbackTestSteps(&executionRecorder{}, rollbackRecorder, tt.completed), "bootstrap")
+			if err == nil {
+				t.Fatal("Expected bootstrap to fail")
+			}
+
+			if len(rollbackRecorder.started) != len(tt.wantRollback) {
+				t.Fatalf("Expected rollback %v, got %v", tt.wantRollback, rollbackRecorder.started)
+			}
+			for i, name := range tt.wantRollback {
+				if rollbackRecorder.started[i] != name {
+					t.Errorf("rollback[%d] = %s, want %s", i, rollbackRecorder.started[i], name)
+				}
+			}
+			if len(result.RollbackResults) != len(tt.wantRollback) {
+				t.Errorf("Expected %d rollback results, got %d", len(tt.wantRollback), len(result.RollbackResults))
+			}
+			if len(result.StepResults) != 3 {
+				t.Errorf("Expected forward results for all 3 steps, got %d", len(result.StepResults))
+			}
+		})
+	}
+}
+
+// TestRollback_RolledBackStepsAreNotResumed verifies that the journal forgets rolled back steps.
+// Test: Fails a -> b -> c with rollback enabled, then re-runs with the same config
+// Expected: The second run executes a and b again instead of resuming past them
+func TestRollback_RolledBackStepsAreNotResumed(t *testing.T) {
+	executor := newJournalTestExecutor(t)
+	executor.SetRollbackOnFailure(true)
+
+	if _, err := executor.ExecuteGraph(context.Background(), rollbackTestSteps(&executionRecorder{}, &executionRecorder{}, ""), "bootstrap"); err == nil {
+		t.Fatal("Expected first run to fail")
+	}
+
+	recorder := &executionRecorder{}
+	if _, err := executor.ExecuteGraph(context.Background(), journalTestSteps(recorder, ""), "bootstrap"); err != nil {
+		t.Fatalf("Expected second run to succeed, got: %v", err)
+	}
+	if len(recorder.started) != 3 {
+		t.Errorf("Expected all steps to run again, got %v", recorder.started)
+	}
+}
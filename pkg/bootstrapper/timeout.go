@@ -0,0 +1,61 @@
+package bootstrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+)
+
+const (
+	// defaultStepTimeout applies to steps that declare no timeout of their own
+	defaultStepTimeout = 15 * time.Minute
+
+	// stepAbandonGrace is how long a timed out step may take to return before it is abandoned
+	stepAbandonGrace = 5 * time.Second
+)
+
+// timedOutStepResult reports a step that hit its own timeout or the overall deadline carried by ctx
+func (be *BaseExecutor) timedOutStepResult(ctx context.Context, stepName, stepType string, startTime time.Time,
+	timeout time.Duration, logs *logTail) StepResult {
+	var message string
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		message = fmt.Sprintf("not completed: %v", ctx.Err())
+	case ctx.Err() != nil:
+		message = fmt.Sprintf("timed out after %s: overall %s deadline exceeded", be.overallTimeout(), stepType)
+	default:
+		message = fmt.Sprintf("timed out after %s", timeout)
+	}
+
+	be.logger.Errorf("%s step: %s %s", stepType, stepName, message)
+	result := be.createStepResult(stepName, startTime, false, message)
+	result.ErrorClass = retry.ClassTransient
+	result.TimedOut = !errors.Is(ctx.Err(), context.Canceled)
+	result.LogTail = logs.Lines()
+	return result
+}
+
+// stepTimeout resolves the timeout of the named step: the agent.stepTimeouts entry if present,
+// otherwise the step's own default, otherwise defaultStepTimeout
+func (be *BaseExecutor) stepTimeout(stepName string, builtin time.Duration) time.Duration {
+	if be.config != nil {
+		if timeout, ok := be.config.Agent.StepTimeout(stepName); ok {
+			return timeout
+		}
+	}
+	if builtin > 0 {
+		return builtin
+	}
+	return defaultStepTimeout
+}
+
+// overallTimeout returns the deadline of a complete run, zero when disabled
+func (be *BaseExecutor) overallTimeout() time.Duration {
+	if be.config == nil || be.config.Agent.OverallTimeout < 0 {
+		return 0
+	}
+	return be.config.Agent.OverallTimeout
+}
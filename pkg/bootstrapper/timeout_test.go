@@ -0,0 +1,137 @@
+package bootstrapper
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// hangingMockExecutor logs a line and then blocks until its context is done
+type hangingMockExecutor struct {
+	name   string
+	logger *logrus.Logger
+}
+
+func (m *hangingMockExecutor) Execute(ctx context.Context) error {
+	m.logger.Infof("waiting for %s to become ready", m.name)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (m *hangingMockExecutor) IsCompleted(ctx context.Context) bool {
+	return false
+}
+
+func (m *hangingMockExecutor) GetName() string {
+	return m.name
+}
+
+// newTimeoutTestExecutor creates a BaseExecutor with the given agent timeouts and no journal
+func newTimeoutTestExecutor(agent config.AgentConfig) *BaseExecutor {
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	return NewBaseExecutor(&config.Config{Agent: agent}, logger)
+}
+
+// hangingStep returns a step that hangs until cancelled, logging through its own step logger
+func hangingStep(name string, timeout time.Duration) Step {
+	base := logrus.New()
+	base.SetLevel(logrus.InfoLevel)
+	base.SetOutput(&strings.Builder{})
+	stepLogger, logs := newStepLogger(base)
+	return Step{Executor: &hangingMockExecutor{name: name, logger: stepLogger}, Timeout: timeout, logs: logs}
+}
+
+// TestExecuteGraph_StepTimeout verifies per-step timeouts and their configuration overrides.
+// Test: Runs a hanging step with a built-in timeout, with and without an agent.stepTimeouts override
+// Expected: The step fails as timed out after the effective timeout and reports its last log lines
+func TestExecuteGraph_StepTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		stepTimeout time.Duration
+		overrides   map[string]time.Duration
+		wantError   string
+	}{
+		{
+			name:        "built-in step timeout",
+			stepTimeout: 50 * time.Millisecond,
+			wantError:   "timed out after 50ms",
+		},
+		{
+			name:        "override matched case-insensitively",
+			stepTimeout: time.Hour,
+			overrides:   map[string]time.Duration{"hangingstep": 20 * time.Millisecond},
+			wantError:   "timed out after 20ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := newTimeoutTestExecutor(config.AgentConfig{StepTimeouts: tt.overrides})
+
+			result, err := executor.ExecuteGraph(context.Background(), []Step{hangingStep("HangingStep", tt.stepTimeout)}, "bootstrap")
+			if err == nil {
+				t.Fatal("Expected timed out step to fail bootstrap")
+			}
+
+			step := result.StepResults[0]
+			if !step.TimedOut {
+				t.Error("Expected step to be marked as timed out")
+			}
+			if !strings.HasPrefix(step.Error, tt.wantError) {
+				t.Errorf("Expected error to start with %q, got %q", tt.wantError, step.Error)
+			}
+			if len(step.LogTail) != 1 || !strings.Contains(step.LogTail[0], "waiting for HangingStep") {
+				t.Errorf("Expected log tail with the step's last line, got %v", step.LogTail)
+			}
+		})
+	}
+}
+
+// TestExecuteGraph_OverallTimeout verifies the overall deadline of a run.
+// Test: Runs a hanging step followed by a dependent step with a short overall timeout
+// Expected: The hanging step fails with the overall deadline message and the dependent step never runs
+func TestExecuteGraph_OverallTimeout(t *testing.T) {
+	executor := newTimeoutTestExecutor(config.AgentConfig{OverallTimeout: 50 * time.Millisecond})
+	recorder := &executionRecorder{}
+	steps := []Step{
+		hangingStep("first", time.Hour),
+		{Executor: &graphMockExecutor{name: "second", recorder: recorder}, DependsOn: []string{"first"}},
+	}
+
+	result, err := executor.ExecuteGraph(context.Background(), steps, "bootstrap")
+	if err == nil {
+		t.Fatal("Expected bootstrap to fail once the overall deadline passes")
+	}
+	if !strings.Contains(result.Error, "overall bootstrap deadline exceeded") {
+		t.Errorf("Expected overall deadline error, got %q", result.Error)
+	}
+	if len(recorder.started) != 0 {
+		t.Errorf("Expected dependent step not to run, got %v", recorder.started)
+	}
+}
+
+// TestLogTail verifies that the step log tail keeps only the most recent lines.
+// Test: Logs more lines than the tail holds through a step logger
+// Expected: The tail holds the last logTailSize lines, oldest first
+func TestLogTail(t *testing.T) {
+	base := logrus.New()
+	base.SetOutput(&strings.Builder{})
+	stepLogger, logs := newStepLogger(base)
+
+	for i := 0; i < logTailSize+5; i++ {
+		stepLogger.Infof("line %d", i)
+	}
+
+	lines := logs.Lines()
+	if len(lines) != logTailSize {
+		t.Fatalf("Expected %d lines, got %d", logTailSize, len(lines))
+	}
+	if !strings.HasSuffix(lines[0], "line 5") || !strings.HasSuffix(lines[len(lines)-1], "line 24") {
+		t.Errorf("Expected lines 5..24, got first %q last %q", lines[0], lines[len(lines)-1])
+	}
+}
@@ -3,6 +3,7 @@ package arc
 import (
 	"context"
 	"fmt"
+	"os/exec"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v3"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v5"
@@ -178,3 +179,17 @@ func (ab *base) ensureAuthentication(ctx context.Context) error {
 	ab.logger.Info("✅ Azure CLI authentication verified")
 	return nil
 }
+
+// disconnectArcMachine disconnects the machine using azcmagent
+func (ab *base) disconnectArcMachine(ctx context.Context) error {
+	ab.logger.Info("Disconnecting Arc machine")
+
+	cmd := exec.CommandContext(ctx, "sudo", "azcmagent", "disconnect", "--force-local-only")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to disconnect Arc machine: %w, output: %s", err, string(output))
+	}
+
+	ab.logger.Infof("Arc machine disconnected: %s", string(output))
+	return nil
+}
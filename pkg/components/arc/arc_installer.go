@@ -2,8 +2,10 @@ package arc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
@@ -13,6 +15,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -33,13 +37,13 @@ func (i *Installer) Validate(ctx context.Context) error {
 	// Ensure SP or CLI auth is ready for Arc agent setup
 	if err := i.ensureAuthentication(ctx); err != nil {
 		i.logger.Errorf("Authentication setup failed: %v", err)
-		return fmt.Errorf("arc bootstrap setup failed at authentication: %w", err)
+		return retry.NeedsUserAction(fmt.Errorf("arc bootstrap setup failed at authentication: %w", err))
 	}
 	// Ensure Arc agent is installed and running
 	if !isArcAgentInstalled() {
 		i.logger.Info("Azure Arc agent not found")
-		return fmt.Errorf("azure Arc agent not found - please run the installation script first:\n" +
-			"curl -fsSL https://raw.githubusercontent.com/Azure/AKSFlexNode/main/scripts/install.sh | bash")
+		return retry.NeedsUserAction(fmt.Errorf("azure Arc agent not found - please run the installation script first:\n" +
+			"curl -fsSL https://raw.githubusercontent.com/Azure/AKSFlexNode/main/scripts/install.sh | bash"))
 	}
 	return nil
 }
@@ -49,6 +53,19 @@ func (i *Installer) GetName() string {
 	return "ArcInstall"
 }
 
+// RetryPolicy retries the step when ARM throttles or fails a request. ARM throttling windows
+// last longer than a download hiccup, so the step waits longer and tries more often than the
+// default; registration and role assignment are skipped when a previous attempt completed them.
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.Policy{
+		MaxAttempts:    5,
+		InitialBackoff: 15 * time.Second,
+		MaxBackoff:     2 * time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
 // Execute performs Arc setup as part of the bootstrap process
 // This method is designed to be called from bootstrap steps and handles all Arc-related setup
 // It stops on the first error to prevent partial setups
@@ -277,7 +294,8 @@ func (i *Installer) assignRBACRoles(ctx context.Context, arcMachine *armhybridco
 		for _, err := range assignmentErrors {
 			i.logger.Errorf("   - %v", err)
 		}
-		return fmt.Errorf("failed to assign %d out of %d RBAC roles", len(assignmentErrors), len(requiredRoles))
+		// Join the failures so that a throttled or forbidden assignment keeps its class
+		return fmt.Errorf("failed to assign %d out of %d RBAC roles: %w", len(assignmentErrors), len(requiredRoles), errors.Join(assignmentErrors...))
 	}
 
 	// wait for permissions to propagate
@@ -370,7 +388,7 @@ func (i *Installer) assignRole(
 			i.logger.Errorf("   Scope: %s", scope)
 			i.logger.Errorf("   Assignment Name: %s", roleAssignmentName)
 			i.logger.Errorf("   Azure API Error: %v", err)
-			return fmt.Errorf("failed to create role assignment: %s", err)
+			return fmt.Errorf("failed to create role assignment: %w", err)
 		}
 
 		// Success
@@ -442,10 +460,39 @@ func (i *Installer) runAzcmagentSecurely(name string, args []string) error {
 	i.logger.Infof("Executing command: %s %v", name, maskedArgs)
 
 	// Execute the actual command with real args but capture output to avoid logging
-	_, err := utils.RunCommandWithOutput(name, args...)
+	output, err := utils.RunCommandWithOutput(name, args...)
 	if err != nil {
-		return err
+		return classifyAzcmagentError(output, err)
 	}
 
 	return nil
 }
+
+// armTransientPattern matches the throttling and server error responses of ARM that azcmagent
+// reports in its output
+var armTransientPattern = regexp.MustCompile(`(?i)\b(TooManyRequests|InternalServerError|BadGateway|ServiceUnavailable|GatewayTimeout)\b|(status ?code|RESPONSE)[\s:=]*(429|5\d\d)\b`)
+
+// classifyAzcmagentError marks a failed azcmagent run as transient when its output shows that ARM
+// throttled or failed the request. The output is not part of the error as it may echo the command.
+func classifyAzcmagentError(output string, err error) error {
+	if match := armTransientPattern.FindString(output); match != "" {
+		return retry.Transient(fmt.Errorf("%w (ARM responded with %s)", err, match))
+	}
+	return err
+}
+
+// Plan describes the changes Execute would make without applying them.
+// No Azure calls are made; the actions reflect the configured Arc machine and target cluster.
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	machineName := i.config.GetArcMachineName()
+	actions := []plan.Action{
+		plan.AzureResource("Microsoft.HybridCompute/machines", machineName, plan.OpCreate,
+			fmt.Sprintf("azcmagent connect in resource group %s, location %s; skipped if already registered",
+				i.config.GetArcResourceGroup(), i.config.GetArcLocation())),
+	}
+	principal := fmt.Sprintf("managed identity of Arc machine %s", machineName)
+	for _, role := range i.getRoleAssignments() {
+		actions = append(actions, plan.RoleAssignment(role.roleName, role.scope, principal, plan.OpCreate))
+	}
+	return actions, nil
+}
@@ -4,14 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v3"
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 )
 
 // mockRoleAssignmentsClient is a mock implementation for testing
@@ -451,3 +454,84 @@ func TestAssignRole_ExponentialBackoff(t *testing.T) {
 		}
 	}
 }
+
+// TestRetryPolicy verifies that the Arc step waits out ARM throttling longer than other steps.
+// Test: Compares the Arc retry policy with the default policy
+// Expected: The Arc policy allows more attempts and backs off longer
+func TestRetryPolicy(t *testing.T) {
+	policy := (&Installer{}).RetryPolicy()
+	defaults := retry.DefaultPolicy()
+	if policy.Attempts() <= defaults.Attempts() {
+		t.Errorf("Expected more than %d attempts, got %d", defaults.Attempts(), policy.Attempts())
+	}
+	if policy.InitialBackoff <= defaults.InitialBackoff || policy.MaxBackoff <= defaults.MaxBackoff {
+		t.Errorf("Expected longer backoff than %+v, got %+v", defaults, policy)
+	}
+}
+
+// TestArcErrorClassification verifies that ARM throttling and server errors are retried.
+// Test: Classifies failed azcmagent runs by their output and role assignments failing with ARM responses
+// Expected: 429 and 5xx responses are transient, other failures keep their class through the step's error wrapping
+func TestArcErrorClassification(t *testing.T) {
+	exitErr := errors.New("exit status 1")
+	tests := []struct {
+		name   string
+		output string
+		want   retry.Class
+	}{
+		{name: "throttled", output: "Error: Status Code = 429, Code = TooManyRequests", want: retry.ClassTransient},
+		{name: "server error code", output: "RESPONSE 503: 503 Service Unavailable", want: retry.ClassTransient},
+		{name: "server error name", output: "ERROR CODE: InternalServerError", want: retry.ClassTransient},
+		{name: "bad gateway", output: "StatusCode=502 BadGateway", want: retry.ClassTransient},
+		{name: "bad request", output: "RESPONSE 400: 400 Bad Request\nERROR CODE: InvalidParameter", want: retry.ClassUnclassified},
+		{name: "no output", output: "", want: retry.ClassUnclassified},
+	}
+	for _, tt := range tests {
+		t.Run("azcmagent "+tt.name, func(t *testing.T) {
+			err := classifyAzcmagentError(tt.output, exitErr)
+			if !errors.Is(err, exitErr) {
+				t.Errorf("Expected the command error to be wrapped, got %v", err)
+			}
+			if got := retry.Classify(fmt.Errorf("arc bootstrap setup failed at machine registration: %w", err)); got != tt.want {
+				t.Errorf("Expected class %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.PanicLevel)
+	for _, tt := range []struct {
+		statusCode int
+		want       retry.Class
+	}{
+		{statusCode: http.StatusTooManyRequests, want: retry.ClassTransient},
+		{statusCode: http.StatusInternalServerError, want: retry.ClassTransient},
+		{statusCode: http.StatusGatewayTimeout, want: retry.ClassTransient},
+		{statusCode: http.StatusBadRequest, want: retry.ClassPermanent},
+	} {
+		t.Run(fmt.Sprintf("role assignment %d", tt.statusCode), func(t *testing.T) {
+			installer := &Installer{
+				base: &base{
+					config: &config.Config{Azure: config.AzureConfig{SubscriptionID: "test-sub-id"}},
+					logger: logger,
+					roleAssignmentsClient: &mockRoleAssignmentsClient{
+						createFunc: func(ctx context.Context, scope string, roleAssignmentName string, parameters armauthorization.RoleAssignmentCreateParameters, options *armauthorization.RoleAssignmentsClientCreateOptions) (armauthorization.RoleAssignmentsClientCreateResponse, error) {
+							return armauthorization.RoleAssignmentsClientCreateResponse{}, &azcore.ResponseError{StatusCode: tt.statusCode}
+						},
+					},
+				},
+			}
+
+			err := installer.assignRole(context.Background(), "test-principal-id", "test-role-id", "/test/scope", "TestRole")
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			// Mirror how assignRBACRoles and Execute wrap a failed assignment
+			err = fmt.Errorf("arc bootstrap setup failed at RBAC role assignment: %w",
+				fmt.Errorf("failed to assign 1 out of 2 RBAC roles: %w", errors.Join(fmt.Errorf("role 'TestRole': %w", err))))
+			if got := retry.Classify(err); got != tt.want {
+				t.Errorf("Expected class %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package arc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+)
+
+// AzureResource is an Azure-side resource created by bootstrap that a local reset leaves in place
+type AzureResource struct {
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	Scope          string `json:"scope"`
+	CleanupCommand string `json:"cleanupCommand"`
+}
+
+// LocalUnInstaller disconnects the Arc agent on this machine only. Unlike UnInstaller it never
+// authenticates or calls Azure, so it works on nodes without connectivity or subscription access.
+type LocalUnInstaller struct {
+	*base
+}
+
+// NewLocalUnInstaller creates a new local-only Arc UnInstaller
+func NewLocalUnInstaller(logger *logrus.Logger) *LocalUnInstaller {
+	return &LocalUnInstaller{
+		base: newBase(logger),
+	}
+}
+
+// GetName returns the cleanup step name
+func (u *LocalUnInstaller) GetName() string {
+	return "ArcLocalReset"
+}
+
+// IsCompleted checks if the local Arc cleanup has been completed
+// always returns false to ensure cleanup is attempted
+func (u *LocalUnInstaller) IsCompleted(ctx context.Context) bool {
+	return false
+}
+
+// Execute removes the Arc agent state from this machine with azcmagent disconnect --force-local-only.
+// Failures are logged and do not fail the reset, as the remaining local cleanup is still useful.
+func (u *LocalUnInstaller) Execute(ctx context.Context) error {
+	if !isArcAgentInstalled() {
+		u.logger.Info("Arc agent is not installed, nothing to disconnect")
+		return nil
+	}
+
+	if err := u.disconnectArcMachine(ctx); err != nil {
+		u.logger.Warnf("Failed to disconnect Arc machine locally (continuing reset): %v", err)
+		return nil
+	}
+
+	u.logger.Info("Arc agent state removed from this machine; the Azure resources still need manual cleanup")
+	return nil
+}
+
+// Plan describes the changes Execute would make without applying them
+func (u *LocalUnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	if !isArcAgentInstalled() {
+		return nil, nil
+	}
+	return []plan.Action{plan.Command("azcmagent", "disconnect", "--force-local-only")}, nil
+}
+
+// RemainingResources lists the Azure resources bootstrap created for this machine, together with
+// the Azure CLI commands that remove them. Role assignments come first, since the principal they
+// are assigned to is looked up from the Arc machine.
+func (u *LocalUnInstaller) RemainingResources() []AzureResource {
+	machineName := u.config.GetArcMachineName()
+	resourceGroup := u.config.GetArcResourceGroup()
+	principal := fmt.Sprintf("$(az connectedmachine show --resource-group %s --name %s --query identity.principalId --output tsv)",
+		resourceGroup, machineName)
+
+	roles := u.getRoleAssignments()
+	resources := make([]AzureResource, 0, len(roles)+1)
+	for _, role := range roles {
+		resources = append(resources, AzureResource{
+			Type:  "Microsoft.Authorization/roleAssignments",
+			Name:  role.roleName,
+			Scope: role.scope,
+			CleanupCommand: fmt.Sprintf("az role assignment delete --assignee %s --role %s --scope %s",
+				principal, role.roleID, role.scope),
+		})
+	}
+	return append(resources, AzureResource{
+		Type:           "Microsoft.HybridCompute/machines",
+		Name:           machineName,
+		Scope:          fmt.Sprintf("/subscriptions/%s/resourceGroups/%s", u.config.GetSubscriptionID(), resourceGroup),
+		CleanupCommand: fmt.Sprintf("az connectedmachine delete --resource-group %s --name %s --yes", resourceGroup, machineName),
+	})
+}
@@ -0,0 +1,49 @@
+package arc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// TestRemainingResources verifies the Azure resources a local reset reports for manual cleanup.
+// Test: Lists the resources for a config with an Arc machine and a target cluster
+// Expected: One role assignment per assigned role on the cluster, followed by the Arc machine, each with a cleanup command
+func TestRemainingResources(t *testing.T) {
+	clusterID := "/subscriptions/sub-id/resourceGroups/aks-rg/providers/Microsoft.ContainerService/managedClusters/aks"
+	cfg := &config.Config{
+		Azure: config.AzureConfig{
+			SubscriptionID: "sub-id",
+			Arc:            &config.ArcConfig{MachineName: "edge-node-1", ResourceGroup: "arc-rg"},
+			TargetCluster:  &config.TargetClusterConfig{ResourceID: clusterID},
+		},
+	}
+	u := &LocalUnInstaller{base: &base{config: cfg, logger: logrus.New()}}
+
+	resources := u.RemainingResources()
+	if len(resources) != 4 {
+		t.Fatalf("Expected 3 role assignments and the Arc machine, got %d resources", len(resources))
+	}
+
+	for _, resource := range resources[:3] {
+		if resource.Type != "Microsoft.Authorization/roleAssignments" || resource.Scope != clusterID {
+			t.Errorf("Expected a role assignment on the cluster, got %+v", resource)
+		}
+		if !strings.Contains(resource.CleanupCommand, "az role assignment delete") ||
+			!strings.Contains(resource.CleanupCommand, "--resource-group arc-rg --name edge-node-1") {
+			t.Errorf("Unexpected cleanup command %q", resource.CleanupCommand)
+		}
+	}
+
+	machine := resources[3]
+	if machine.Type != "Microsoft.HybridCompute/machines" || machine.Name != "edge-node-1" ||
+		machine.Scope != "/subscriptions/sub-id/resourceGroups/arc-rg" {
+		t.Errorf("Unexpected Arc machine resource %+v", machine)
+	}
+	if machine.CleanupCommand != "az connectedmachine delete --resource-group arc-rg --name edge-node-1 --yes" {
+		t.Errorf("Unexpected cleanup command %q", machine.CleanupCommand)
+	}
+}
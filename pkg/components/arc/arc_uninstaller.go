@@ -3,12 +3,13 @@ package arc
 import (
 	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/authorization/armauthorization/v3"
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/hybridcompute/armhybridcompute"
 	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 )
 
 // UnInstaller handles Azure Arc cleanup operations
@@ -144,20 +145,6 @@ func (u *UnInstaller) removeRBACRoles(ctx context.Context, arcMachine *armhybrid
 	return nil
 }
 
-// disconnectArcMachine disconnects the machine using azcmagent
-func (u *UnInstaller) disconnectArcMachine(ctx context.Context) error {
-	u.logger.Info("Disconnecting Arc machine")
-
-	cmd := exec.CommandContext(ctx, "sudo", "azcmagent", "disconnect", "--force-local-only")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to disconnect Arc machine: %w, output: %s", err, string(output))
-	}
-
-	u.logger.Infof("Arc machine disconnected: %s", string(output))
-	return nil
-}
-
 // removeRoleAssignment removes role assignment for a specific principal, role, and scope
 func (u *UnInstaller) removeRoleAssignment(ctx context.Context, principalID, roleDefinitionID, scope, roleName string) error {
 	// Build the full role definition ID
@@ -210,3 +197,21 @@ func (u *UnInstaller) removeRoleAssignment(ctx context.Context, principalID, rol
 
 	return nil
 }
+
+// Plan describes the changes Execute would make without applying them.
+// No Azure calls are made; the actions reflect the configured Arc machine and target cluster.
+func (u *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	machineName := u.config.GetArcMachineName()
+	principal := fmt.Sprintf("managed identity of Arc machine %s", machineName)
+	roles := u.getRoleAssignments()
+
+	actions := make([]plan.Action, 0, len(roles)+2)
+	for _, role := range roles {
+		actions = append(actions, plan.RoleAssignment(role.roleName, role.scope, principal, plan.OpDelete))
+	}
+	return append(actions,
+		plan.AzureResource("Microsoft.HybridCompute/machines", machineName, plan.OpDelete,
+			fmt.Sprintf("resource group %s", u.config.GetArcResourceGroup())),
+		plan.Command("azcmagent", "disconnect", "--force-local-only"),
+	), nil
+}
@@ -0,0 +1,148 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+)
+
+// fingerprint identifies the artifact definition, so that any change to it triggers a reinstall
+func fingerprint(artifact config.ArtifactConfig) string {
+	data, err := json.Marshal(artifact)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%+v", artifact))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// stampPath returns the file recording the fingerprint of the installed artifact
+func stampPath(cfg *config.Config, name string) string {
+	stateDir := ""
+	if cfg != nil {
+		stateDir = cfg.Agent.StateDir
+	}
+	return filepath.Join(stateDir, stampDirName, name+".sha256")
+}
+
+// unitPath returns the install path of the artifact's systemd unit
+func unitPath(unit *config.ArtifactUnitConfig) string {
+	return filepath.Join(systemdUnitDir, unit.Name)
+}
+
+// fileSHA256 returns the hex SHA-256 digest of a file
+func fileSHA256(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// verifyChecksum compares the digest of a file with the expected hex digest.
+// A mismatch is permanent: retrying the same source yields the same content.
+func verifyChecksum(filePath, expected string) error {
+	actual, err := fileSHA256(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to compute checksum of %s: %w", filePath, err)
+	}
+	if !strings.EqualFold(actual, expected) {
+		return retry.Permanent(fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", strings.ToLower(expected), actual))
+	}
+	return nil
+}
+
+// copyFile copies a local source file to destination
+func copyFile(source, destination string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", source, err)
+	}
+	defer func() {
+		_ = in.Close()
+	}()
+
+	out, err := os.Create(destination)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", destination, err)
+	}
+	defer func() {
+		_ = out.Close()
+	}()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", source, destination, err)
+	}
+	return out.Close()
+}
+
+// extractMember extracts a single regular file from a tar or gzip compressed tar archive
+func extractMember(archivePath, member, destination string) error {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open archive %s: %w", archivePath, err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var reader io.Reader = bufio.NewReader(file)
+	if magic, err := reader.(*bufio.Reader).Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("failed to open gzip archive %s: %w", archivePath, err)
+		}
+		defer func() {
+			_ = gzipReader.Close()
+		}()
+		reader = gzipReader
+	}
+
+	want := path.Clean(strings.TrimPrefix(member, "./"))
+	tarReader := tar.NewReader(reader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return retry.Permanent(fmt.Errorf("archive member %s not found in %s", member, archivePath))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive %s: %w", archivePath, err)
+		}
+		if header.Typeflag != tar.TypeReg || path.Clean(strings.TrimPrefix(header.Name, "./")) != want {
+			continue
+		}
+
+		out, err := os.Create(destination)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", destination, err)
+		}
+		defer func() {
+			_ = out.Close()
+		}()
+		if _, err := io.Copy(out, tarReader); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", member, err)
+		}
+		return out.Close()
+	}
+}
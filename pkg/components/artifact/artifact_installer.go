@@ -0,0 +1,241 @@
+package artifact
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Installer installs a single config-defined artifact and its optional systemd unit
+type Installer struct {
+	config   *config.Config
+	artifact config.ArtifactConfig
+	logger   *logrus.Logger
+}
+
+// NewInstaller creates a new Installer for the given artifact
+func NewInstaller(logger *logrus.Logger, artifact config.ArtifactConfig) *Installer {
+	return &Installer{
+		config:   config.GetConfig(),
+		artifact: artifact,
+		logger:   logger,
+	}
+}
+
+// GetName returns the step name
+func (i *Installer) GetName() string {
+	return "ArtifactInstaller_" + i.artifact.Name
+}
+
+// RetryPolicy retries artifacts fetched from a URL with the default policy; copying a local file
+// fails the same way every time, so it runs once
+func (i *Installer) RetryPolicy() retry.Policy {
+	if i.artifact.URL == "" {
+		return retry.NoRetry
+	}
+	return retry.DefaultPolicy()
+}
+
+// Execute fetches, verifies and installs the artifact, then installs and starts its unit if any
+func (i *Installer) Execute(ctx context.Context) error {
+	i.logger.Infof("Installing artifact %s to %s", i.artifact.Name, i.artifact.Target)
+
+	tempDir, err := os.MkdirTemp("", "aks-flex-node-artifact-"+i.artifact.Name+"-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory for artifact %s: %w", i.artifact.Name, err)
+	}
+	defer func() {
+		if err := os.RemoveAll(tempDir); err != nil {
+			i.logger.Warnf("Failed to clean up temp directory %s: %v", tempDir, err)
+		}
+	}()
+
+	file, err := i.fetch(tempDir)
+	if err != nil {
+		return err
+	}
+
+	if err := i.install(file); err != nil {
+		return err
+	}
+
+	if i.artifact.Unit != nil {
+		if err := i.installUnit(); err != nil {
+			return err
+		}
+	}
+
+	// Record what was installed so that a changed definition is detected and reinstalled
+	if err := i.writeStamp(); err != nil {
+		return err
+	}
+
+	i.logger.Infof("Artifact %s installed successfully", i.artifact.Name)
+	return nil
+}
+
+// fetch downloads or copies the artifact source into tempDir, verifies its checksum and extracts
+// the archive member if one is configured, returning the file to install
+func (i *Installer) fetch(tempDir string) (string, error) {
+	source := filepath.Join(tempDir, "source")
+	if i.artifact.URL != "" {
+		i.logger.Infof("Downloading artifact %s from %s", i.artifact.Name, i.artifact.URL)
+		if err := utils.DownloadFile(i.artifact.URL, source); err != nil {
+			return "", fmt.Errorf("failed to download artifact %s from %s: %w", i.artifact.Name, i.artifact.URL, err)
+		}
+	} else {
+		i.logger.Infof("Copying artifact %s from %s", i.artifact.Name, i.artifact.Path)
+		if err := copyFile(i.artifact.Path, source); err != nil {
+			return "", fmt.Errorf("failed to copy artifact %s: %w", i.artifact.Name, err)
+		}
+	}
+
+	if i.artifact.SHA256 != "" {
+		if err := verifyChecksum(source, i.artifact.SHA256); err != nil {
+			return "", fmt.Errorf("artifact %s failed verification: %w", i.artifact.Name, err)
+		}
+		i.logger.Debugf("Artifact %s checksum verified", i.artifact.Name)
+	}
+
+	if i.artifact.ArchiveMember == "" {
+		return source, nil
+	}
+
+	extracted := filepath.Join(tempDir, "member")
+	i.logger.Infof("Extracting %s from artifact %s", i.artifact.ArchiveMember, i.artifact.Name)
+	if err := extractMember(source, i.artifact.ArchiveMember, extracted); err != nil {
+		return "", fmt.Errorf("failed to extract artifact %s: %w", i.artifact.Name, err)
+	}
+	return extracted, nil
+}
+
+// install places the file at the target path with the configured mode and owner
+func (i *Installer) install(file string) error {
+	args := i.installArgs(file)
+	if err := utils.RunSystemCommand("install", args...); err != nil {
+		return fmt.Errorf("failed to install artifact %s to %s: %w", i.artifact.Name, i.artifact.Target, err)
+	}
+	return nil
+}
+
+// installArgs returns the arguments of the install command placing file at the target path
+func (i *Installer) installArgs(file string) []string {
+	args := []string{"-D", "-m", i.mode()}
+	if i.artifact.Owner != "" {
+		user, group, hasGroup := strings.Cut(i.artifact.Owner, ":")
+		args = append(args, "-o", user)
+		if hasGroup {
+			args = append(args, "-g", group)
+		}
+	}
+	return append(args, file, i.artifact.Target)
+}
+
+// installUnit writes the artifact's systemd unit and enables and starts it
+func (i *Installer) installUnit() error {
+	unit := i.artifact.Unit
+	if err := utils.WriteFileAtomicSystem(unitPath(unit), []byte(unit.Content), 0644); err != nil {
+		return fmt.Errorf("failed to write unit %s for artifact %s: %w", unit.Name, i.artifact.Name, err)
+	}
+	if err := utils.ReloadSystemd(); err != nil {
+		return fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	// Restart rather than start so an updated binary or unit takes effect
+	if err := utils.RunSystemCommand("systemctl", "enable", unit.Name); err != nil {
+		return fmt.Errorf("failed to enable unit %s: %w", unit.Name, err)
+	}
+	if err := utils.RestartService(unit.Name); err != nil {
+		return fmt.Errorf("failed to start unit %s: %w", unit.Name, err)
+	}
+	i.logger.Infof("Installed and started unit %s for artifact %s", unit.Name, i.artifact.Name)
+	return nil
+}
+
+// writeStamp records the fingerprint of the installed artifact definition
+func (i *Installer) writeStamp() error {
+	stamp := stampPath(i.config, i.artifact.Name)
+	if err := os.MkdirAll(filepath.Dir(stamp), 0750); err != nil {
+		return fmt.Errorf("failed to create artifact state directory: %w", err)
+	}
+	if err := utils.WriteFileAtomic(stamp, []byte(fingerprint(i.artifact)), 0640); err != nil {
+		return fmt.Errorf("failed to record artifact %s as installed: %w", i.artifact.Name, err)
+	}
+	return nil
+}
+
+// mode returns the configured file mode
+func (i *Installer) mode() string {
+	if i.artifact.Mode == "" {
+		return "0644"
+	}
+	return i.artifact.Mode
+}
+
+// IsCompleted checks that the target exists and was installed from the current artifact definition
+func (i *Installer) IsCompleted(ctx context.Context) bool {
+	if !utils.FileExists(i.artifact.Target) {
+		return false
+	}
+	if i.artifact.Unit != nil && !utils.FileExists(unitPath(i.artifact.Unit)) {
+		return false
+	}
+
+	stamp, err := os.ReadFile(stampPath(i.config, i.artifact.Name))
+	if err != nil {
+		i.logger.Debugf("Artifact %s has no install record: %v", i.artifact.Name, err)
+		return false
+	}
+	return strings.TrimSpace(string(stamp)) == fingerprint(i.artifact)
+}
+
+// Validate validates prerequisites before installing the artifact
+func (i *Installer) Validate(ctx context.Context) error {
+	if i.artifact.Path != "" && !utils.FileExists(i.artifact.Path) {
+		return retry.NeedsUserAction(fmt.Errorf("artifact %s source %s does not exist", i.artifact.Name, i.artifact.Path))
+	}
+	return nil
+}
+
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	details := "mode " + i.mode()
+	if i.artifact.Owner != "" {
+		details += ", owner " + i.artifact.Owner
+	}
+	if i.artifact.ArchiveMember != "" {
+		details += ", archive member " + i.artifact.ArchiveMember
+	}
+	if i.artifact.SHA256 != "" {
+		details += ", sha256 " + strings.ToLower(i.artifact.SHA256)
+	}
+
+	var actions []plan.Action
+	if i.artifact.URL != "" {
+		actions = append(actions, plan.Download(i.artifact.URL, i.artifact.Target).WithDetails(details))
+	} else {
+		actions = append(actions, plan.Action{
+			Kind:      plan.KindFile,
+			Operation: plan.OpInstall,
+			Target:    i.artifact.Target,
+			Source:    i.artifact.Path,
+			Details:   details,
+		})
+	}
+
+	if unit := i.artifact.Unit; unit != nil {
+		actions = append(actions,
+			plan.WriteFile(unitPath(unit), []byte(unit.Content), 0644),
+			plan.Unit(unit.Name, plan.OpEnable),
+			plan.Unit(unit.Name, plan.OpRestart),
+		)
+	}
+	return actions, nil
+}
@@ -0,0 +1,201 @@
+package artifact
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils/sudoerstest"
+)
+
+// writeTarGz creates a gzip compressed tar archive with the given files
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestInstaller returns an installer whose install record lives in a temporary state directory
+func newTestInstaller(t *testing.T, definition config.ArtifactConfig) *Installer {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	installer := NewInstaller(logger, definition)
+	installer.config = &config.Config{Agent: config.AgentConfig{StateDir: t.TempDir()}}
+	return installer
+}
+
+// TestExtractMember verifies extracting a single file from tar archives.
+// Test: Extracts existing members with and without a leading ./ and a missing member
+// Expected: Existing members are written with their content; a missing member fails permanently
+func TestExtractMember(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "plugin.tgz")
+	writeTarGz(t, archive, map[string]string{"./bin/plugin": "binary", "README": "docs"})
+
+	tests := []struct {
+		member  string
+		want    string
+		wantErr bool
+	}{
+		{member: "bin/plugin", want: "binary"},
+		{member: "./README", want: "docs"},
+		{member: "bin/missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.member, func(t *testing.T) {
+			destination := filepath.Join(t.TempDir(), "out")
+			err := extractMember(archive, tt.member, destination)
+			if tt.wantErr {
+				if err == nil || retry.Classify(err) != retry.ClassPermanent {
+					t.Errorf("Expected permanent error for missing member, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractMember failed: %v", err)
+			}
+			if got, _ := os.ReadFile(destination); string(got) != tt.want {
+				t.Errorf("Extracted content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVerifyChecksum verifies checksum comparison of fetched artifacts.
+// Test: Verifies a file against its digest in upper case and against a wrong digest
+// Expected: The matching digest passes regardless of case; the wrong one fails permanently
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("content"))
+	digest := hex.EncodeToString(sum[:])
+
+	if err := verifyChecksum(path, strings.ToUpper(digest)); err != nil {
+		t.Errorf("Expected matching checksum to pass, got %v", err)
+	}
+	if err := verifyChecksum(path, strings.Repeat("0", 64)); err == nil || retry.Classify(err) != retry.ClassPermanent {
+		t.Errorf("Expected permanent checksum mismatch, got %v", err)
+	}
+}
+
+// TestInstallArgs verifies the install command built for mode and owner settings.
+// Test: Builds install arguments with and without owner and group
+// Expected: Mode is always passed; owner and group only when configured
+func TestInstallArgs(t *testing.T) {
+	tests := []struct {
+		owner string
+		want  string
+	}{
+		{owner: "", want: "-D -m 0755 src /opt/bin/tool"},
+		{owner: "root", want: "-D -m 0755 -o root src /opt/bin/tool"},
+		{owner: "root:adm", want: "-D -m 0755 -o root -g adm src /opt/bin/tool"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.owner, func(t *testing.T) {
+			installer := newTestInstaller(t, config.ArtifactConfig{Name: "tool", Target: "/opt/bin/tool", Mode: "0755", Owner: tt.owner})
+			if got := strings.Join(installer.installArgs("src"), " "); got != tt.want {
+				t.Errorf("installArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUnitCommandsMatchSudoers verifies that the sudoers rules allow managing artifact units.
+// Test: Checks the systemctl commands the installer and uninstaller run for a unit with the
+// configured prefix and for a unit without it against the shipped sudoers file
+// Expected: Every command is allowed for the prefixed unit and none for the other unit
+func TestUnitCommandsMatchSudoers(t *testing.T) {
+	rules := sudoerstest.Rules(t)
+	verbs := []string{"enable", "restart", "stop", "disable"}
+
+	unit := config.ArtifactUnitPrefix + "cred.service"
+	for _, verb := range verbs {
+		if !sudoerstest.Allows(rules, "systemctl", verb, unit) {
+			t.Errorf("Expected sudoers to allow systemctl %s %s", verb, unit)
+		}
+	}
+	for _, verb := range verbs {
+		if sudoerstest.Allows(rules, "systemctl", verb, "sshd.service") {
+			t.Errorf("Expected sudoers to reject systemctl %s sshd.service", verb)
+		}
+	}
+}
+
+// TestInstaller_LocalArchive verifies installing an artifact from a local archive end to end.
+// Test: Installs a member of a local tar.gz with a checksum, then changes the artifact definition
+// Expected: The member is installed with the configured mode and reported complete until the definition changes
+func TestInstaller_LocalArchive(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "tool.tar.gz")
+	writeTarGz(t, archive, map[string]string{"tool": "#!/bin/sh\n"})
+	digest, err := fileSHA256(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	definition := config.ArtifactConfig{
+		Name:          "tool",
+		Path:          archive,
+		SHA256:        digest,
+		ArchiveMember: "tool",
+		Target:        filepath.Join(dir, "bin", "tool"),
+		Mode:          "0750",
+	}
+	installer := newTestInstaller(t, definition)
+
+	if installer.IsCompleted(context.Background()) {
+		t.Fatal("Artifact should not be complete before installation")
+	}
+	if err := installer.Execute(context.Background()); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	info, err := os.Stat(definition.Target)
+	if err != nil {
+		t.Fatalf("Expected target to be installed: %v", err)
+	}
+	if info.Mode().Perm() != 0750 {
+		t.Errorf("Expected mode 0750, got %04o", info.Mode().Perm())
+	}
+	if !installer.IsCompleted(context.Background()) {
+		t.Error("Artifact should be complete after installation")
+	}
+
+	installer.artifact.Mode = "0755"
+	if installer.IsCompleted(context.Background()) {
+		t.Error("Artifact should not be complete after its definition changed")
+	}
+}
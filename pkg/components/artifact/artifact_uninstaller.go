@@ -0,0 +1,84 @@
+package artifact
+
+import (
+	"context"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// UnInstaller removes a config-defined artifact and its optional systemd unit
+type UnInstaller struct {
+	config   *config.Config
+	artifact config.ArtifactConfig
+	logger   *logrus.Logger
+}
+
+// NewUnInstaller creates a new UnInstaller for the given artifact
+func NewUnInstaller(logger *logrus.Logger, artifact config.ArtifactConfig) *UnInstaller {
+	return &UnInstaller{
+		config:   config.GetConfig(),
+		artifact: artifact,
+		logger:   logger,
+	}
+}
+
+// GetName returns the cleanup step name
+func (u *UnInstaller) GetName() string {
+	return "ArtifactUnInstaller_" + u.artifact.Name
+}
+
+// Execute stops and removes the artifact's unit, then removes the artifact itself
+func (u *UnInstaller) Execute(ctx context.Context) error {
+	u.logger.Infof("Uninstalling artifact %s", u.artifact.Name)
+
+	if unit := u.artifact.Unit; unit != nil {
+		if err := utils.StopService(unit.Name); err != nil {
+			u.logger.Debugf("Failed to stop unit %s: %v (may not be running)", unit.Name, err)
+		}
+		if err := utils.DisableService(unit.Name); err != nil {
+			u.logger.Debugf("Failed to disable unit %s: %v (may not be enabled)", unit.Name, err)
+		}
+		if err := utils.RunCleanupCommand(unitPath(unit)); err != nil {
+			u.logger.Debugf("Failed to remove unit %s: %v (may not exist)", unitPath(unit), err)
+		}
+		if err := utils.ReloadSystemd(); err != nil {
+			u.logger.Warnf("Failed to reload systemd after removing unit %s: %v", unit.Name, err)
+		}
+	}
+
+	if err := utils.RunCleanupCommand(u.artifact.Target); err != nil {
+		u.logger.Debugf("Failed to remove %s: %v (may not exist)", u.artifact.Target, err)
+	}
+
+	if err := os.Remove(stampPath(u.config, u.artifact.Name)); err != nil && !os.IsNotExist(err) {
+		u.logger.Warnf("Failed to remove install record of artifact %s: %v", u.artifact.Name, err)
+	}
+
+	u.logger.Infof("Artifact %s uninstalled successfully", u.artifact.Name)
+	return nil
+}
+
+// Plan describes the changes Execute would make without applying them
+func (u *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	var actions []plan.Action
+	if unit := u.artifact.Unit; unit != nil {
+		actions = append(actions,
+			plan.Unit(unit.Name, plan.OpStop),
+			plan.Unit(unit.Name, plan.OpDisable),
+			plan.RemoveFile(unitPath(unit)),
+		)
+	}
+	return append(actions, plan.RemoveFile(u.artifact.Target)), nil
+}
+
+// IsCompleted checks if the artifact and its unit have been removed
+func (u *UnInstaller) IsCompleted(ctx context.Context) bool {
+	if u.artifact.Unit != nil && utils.FileExists(unitPath(u.artifact.Unit)) {
+		return false
+	}
+	return !utils.FileExists(u.artifact.Target)
+}
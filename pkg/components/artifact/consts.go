@@ -0,0 +1,9 @@
+package artifact
+
+const (
+	// systemdUnitDir is where artifact systemd units are installed
+	systemdUnitDir = "/etc/systemd/system"
+
+	// stampDirName is the directory below the agent state directory that records installed artifacts
+	stampDirName = "artifacts"
+)
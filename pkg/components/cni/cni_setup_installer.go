@@ -7,6 +7,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -29,6 +32,12 @@ func (i *Installer) GetName() string {
 	return "CNISetup"
 }
 
+// RetryPolicy reruns a failed setup with the default policy; the CNI configuration is cleaned and
+// rewritten on every run, so starting over after a failed plugins download is safe
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
+
 // Validate validates prerequisites for CNI setup
 func (i *Installer) Validate(ctx context.Context) error {
 	// Validate CNI version format
@@ -223,7 +232,37 @@ func (i *Installer) createBridgeConfig() error {
 		logrus.Warnf("Failed to remove existing config file: %v", err)
 	}
 
-	bridgeConfig := fmt.Sprintf(`{
+	bridgeConfig := renderBridgeConfig()
+
+	// Write the config file into a temp file for Atomic file write
+	tempBridgeFile, err := utils.CreateTempFile("bridge-cni-*.conf", []byte(bridgeConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary bridge config file: %w", err)
+	}
+	defer utils.CleanupTempFile(tempBridgeFile.Name())
+
+	// Copy the temp file to the final location
+	if err := utils.RunSystemCommand("cp", tempBridgeFile.Name(), configPath); err != nil {
+		return fmt.Errorf("failed to Execute bridge config file: %w", err)
+	}
+
+	// Set proper permissions - it needs to be readable by the kubelet and CNI runtime, but only writable by root
+	if err := utils.RunSystemCommand("chmod", "644", configPath); err != nil {
+		return fmt.Errorf("failed to set bridge config file permissions: %w", err)
+	}
+
+	// Set proper ownership to root:root
+	if err := utils.RunSystemCommand("chown", "root:root", configPath); err != nil {
+		logrus.Warnf("Failed to set ownership for bridge config: %v", err)
+	}
+
+	logrus.Info("Bridge CNI configuration created")
+	return nil
+}
+
+// renderBridgeConfig renders the bridge CNI network configuration
+func renderBridgeConfig() string {
+	return fmt.Sprintf(`{
     "cniVersion": "%s",
     "name": "bridge",
     "type": "bridge",
@@ -247,29 +286,37 @@ func (i *Installer) createBridgeConfig() error {
         ]
     }
 }`, DefaultCNISpecVersion)
+}
 
-	// Write the config file into a temp file for Atomic file write
-	tempBridgeFile, err := utils.CreateTempFile("bridge-cni-*.conf", []byte(bridgeConfig))
-	if err != nil {
-		return fmt.Errorf("failed to create temporary bridge config file: %w", err)
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := make([]plan.Action, 0, len(cniDirs)+5)
+	for _, dir := range cniDirs {
+		actions = append(actions, plan.CreateDirectory(dir))
 	}
-	defer utils.CleanupTempFile(tempBridgeFile.Name())
+	actions = append(actions, plan.Command("rm", "-rf", DefaultCNIConfDir+"/*").WithDetails("start from a clean configuration directory"))
 
-	// Copy the temp file to the final location
-	if err := utils.RunSystemCommand("cp", tempBridgeFile.Name(), configPath); err != nil {
-		return fmt.Errorf("failed to Execute bridge config file: %w", err)
+	_, downloadURL, err := i.constructCNIDownloadURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct CNI download URL: %w", err)
 	}
-
-	// Set proper permissions - it needs to be readable by the kubelet and CNI runtime, but only writable by root
-	if err := utils.RunSystemCommand("chmod", "644", configPath); err != nil {
-		return fmt.Errorf("failed to set bridge config file permissions: %w", err)
+	download := plan.Download(downloadURL, DefaultCNIBinDir).WithDetails(fmt.Sprintf("CNI plugins %s", getCNIVersion(i.config)))
+	if canSkipCNIPluginInstallation() {
+		download.Operation = plan.OpUnchanged
+	} else {
+		actions = append(actions, plan.Package("curl", "curl"))
 	}
 
-	// Set proper ownership to root:root
-	if err := utils.RunSystemCommand("chown", "root:root", configPath); err != nil {
-		logrus.Warnf("Failed to set ownership for bridge config: %v", err)
-	}
+	return append(actions,
+		download,
+		plan.Command("modprobe", "br_netfilter"),
+		plan.WriteFile(filepath.Join(DefaultCNIConfDir, bridgeConfigFile), []byte(renderBridgeConfig()), 0644),
+	), nil
+}
 
-	logrus.Info("Bridge CNI configuration created")
-	return nil
+// Fingerprint returns the bridge network configuration Execute installs
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{
+		drift.File(filepath.Join(DefaultCNIConfDir, bridgeConfigFile), []byte(renderBridgeConfig())),
+	}, nil
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -52,3 +53,12 @@ func (u *UnInstaller) IsCompleted(ctx context.Context) bool {
 func (u *UnInstaller) GetName() string {
 	return "CNICleanup"
 }
+
+// Plan describes the changes Execute would make without applying them
+func (u *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := make([]plan.Action, 0, len(cniDirs))
+	for _, dir := range cniDirs {
+		actions = append(actions, plan.RemoveDirectory(dir))
+	}
+	return actions, nil
+}
@@ -26,3 +26,12 @@ var (
 	containerdFileName    = "containerd-%s-linux-%s.tar.gz"
 	containerdDownloadURL = "https://github.com/containerd/containerd/releases/download/v%s/" + containerdFileName
 )
+
+// BinaryPaths returns the containerd binaries an installation consists of
+func BinaryPaths() []string {
+	paths := make([]string, 0, len(containerdBinaries))
+	for _, binary := range containerdBinaries {
+		paths = append(paths, systemBinDir+"/"+binary)
+	}
+	return paths
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/components/cni"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/preflight"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -217,7 +221,52 @@ func (i *Installer) configure() error {
 
 // createContainerdServiceFile creates the containerd systemd service file
 func (i *Installer) createContainerdServiceFile() error {
-	containerdService := `[Unit]
+	// Create containerd service file using sudo-aware approach
+	tempFile, err := utils.CreateTempFile("containerd-service-*.service", []byte(containerdServiceUnit))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary containerd service file: %w", err)
+	}
+	defer utils.CleanupTempFile(tempFile.Name())
+
+	// Copy the temp file to the final location using sudo
+	if err := utils.RunSystemCommand("cp", tempFile.Name(), containerdServiceFile); err != nil {
+		return fmt.Errorf("failed to install containerd service file: %w", err)
+	}
+
+	// Set proper permissions: root can modify the service, but everyone else can only read it, and nobody can execute it
+	if err := utils.RunSystemCommand("chmod", "644", containerdServiceFile); err != nil {
+		return fmt.Errorf("failed to set containerd service file permissions: %w", err)
+	}
+
+	return nil
+}
+
+// createContainerdConfigFile creates the containerd configuration file
+func (i *Installer) createContainerdConfigFile() error {
+	containerdConfig := i.renderContainerdConfig()
+
+	// Create a tmp containerd config file
+	tempConfigFile, err := utils.CreateTempFile("containerd-config-*.toml", []byte(containerdConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create temporary containerd config file: %w", err)
+	}
+	defer utils.CleanupTempFile(tempConfigFile.Name())
+
+	// Copy the temp file to the final location using sudo
+	if err := utils.RunSystemCommand("cp", tempConfigFile.Name(), containerdConfigFile); err != nil {
+		return fmt.Errorf("failed to install containerd config file: %w", err)
+	}
+
+	// Set proper permissions
+	if err := utils.RunSystemCommand("chmod", "644", containerdConfigFile); err != nil {
+		return fmt.Errorf("failed to set containerd config file permissions: %w", err)
+	}
+
+	return nil
+}
+
+// containerdServiceUnit is the systemd unit installed for containerd
+const containerdServiceUnit = `[Unit]
 Description=containerd container runtime
 Documentation=https://containerd.io
 After=network.target local-fs.target
@@ -241,29 +290,9 @@ OOMScoreAdjust=-999
 [Install]
 WantedBy=multi-user.target`
 
-	// Create containerd service file using sudo-aware approach
-	tempFile, err := utils.CreateTempFile("containerd-service-*.service", []byte(containerdService))
-	if err != nil {
-		return fmt.Errorf("failed to create temporary containerd service file: %w", err)
-	}
-	defer utils.CleanupTempFile(tempFile.Name())
-
-	// Copy the temp file to the final location using sudo
-	if err := utils.RunSystemCommand("cp", tempFile.Name(), containerdServiceFile); err != nil {
-		return fmt.Errorf("failed to install containerd service file: %w", err)
-	}
-
-	// Set proper permissions: root can modify the service, but everyone else can only read it, and nobody can execute it
-	if err := utils.RunSystemCommand("chmod", "644", containerdServiceFile); err != nil {
-		return fmt.Errorf("failed to set containerd service file permissions: %w", err)
-	}
-
-	return nil
-}
-
-// createContainerdConfigFile creates the containerd configuration file
-func (i *Installer) createContainerdConfigFile() error {
-	containerdConfig := fmt.Sprintf(`version = 2
+// renderContainerdConfig renders the containerd config.toml content
+func (i *Installer) renderContainerdConfig() string {
+	return fmt.Sprintf(`version = 2
 oom_score = 0
 [plugins."io.containerd.grpc.v1.cri"]
 	sandbox_image = "%s"
@@ -291,30 +320,44 @@ oom_score = 0
 		cni.DefaultCNIBinDir,
 		cni.DefaultCNIConfDir,
 		i.getMetricsAddress())
+}
 
-	// Create a tmp containerd config file
-	tempConfigFile, err := utils.CreateTempFile("containerd-config-*.toml", []byte(containerdConfig))
-	if err != nil {
-		return fmt.Errorf("failed to create temporary containerd config file: %w", err)
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := []plan.Action{
+		plan.CreateDirectory(defaultContainerdConfigDir),
+		plan.Command("rm", "-rf", defaultContainerdConfigDir+"/*").WithDetails("start from a clean configuration directory"),
 	}
-	defer utils.CleanupTempFile(tempConfigFile.Name())
 
-	// Copy the temp file to the final location using sudo
-	if err := utils.RunSystemCommand("cp", tempConfigFile.Name(), containerdConfigFile); err != nil {
-		return fmt.Errorf("failed to install containerd config file: %w", err)
+	_, downloadURL, err := i.constructContainerdDownloadURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct containerd download URL: %w", err)
 	}
-
-	// Set proper permissions
-	if err := utils.RunSystemCommand("chmod", "644", containerdConfigFile); err != nil {
-		return fmt.Errorf("failed to set containerd config file permissions: %w", err)
+	download := plan.Download(downloadURL, systemBinDir).WithDetails(fmt.Sprintf("containerd %s binaries: %s", i.getContainerdVersion(), strings.Join(containerdBinaries, ", ")))
+	if i.canSkipContainerdInstallation() {
+		download.Operation = plan.OpUnchanged
 	}
 
-	return nil
+	return append(actions,
+		download,
+		plan.WriteFile(containerdServiceFile, []byte(containerdServiceUnit), 0644),
+		plan.WriteFile(containerdConfigFile, []byte(i.renderContainerdConfig()), 0644),
+		plan.Command("systemctl", "daemon-reload"),
+	), nil
+}
+
+// Fingerprint returns the containerd version, systemd unit and configuration Execute installs
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{
+		drift.Binary(defaultContainerdBinaryDir, i.getContainerdVersion(), "--version"),
+		drift.File(containerdServiceFile, []byte(containerdServiceUnit)),
+		drift.File(containerdConfigFile, []byte(i.renderContainerdConfig())),
+	}, nil
 }
 
 // Validate validates preconditions before execution
 func (i *Installer) Validate(ctx context.Context) error {
-	return nil
+	return preflight.Validate(ctx, i.config, i.logger, preflight.CheckConflictingPackages, preflight.CheckKernelModules)
 }
 
 // GetName returns the step name
@@ -322,6 +365,12 @@ func (i *Installer) GetName() string {
 	return "ContainerdInstaller"
 }
 
+// RetryPolicy reruns a failed install with the default policy, which mostly helps when fetching
+// the containerd release archive hits a network error
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
+
 // IsCompleted checks if containerd and required plugins are installed
 func (i *Installer) IsCompleted(ctx context.Context) bool {
 	// Check if containerd binaries are installed and functional
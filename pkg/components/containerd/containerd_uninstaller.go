@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 
 	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -77,6 +78,23 @@ func (u *UnInstaller) IsCompleted(ctx context.Context) bool {
 }
 
 // stopContainerdServices stops and disables all containerd-related services
+// Plan describes the changes Execute would make without applying them
+func (u *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := []plan.Action{
+		plan.Unit("containerd", plan.OpStop),
+		plan.Unit("containerd", plan.OpDisable),
+	}
+	for _, binary := range containerdBinaries {
+		actions = append(actions, plan.RemoveFile(filepath.Join(systemBinDir, binary)))
+	}
+	return append(actions,
+		plan.RemoveFile(containerdServiceFile),
+		plan.Command("systemctl", "daemon-reload"),
+		plan.RemoveDirectory(containerdDataDir),
+		plan.RemoveDirectory(defaultContainerdConfigDir),
+	), nil
+}
+
 func (u *UnInstaller) stopContainerdServices() error {
 	u.logger.Info("Stopping and disabling containerd service")
 
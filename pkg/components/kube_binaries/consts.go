@@ -30,3 +30,8 @@ var kubeBinariesPaths = []string{
 	kubectlPath,
 	kubeadmPath,
 }
+
+// BinaryPaths returns the Kubernetes binaries an installation consists of
+func BinaryPaths() []string {
+	return append([]string(nil), kubeBinariesPaths...)
+}
@@ -7,6 +7,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -95,6 +98,23 @@ func (i *Installer) IsCompleted(ctx context.Context) bool {
 	return false
 }
 
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	_, downloadURL, err := i.constructKubeBinariesDownloadURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct Kubernetes download URL: %w", err)
+	}
+
+	return []plan.Action{
+		plan.Download(downloadURL, binDir).WithDetails(fmt.Sprintf("kubelet, kubectl and kubeadm %s, mode 0755", i.config.GetKubernetesVersion())),
+	}, nil
+}
+
+// Fingerprint returns the kubelet version Execute installs
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{drift.Binary(kubeletPath, i.config.GetKubernetesVersion(), "--version")}, nil
+}
+
 // Validate validates prerequisites for Kube binaries installation
 func (i *Installer) Validate(ctx context.Context) error {
 	// Verify network connectivity for download (basic check)
@@ -187,3 +207,9 @@ func (i *Installer) getKubernetesURLTemplate() string {
 func (i *Installer) GetName() string {
 	return "KubeBinariesInstaller"
 }
+
+// RetryPolicy reruns a failed install with the default policy so that a dropped connection while
+// fetching the kubelet, kubectl and kubeadm binaries does not fail the bootstrap
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -44,6 +45,15 @@ func (u *UnInstaller) Execute(ctx context.Context) error {
 	return nil
 }
 
+// Plan describes the changes Execute would make without applying them
+func (u *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := make([]plan.Action, 0, len(kubeBinariesPaths))
+	for _, binaryPath := range kubeBinariesPaths {
+		actions = append(actions, plan.RemoveFile(binaryPath))
+	}
+	return actions, nil
+}
+
 // IsCompleted checks if Kubernetes components have been removed
 func (u *UnInstaller) IsCompleted(ctx context.Context) bool {
 	return !utils.BinaryExists(kubeletBinary)
@@ -24,6 +24,9 @@ const (
 	kubeletKubeconfigPath      = "/var/lib/kubelet/kubeconfig"
 	kubeletTokenScriptPath     = "/var/lib/kubelet/token.sh"
 
+	// procMountsPath lists the mounts of the agent's mount namespace
+	procMountsPath = "/proc/self/mounts"
+
 	// Azure resource identifiers
 	aksServiceResourceID = "6dae42f8-4368-4678-94ff-3960e28e3630"
 )
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v5"
@@ -12,6 +13,10 @@ import (
 
 	"go.goms.io/aks/AKSFlexNode/pkg/auth"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/preflight"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -30,11 +35,51 @@ func NewInstaller(logger *logrus.Logger) *Installer {
 	}
 }
 
+const (
+	// kubeletContainerdDropIn points kubelet at the containerd runtime endpoint
+	kubeletContainerdDropIn = `[Service]
+Environment=KUBELET_CONTAINERD_FLAGS="--runtime-request-timeout=15m --container-runtime-endpoint=unix:///run/containerd/containerd.sock"`
+
+	// kubeletTLSBootstrapDropIn points kubelet at the exec credential kubeconfig
+	kubeletTLSBootstrapDropIn = `[Service]
+Environment=KUBELET_TLS_BOOTSTRAP_FLAGS="--kubeconfig /var/lib/kubelet/kubeconfig"`
+
+	// kubeletServiceUnit is the main kubelet systemd service
+	kubeletServiceUnit = `[Unit]
+Description=Kubelet
+ConditionPathExists=/usr/local/bin/kubelet
+[Service]
+Restart=always
+EnvironmentFile=/etc/default/kubelet
+SuccessExitStatus=143
+# Ace does not recall why this is done
+ExecStartPre=/bin/bash -c "if [ $(mount | grep \"/var/lib/kubelet\" | wc -l) -le 0 ] ; then /bin/mount --bind /var/lib/kubelet /var/lib/kubelet ; fi"
+ExecStartPre=/bin/mount --make-shared /var/lib/kubelet
+ExecStartPre=-/sbin/ebtables -t nat --list
+ExecStartPre=-/sbin/iptables -t nat --numeric --list
+ExecStart=/usr/local/bin/kubelet \
+        --enable-server \
+        --node-labels="${KUBELET_NODE_LABELS}" \
+        --volume-plugin-dir=/etc/kubernetes/volumeplugins \
+        --pod-manifest-path=/etc/kubernetes/manifests/ \
+        $KUBELET_TLS_BOOTSTRAP_FLAGS \
+        $KUBELET_CONFIG_FILE_FLAGS \
+        $KUBELET_CONTAINERD_FLAGS \
+        $KUBELET_FLAGS
+[Install]
+WantedBy=multi-user.target`
+)
+
 // GetName returns the step name for the executor interface
 func (i *Installer) GetName() string {
 	return "KubeletInstaller"
 }
 
+// RetryPolicy retries the step when fetching cluster credentials from Azure fails transiently
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
+
 // Execute installs and configures kubelet service
 func (i *Installer) Execute(ctx context.Context) error {
 	i.logger.Info("Installing and configuring kubelet")
@@ -60,10 +105,9 @@ func (i *Installer) IsCompleted(ctx context.Context) bool {
 }
 
 // Validate validates prerequisites for kubelet installation
-func (i *Installer) Validate(_ context.Context) error {
+func (i *Installer) Validate(ctx context.Context) error {
 	i.logger.Debug("Validating prerequisites for kubelet installation")
-	// No specific prerequisites for kubelet configuration
-	return nil
+	return preflight.Validate(ctx, i.config, i.logger, preflight.CheckPorts, preflight.CheckSystemdResolved)
 }
 
 // configure configures kubelet service with systemd unit file and default settings
@@ -191,46 +235,7 @@ func (i *Installer) ensureRequiredPackages() error {
 
 // createKubeletDefaultsFile creates the kubelet defaults configuration file
 func (i *Installer) createKubeletDefaultsFile() error {
-	// Create kubelet default config
-	labels := make([]string, 0, len(i.config.Node.Labels))
-	for key, value := range i.config.Node.Labels {
-		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
-	}
-
-	kubeletDefaults := fmt.Sprintf(`KUBELET_NODE_LABELS="%s"
-KUBELET_CONFIG_FILE_FLAGS=""
-KUBELET_FLAGS="\
-	--v=%d \
-  --address=0.0.0.0 \
-  --anonymous-auth=false \
-  --authentication-token-webhook=true \
-  --authorization-mode=Webhook \
-  --cgroup-driver=systemd \
-  --cgroups-per-qos=true \
-  --enforce-node-allocatable=pods \
-  --event-qps=0  \
-  --eviction-hard=%s  \
-  --kube-reserved=%s  \
-  --image-gc-high-threshold=%d  \
-  --image-gc-low-threshold=%d  \
-  --max-pods=%d  \
-  --node-status-update-frequency=10s  \
-  --pod-infra-container-image=%s  \
-  --pod-max-pids=-1  \
-  --protect-kernel-defaults=true  \
-  --read-only-port=0  \
-  --resolv-conf=/run/systemd/resolve/resolv.conf  \
-  --streaming-connection-idle-timeout=4h  \
-  --tls-cipher-suites=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_128_GCM_SHA256 \
-  "`,
-		strings.Join(labels, ","),
-		i.config.Node.Kubelet.Verbosity,
-		mapToEvictionThresholds(i.config.Node.Kubelet.EvictionHard, ","),
-		mapToKeyValuePairs(i.config.Node.Kubelet.KubeReserved, ","),
-		i.config.Node.Kubelet.ImageGCHighThreshold,
-		i.config.Node.Kubelet.ImageGCLowThreshold,
-		i.config.Node.MaxPods,
-		i.config.Containerd.PauseImage)
+	kubeletDefaults := i.renderKubeletDefaults()
 
 	// Ensure /etc/default directory exists
 	if err := utils.RunSystemCommand("mkdir", "-p", etcDefaultDir); err != nil {
@@ -262,48 +267,18 @@ func (i *Installer) createSystemdDropInFile(filePath, content, description strin
 
 // createKubeletContainerdConfig creates the kubelet containerd configuration
 func (i *Installer) createKubeletContainerdConfig() error {
-	containerdConf := `[Service]
-Environment=KUBELET_CONTAINERD_FLAGS="--runtime-request-timeout=15m --container-runtime-endpoint=unix:///run/containerd/containerd.sock"`
-
-	return i.createSystemdDropInFile(kubeletContainerdConfig, containerdConf, "kubelet containerd config file")
+	return i.createSystemdDropInFile(kubeletContainerdConfig, kubeletContainerdDropIn, "kubelet containerd config file")
 }
 
 // createKubeletTLSBootstrapConfig creates the kubelet TLS bootstrap configuration
 func (i *Installer) createKubeletTLSBootstrapConfig() error {
-	tlsBootstrapConf := `[Service]
-Environment=KUBELET_TLS_BOOTSTRAP_FLAGS="--kubeconfig /var/lib/kubelet/kubeconfig"`
-
-	return i.createSystemdDropInFile(kubeletTLSBootstrapConfig, tlsBootstrapConf, "kubelet TLS bootstrap config file")
+	return i.createSystemdDropInFile(kubeletTLSBootstrapConfig, kubeletTLSBootstrapDropIn, "kubelet TLS bootstrap config file")
 }
 
 // createKubeletServiceFile creates the main kubelet systemd service file
 func (i *Installer) createKubeletServiceFile() error {
-	kubeletService := `[Unit]
-Description=Kubelet
-ConditionPathExists=/usr/local/bin/kubelet
-[Service]
-Restart=always
-EnvironmentFile=/etc/default/kubelet
-SuccessExitStatus=143
-# Ace does not recall why this is done
-ExecStartPre=/bin/bash -c "if [ $(mount | grep \"/var/lib/kubelet\" | wc -l) -le 0 ] ; then /bin/mount --bind /var/lib/kubelet /var/lib/kubelet ; fi"
-ExecStartPre=/bin/mount --make-shared /var/lib/kubelet
-ExecStartPre=-/sbin/ebtables -t nat --list
-ExecStartPre=-/sbin/iptables -t nat --numeric --list
-ExecStart=/usr/local/bin/kubelet \
-        --enable-server \
-        --node-labels="${KUBELET_NODE_LABELS}" \
-        --volume-plugin-dir=/etc/kubernetes/volumeplugins \
-        --pod-manifest-path=/etc/kubernetes/manifests/ \
-        $KUBELET_TLS_BOOTSTRAP_FLAGS \
-        $KUBELET_CONFIG_FILE_FLAGS \
-        $KUBELET_CONTAINERD_FLAGS \
-        $KUBELET_FLAGS
-[Install]
-WantedBy=multi-user.target`
-
 	// Write kubelet service file atomically with proper permissions
-	if err := utils.WriteFileAtomicSystem(kubeletServicePath, []byte(kubeletService), 0o644); err != nil {
+	if err := utils.WriteFileAtomicSystem(kubeletServicePath, []byte(kubeletServiceUnit), 0o644); err != nil {
 		return fmt.Errorf("failed to create kubelet service file: %w", err)
 	}
 
@@ -312,36 +287,7 @@ WantedBy=multi-user.target`
 
 // createArcTokenScript creates the Arc token script for exec credential authentication
 func (i *Installer) createArcTokenScript() error {
-	// Arc HIMDS token script using proven Www-Authenticate challenge approach
-	tokenScript := fmt.Sprintf(`#!/bin/bash
-
-# Fetch an AAD token from Azure Arc HIMDS and output it in the ExecCredential format
-# https://learn.microsoft.com/azure/azure-arc/servers/managed-identity-authentication
-
-TOKEN_URL="http://127.0.0.1:40342/metadata/identity/oauth2/token?api-version=2019-11-01&resource=%s"
-EXECCREDENTIAL='''
-{
-  "kind": "ExecCredential",
-  "apiVersion": "client.authentication.k8s.io/v1beta1",
-  "spec": {
-    "interactive": false
-  },
-  "status": {
-    "expirationTimestamp": .expires_on | tonumber | todate,
-    "token": .access_token
-  }
-}
-'''
-
-# Arc IMDS requires a challenge token from a file only readable by root for security
-CHALLENGE_TOKEN_PATH=$(curl -s -D - -H Metadata:true $TOKEN_URL | grep Www-Authenticate | cut -d "=" -f 2 | tr -d "[:cntrl:]")
-CHALLENGE_TOKEN=$(cat $CHALLENGE_TOKEN_PATH)
-if [ $? -ne 0 ]; then
-    echo "Could not retrieve challenge token, double check that this command is run with root privileges."
-    exit 255
-fi
-
-curl -s -H Metadata:true -H "Authorization: Basic $CHALLENGE_TOKEN" $TOKEN_URL | jq "$EXECCREDENTIAL"`, aksServiceResourceID)
+	tokenScript := renderArcTokenScript()
 
 	// Ensure /var/lib/kubelet directory exists
 	if err := utils.RunSystemCommand("mkdir", "-p", kubeletVarDir); err != nil {
@@ -471,6 +417,7 @@ func mapToKeyValuePairs(m map[string]string, separator string) string {
 	for k, v := range m {
 		pairs = append(pairs, fmt.Sprintf("%s=%s", k, v))
 	}
+	sort.Strings(pairs)
 	return strings.Join(pairs, separator)
 }
 
@@ -480,5 +427,114 @@ func mapToEvictionThresholds(m map[string]string, separator string) string {
 	for k, v := range m {
 		pairs = append(pairs, fmt.Sprintf("%s<%s", k, v))
 	}
+	sort.Strings(pairs)
 	return strings.Join(pairs, separator)
 }
+
+// renderKubeletDefaults renders the kubelet defaults environment file
+func (i *Installer) renderKubeletDefaults() string {
+	labels := make([]string, 0, len(i.config.Node.Labels))
+	for key, value := range i.config.Node.Labels {
+		labels = append(labels, fmt.Sprintf("%s=%s", key, value))
+	}
+	sort.Strings(labels)
+
+	return fmt.Sprintf(`KUBELET_NODE_LABELS="%s"
+KUBELET_CONFIG_FILE_FLAGS=""
+KUBELET_FLAGS="\
+	--v=%d \
+  --address=0.0.0.0 \
+  --anonymous-auth=false \
+  --authentication-token-webhook=true \
+  --authorization-mode=Webhook \
+  --cgroup-driver=systemd \
+  --cgroups-per-qos=true \
+  --enforce-node-allocatable=pods \
+  --event-qps=0  \
+  --eviction-hard=%s  \
+  --kube-reserved=%s  \
+  --image-gc-high-threshold=%d  \
+  --image-gc-low-threshold=%d  \
+  --max-pods=%d  \
+  --node-status-update-frequency=10s  \
+  --pod-infra-container-image=%s  \
+  --pod-max-pids=-1  \
+  --protect-kernel-defaults=true  \
+  --read-only-port=0  \
+  --resolv-conf=/run/systemd/resolve/resolv.conf  \
+  --streaming-connection-idle-timeout=4h  \
+  --tls-cipher-suites=TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_256_GCM_SHA384,TLS_RSA_WITH_AES_128_GCM_SHA256 \
+  "`,
+		strings.Join(labels, ","),
+		i.config.Node.Kubelet.Verbosity,
+		mapToEvictionThresholds(i.config.Node.Kubelet.EvictionHard, ","),
+		mapToKeyValuePairs(i.config.Node.Kubelet.KubeReserved, ","),
+		i.config.Node.Kubelet.ImageGCHighThreshold,
+		i.config.Node.Kubelet.ImageGCLowThreshold,
+		i.config.Node.MaxPods,
+		i.config.Containerd.PauseImage)
+}
+
+// renderArcTokenScript renders the Arc HIMDS token script used as kubelet exec credential provider
+func renderArcTokenScript() string {
+	// Arc HIMDS token script using proven Www-Authenticate challenge approach
+	return fmt.Sprintf(`#!/bin/bash
+
+# Fetch an AAD token from Azure Arc HIMDS and output it in the ExecCredential format
+# https://learn.microsoft.com/azure/azure-arc/servers/managed-identity-authentication
+
+TOKEN_URL="http://127.0.0.1:40342/metadata/identity/oauth2/token?api-version=2019-11-01&resource=%s"
+EXECCREDENTIAL='''
+{
+  "kind": "ExecCredential",
+  "apiVersion": "client.authentication.k8s.io/v1beta1",
+  "spec": {
+    "interactive": false
+  },
+  "status": {
+    "expirationTimestamp": .expires_on | tonumber | todate,
+    "token": .access_token
+  }
+}
+'''
+
+# Arc IMDS requires a challenge token from a file only readable by root for security
+CHALLENGE_TOKEN_PATH=$(curl -s -D - -H Metadata:true $TOKEN_URL | grep Www-Authenticate | cut -d "=" -f 2 | tr -d "[:cntrl:]")
+CHALLENGE_TOKEN=$(cat $CHALLENGE_TOKEN_PATH)
+if [ $? -ne 0 ]; then
+    echo "Could not retrieve challenge token, double check that this command is run with root privileges."
+    exit 255
+fi
+
+curl -s -H Metadata:true -H "Authorization: Basic $CHALLENGE_TOKEN" $TOKEN_URL | jq "$EXECCREDENTIAL"`, aksServiceResourceID)
+}
+
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	clusterName := i.config.GetTargetClusterName()
+	return []plan.Action{
+		plan.Package("jq", "jq"),
+		plan.Package("iptables", "iptables"),
+		plan.CreateDirectory(kubeletManifestsDir),
+		plan.CreateDirectory(kubeletVolumePluginDir),
+		plan.CreateDirectory(kubeletVarDir),
+		plan.WriteFile(kubeletDefaultsPath, []byte(i.renderKubeletDefaults()), 0o644),
+		plan.WriteFile(kubeletTokenScriptPath, []byte(renderArcTokenScript()), 0o755),
+		plan.WriteGeneratedFile(kubeletKubeconfigPath, fmt.Sprintf("rendered from admin credentials of cluster %s", clusterName)),
+		plan.WriteFile(kubeletContainerdConfig, []byte(kubeletContainerdDropIn), 0o644),
+		plan.WriteFile(kubeletTLSBootstrapConfig, []byte(kubeletTLSBootstrapDropIn), 0o644),
+		plan.WriteFile(kubeletServicePath, []byte(kubeletServiceUnit), 0o644),
+	}, nil
+}
+
+// Fingerprint returns the kubelet configuration, token script and systemd units Execute installs.
+// The kubeconfig is rendered from cluster credentials and is not part of it.
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{
+		drift.File(kubeletDefaultsPath, []byte(i.renderKubeletDefaults())),
+		drift.File(kubeletTokenScriptPath, []byte(renderArcTokenScript())),
+		drift.File(kubeletContainerdConfig, []byte(kubeletContainerdDropIn)),
+		drift.File(kubeletTLSBootstrapConfig, []byte(kubeletTLSBootstrapDropIn)),
+		drift.File(kubeletServicePath, []byte(kubeletServiceUnit)),
+	}, nil
+}
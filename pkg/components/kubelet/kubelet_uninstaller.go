@@ -2,11 +2,34 @@ package kubelet
 
 import (
 	"context"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
+// kubeletUninstallFiles are the kubelet configuration files removed on uninstall
+var kubeletUninstallFiles = []string{
+	kubeletDefaultsPath,
+	kubeletServicePath,
+	kubeletContainerdConfig,
+	kubeletConfigPath,
+	kubeletKubeConfig,
+	kubeletBootstrapKubeConfig,
+}
+
+// kubeletUninstallDirectories are the kubelet configuration directories removed on uninstall
+var kubeletUninstallDirectories = []string{
+	kubeletServiceDir,      // /etc/systemd/system/kubelet.service.d
+	kubeletVarDir,          // /var/lib/kubelet
+	kubeletManifestsDir,    // Static pod manifests (kubelet-specific)
+	kubeletVolumePluginDir, // Volume plugins (kubelet-specific)
+}
+
 // UnInstaller handles kubelet cleanup operations
 type UnInstaller struct {
 	logger *logrus.Logger
@@ -35,33 +58,18 @@ func (u *UnInstaller) Execute(ctx context.Context) error {
 		}
 	}
 
-	// Remove kubelet configuration files
-	kubeletFiles := []string{
-		kubeletDefaultsPath,
-		kubeletServicePath,
-		kubeletContainerdConfig,
-		kubeletConfigPath,
-		kubeletKubeConfig,
-		kubeletBootstrapKubeConfig,
-	}
-
-	// Remove kubelet configuration directories
-	kubeletDirectories := []string{
-		kubeletServiceDir,      // /etc/systemd/system/kubelet.service.d
-		kubeletVarDir,          // /var/lib/kubelet
-		kubeletManifestsDir,    // Static pod manifests (kubelet-specific)
-		kubeletVolumePluginDir, // Volume plugins (kubelet-specific)
-	}
-
 	// Remove individual files
-	if fileErrors := utils.RemoveFiles(kubeletFiles, u.logger); len(fileErrors) > 0 {
+	if fileErrors := utils.RemoveFiles(kubeletUninstallFiles, u.logger); len(fileErrors) > 0 {
 		for _, err := range fileErrors {
 			u.logger.Warnf("Configuration file removal error: %v", err)
 		}
 	}
 
+	// Pod volumes mounted below the kubelet directory would keep it from being removed
+	u.unmountKubeletVolumes()
+
 	// Remove directories
-	if dirErrors := utils.RemoveDirectories(kubeletDirectories, u.logger); len(dirErrors) > 0 {
+	if dirErrors := utils.RemoveDirectories(kubeletUninstallDirectories, u.logger); len(dirErrors) > 0 {
 		for _, err := range dirErrors {
 			u.logger.Warnf("Directory removal error: %v", err)
 		}
@@ -93,3 +101,85 @@ func (u *UnInstaller) IsCompleted(ctx context.Context) bool {
 
 	return true
 }
+
+// Plan describes the changes Execute would make without applying them
+func (u *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := make([]plan.Action, 0, len(kubeletUninstallFiles)+len(kubeletUninstallDirectories)+2)
+	if utils.ServiceExists("kubelet") {
+		actions = append(actions, plan.Unit("kubelet", plan.OpStop))
+	}
+	for _, file := range kubeletUninstallFiles {
+		actions = append(actions, plan.RemoveFile(file))
+	}
+	for _, mountPoint := range kubeletMounts() {
+		actions = append(actions, plan.Command("umount", "-l", mountPoint))
+	}
+	for _, dir := range kubeletUninstallDirectories {
+		actions = append(actions, plan.RemoveDirectory(dir))
+	}
+	return append(actions, plan.Command("systemctl", "daemon-reload")), nil
+}
+
+// unmountKubeletVolumes unmounts everything mounted below the kubelet directory, deepest first.
+// Mounts are detached lazily so that volumes still busy do not block the cleanup; this is also the
+// only umount form the agent's sudoers rules allow.
+func (u *UnInstaller) unmountKubeletVolumes() {
+	for _, mountPoint := range kubeletMounts() {
+		u.logger.Infof("Unmounting %s", mountPoint)
+		if err := utils.RunSystemCommand("umount", "-l", mountPoint); err != nil {
+			u.logger.Warnf("Failed to unmount %s: %v", mountPoint, err)
+		}
+	}
+}
+
+// kubeletMounts returns the current mount points below the kubelet directory
+func kubeletMounts() []string {
+	data, err := os.ReadFile(procMountsPath)
+	if err != nil {
+		return nil
+	}
+	return mountsUnder(string(data), kubeletVarDir)
+}
+
+// mountsUnder returns the mount points of a /proc/mounts listing that lie below dir,
+// ordered so that nested mounts come before the mounts containing them
+func mountsUnder(mounts, dir string) []string {
+	prefix := strings.TrimSuffix(dir, "/") + "/"
+	seen := make(map[string]bool)
+	var result []string
+	for _, line := range strings.Split(mounts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		mountPoint := unescapeMountPath(fields[1])
+		if strings.HasPrefix(mountPoint, prefix) && !seen[mountPoint] {
+			seen[mountPoint] = true
+			result = append(result, mountPoint)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.Count(result[i], "/") > strings.Count(result[j], "/")
+	})
+	return result
+}
+
+// unescapeMountPath decodes the octal escapes /proc/mounts uses for spaces, tabs,
+// newlines and backslashes in paths
+func unescapeMountPath(path string) string {
+	if !strings.Contains(path, "\\") {
+		return path
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] == '\\' && i+3 < len(path) {
+			if c, err := strconv.ParseUint(path[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(c))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(path[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package kubelet
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMountsUnder verifies which mounts are unmounted before the kubelet directory is removed.
+// Test: Parses a /proc/mounts listing with pod volumes, nested mounts, escaped paths and unrelated mounts
+// Expected: Only mounts below the directory are returned, nested mounts before their parents and escapes decoded
+func TestMountsUnder(t *testing.T) {
+	mounts := `sysfs /sys sysfs rw,nosuid,nodev,noexec,relatime 0 0
+/dev/sda1 /var/lib/kubelet ext4 rw,relatime 0 0
+tmpfs /var/lib/kubelet/pods/abc/volumes/kubernetes.io~projected/kube-api-access tmpfs rw,relatime 0 0
+/dev/sdb /var/lib/kubelet/plugins/disk ext4 rw,relatime 0 0
+/dev/sdb /var/lib/kubelet/plugins/disk/globalmount/nested ext4 rw,relatime 0 0
+tmpfs /var/lib/kubelet/pods/my\040pod/volumes/secret tmpfs rw,relatime 0 0
+tmpfs /var/lib/kubelet-other/pods tmpfs rw,relatime 0 0
+tmpfs /var/lib/kubelet/pods/abc/volumes/kubernetes.io~projected/kube-api-access tmpfs rw,relatime 0 0
+`
+
+	got := mountsUnder(mounts, "/var/lib/kubelet")
+	want := []string{
+		"/var/lib/kubelet/pods/abc/volumes/kubernetes.io~projected/kube-api-access",
+		"/var/lib/kubelet/plugins/disk/globalmount/nested",
+		"/var/lib/kubelet/pods/my pod/volumes/secret",
+		"/var/lib/kubelet/plugins/disk",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mountsUnder() = %v, want %v", got, want)
+	}
+
+	if got := mountsUnder("", "/var/lib/kubelet"); len(got) != 0 {
+		t.Errorf("mountsUnder() of an empty listing = %v, want none", got)
+	}
+}
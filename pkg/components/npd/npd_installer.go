@@ -8,6 +8,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -27,6 +30,12 @@ func (i *Installer) GetName() string {
 	return "NPD_Installer"
 }
 
+// RetryPolicy reruns a failed install with the default policy; Execute removes a partial
+// installation before downloading the release again
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
+
 func (i *Installer) Execute(ctx context.Context) error {
 	i.logger.Infof("Installing Node Problem Detector version %s", i.config.Npd.Version)
 
@@ -123,14 +132,30 @@ func (i *Installer) configure() error {
 }
 
 func (i *Installer) createNpdServiceFile() error {
+	npdService, err := i.renderNpdServiceFile()
+	if err != nil {
+		return err
+	}
+
+	// Write NPD service file atomically with proper permissions
+	if err := utils.WriteFileAtomicSystem(npdServicePath, []byte(npdService), 0644); err != nil {
+		return fmt.Errorf("failed to create NPD service file: %w", err)
+	}
+
+	i.logger.Infof("Created NPD systemd service file at %s", npdServicePath)
+	return nil
+}
+
+// renderNpdServiceFile renders the NPD systemd unit pointing at the API server from the kubelet kubeconfig
+func (i *Installer) renderNpdServiceFile() (string, error) {
 	kubeConfigData, err := utils.RunCommandWithOutput("cat", kubeletKubeconfigPath)
 	if err != nil {
-		return fmt.Errorf("failed to read kubelet kubeconfig file: %w", err)
+		return "", fmt.Errorf("failed to read kubelet kubeconfig file: %w", err)
 	}
 
 	serverURL, _, err := utils.ExtractClusterInfo([]byte(kubeConfigData))
 	if err != nil {
-		return fmt.Errorf("failed to extract cluster info: %w", err)
+		return "", fmt.Errorf("failed to extract cluster info: %w", err)
 	}
 
 	cmd := fmt.Sprintf("%s --apiserver-override=\"%s?inClusterConfig=false&auth=%s\" --config.system-log-monitor=%s",
@@ -148,14 +173,7 @@ RestartSec=5s
 [Install]
 WantedBy=multi-user.target
 `
-	// Write NPD service file atomically with proper permissions
-	if err := utils.WriteFileAtomicSystem(npdServicePath, []byte(npdService), 0644); err != nil {
-		return fmt.Errorf("failed to create NPD service file: %w", err)
-	}
-
-	i.logger.Infof("Created NPD systemd service file at %s", npdServicePath)
-
-	return nil
+	return npdService, nil
 }
 
 func (i *Installer) IsCompleted(ctx context.Context) bool {
@@ -168,6 +186,38 @@ func (i *Installer) IsCompleted(ctx context.Context) bool {
 	return i.isNpdVersionCorrect()
 }
 
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	_, downloadURL, err := i.getNpdDownloadURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct NPD download URL: %w", err)
+	}
+
+	actions := []plan.Action{
+		plan.Download(downloadURL, npdBinaryPath).WithDetails(fmt.Sprintf("node-problem-detector %s, mode 0555", i.getNpdVersion())),
+		plan.WriteGeneratedFile(npdConfigPath, "kernel monitor config extracted from the NPD archive"),
+	}
+
+	// The service file embeds the API server URL, which is only known once kubelet is configured
+	if npdService, err := i.renderNpdServiceFile(); err == nil {
+		actions = append(actions, plan.WriteFile(npdServicePath, []byte(npdService), 0644))
+	} else {
+		actions = append(actions, plan.WriteGeneratedFile(npdServicePath, "API server URL taken from the kubelet kubeconfig"))
+	}
+
+	return actions, nil
+}
+
+// Fingerprint returns the NPD version and systemd unit Execute installs. The unit is left out
+// while the kubelet kubeconfig it is rendered from cannot be read.
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	fingerprint := drift.Fingerprint{drift.Binary(npdBinaryPath, i.getNpdVersion(), "--version")}
+	if npdService, err := i.renderNpdServiceFile(); err == nil {
+		fingerprint = append(fingerprint, drift.File(npdServicePath, []byte(npdService)))
+	}
+	return fingerprint, nil
+}
+
 // Validate validates prerequisites before installing NPD
 func (i *Installer) Validate(ctx context.Context) error {
 
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -40,6 +41,14 @@ func (nu *UnInstaller) Execute(ctx context.Context) error {
 	return nil
 }
 
+// Plan describes the changes Execute would make without applying them
+func (nu *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	return []plan.Action{
+		plan.RemoveFile(npdBinaryPath),
+		plan.RemoveFile(npdConfigPath),
+	}, nil
+}
+
 func (nu *UnInstaller) IsCompleted(ctx context.Context) bool {
 	// Check if NPD is uninstalled
 	if !utils.FileExists(npdBinaryPath) && !utils.FileExists(npdConfigPath) {
@@ -9,3 +9,8 @@ var (
 	runcFileName    = "runc.%s"
 	runcDownloadURL = "https://github.com/opencontainers/runc/releases/download/v%s/" + runcFileName
 )
+
+// BinaryPaths returns the files an installation of runc consists of
+func BinaryPaths() []string {
+	return []string{runcBinaryPath}
+}
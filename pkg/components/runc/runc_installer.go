@@ -7,6 +7,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -29,6 +32,12 @@ func (i *Installer) GetName() string {
 	return "Runc_Installer"
 }
 
+// RetryPolicy reruns a failed install with the default policy; Execute cleans up the previous
+// binary before fetching it again
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
+
 // Execute downloads and installs the runc container runtime
 func (i *Installer) Execute(ctx context.Context) error {
 	i.logger.Infof("Installing runc version %s", i.getRuncVersion())
@@ -105,6 +114,23 @@ func (i *Installer) IsCompleted(ctx context.Context) bool {
 	return i.isRuncVersionCorrect()
 }
 
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	_, downloadURL, err := i.constructRuncDownloadURL()
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct runc download URL: %w", err)
+	}
+
+	return []plan.Action{
+		plan.Download(downloadURL, runcBinaryPath).WithDetails(fmt.Sprintf("runc %s, mode 0555", i.getRuncVersion())),
+	}, nil
+}
+
+// Fingerprint returns the runc version Execute installs
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{drift.Binary(runcBinaryPath, i.getRuncVersion(), "--version")}, nil
+}
+
 // Validate validates prerequisites before installing runc
 func (i *Installer) Validate(ctx context.Context) error {
 	return nil
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -40,6 +41,11 @@ func (ru *UnInstaller) Execute(ctx context.Context) error {
 	return nil
 }
 
+// Plan describes the changes Execute would make without applying them
+func (ru *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	return []plan.Action{plan.RemoveFile(runcBinaryPath)}, nil
+}
+
 // IsCompleted checks if runc has been removed
 func (ru *UnInstaller) IsCompleted(ctx context.Context) bool {
 	_, err := utils.RunCommandWithOutput("which", "runc")
@@ -7,6 +7,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -87,3 +90,29 @@ func (i *Installer) Validate(ctx context.Context) error {
 func (i *Installer) GetName() string {
 	return "ServicesEnabled"
 }
+
+// RetryPolicy reruns the step with the default policy. Nothing is downloaded here; the retry covers
+// units such as kubelet that fail to come up until containerd has settled.
+func (i *Installer) RetryPolicy() retry.Policy {
+	return retry.DefaultPolicy()
+}
+
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	return []plan.Action{
+		plan.Command("systemctl", "daemon-reload"),
+		plan.Unit("containerd", plan.OpEnable),
+		plan.Unit("containerd", plan.OpRestart).WithDetails("pick up CNI configuration changes"),
+		plan.Unit("kubelet", plan.OpEnable),
+		plan.Unit("node-problem-detector", plan.OpEnable),
+	}, nil
+}
+
+// Fingerprint returns the services Execute enables
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{
+		drift.UnitEnabled(ContainerdService),
+		drift.UnitEnabled(KubeletService),
+		drift.UnitEnabled("node-problem-detector"),
+	}, nil
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -62,3 +63,14 @@ func (su *UnInstaller) IsCompleted(ctx context.Context) bool {
 	// Services are considered Executeed if they are not active
 	return !utils.IsServiceActive("containerd") && !utils.IsServiceActive("kubelet")
 }
+
+// Plan describes the changes Execute would make without applying them
+func (su *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	var actions []plan.Action
+	for _, service := range []string{"kubelet", "containerd"} {
+		if utils.ServiceExists(service) {
+			actions = append(actions, plan.Unit(service, plan.OpStop), plan.Unit(service, plan.OpDisable))
+		}
+	}
+	return actions, nil
+}
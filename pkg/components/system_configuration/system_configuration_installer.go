@@ -6,9 +6,23 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
+	"go.goms.io/aks/AKSFlexNode/pkg/preflight"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
+// sysctlConfig holds the kernel settings required by Kubernetes
+const sysctlConfig = `# Kubernetes sysctl settings
+net.bridge.bridge-nf-call-iptables = 1
+net.bridge.bridge-nf-call-ip6tables = 1
+net.ipv4.ip_forward = 1
+vm.overcommit_memory = 1
+kernel.panic = 10
+kernel.panic_on_oops = 1
+# Disable swap permanently - required for kubelet
+vm.swappiness = 0`
+
 // Installer handles system configuration installation
 type Installer struct {
 	config *config.Config
@@ -47,23 +61,13 @@ func (i *Installer) IsCompleted(ctx context.Context) bool {
 		utils.FileExists(resolvConfPath)
 }
 
-// Validate validates the system configuration installation
+// Validate runs the host preflight checks system configuration depends on
 func (i *Installer) Validate(ctx context.Context) error {
-	return nil
+	return preflight.Validate(ctx, i.config, i.logger, preflight.CheckSwap, preflight.CheckCgroup, preflight.CheckDiskSpace, preflight.CheckClock)
 }
 
 // configureSysctl creates and applies sysctl configuration for Kubernetes
 func (i *Installer) configureSysctl() error {
-	sysctlConfig := `# Kubernetes sysctl settings
-net.bridge.bridge-nf-call-iptables = 1
-net.bridge.bridge-nf-call-ip6tables = 1
-net.ipv4.ip_forward = 1
-vm.overcommit_memory = 1
-kernel.panic = 10
-kernel.panic_on_oops = 1
-# Disable swap permanently - required for kubelet
-vm.swappiness = 0`
-
 	// Create sysctl directory if it doesn't exist
 	if err := utils.RunSystemCommand("mkdir", "-p", sysctlDir); err != nil {
 		return fmt.Errorf("failed to create sysctl directory: %w", err)
@@ -115,3 +119,21 @@ func (i *Installer) configureResolvConf() error {
 func (i *Installer) GetName() string {
 	return "SystemConfigured"
 }
+
+// Plan describes the changes Execute would make without applying them
+func (i *Installer) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := []plan.Action{
+		plan.CreateDirectory(sysctlDir),
+		plan.WriteFile(sysctlConfigPath, []byte(sysctlConfig), 0644),
+		plan.Command("sysctl", "--system"),
+	}
+	if utils.FileExists(resolvConfSource) {
+		actions = append(actions, plan.Symlink(resolvConfPath, resolvConfSource))
+	}
+	return actions, nil
+}
+
+// Fingerprint returns the sysctl settings Execute installs
+func (i *Installer) Fingerprint(ctx context.Context) (drift.Fingerprint, error) {
+	return drift.Fingerprint{drift.File(sysctlConfigPath, []byte(sysctlConfig))}, nil
+}
@@ -2,9 +2,11 @@ package system_configuration
 
 import (
 	"context"
+	"os"
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/plan"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -90,3 +92,13 @@ func (su *UnInstaller) cleanupResolvConf() error {
 	}
 	return nil
 }
+
+// Plan describes the changes Execute would make without applying them
+func (su *UnInstaller) Plan(ctx context.Context) ([]plan.Action, error) {
+	actions := []plan.Action{plan.RemoveFile(sysctlConfigPath)}
+	// Only the symlink created during bootstrap is removed
+	if target, err := os.Readlink(resolvConfPath); err == nil && target == resolvConfSource {
+		actions = append(actions, plan.RemoveFile(resolvConfPath))
+	}
+	return append(actions, plan.Command("sysctl", "--system")), nil
+}
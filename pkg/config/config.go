@@ -1,19 +1,43 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"path/filepath"
+	"reflect"
 	"regexp"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
 
 const (
 	// Default configuration values
-	defaultConfigPath = "/etc/aks-flex-node/config.json"
-	defaultLogDir     = "/var/log/aks-flex-node"
-	defaultLogLevel   = "info"
-	defaultAzureCloud = "AzurePublicCloud"
+	defaultConfigPath       = "/etc/aks-flex-node/config.json"
+	defaultLogDir           = "/var/log/aks-flex-node"
+	defaultLogLevel         = "info"
+	defaultMaxParallelSteps = 4
+	defaultStateDir         = "/var/lib/aks-flex-node"
+	defaultOverallTimeout   = 60 * time.Minute
+	defaultHookTimeout      = 5 * time.Minute
+	defaultArtifactMode     = "0644"
+	defaultAzureCloud       = "AzurePublicCloud"
+
+	defaultStatusInterval      = 1 * time.Minute
+	defaultRemediationInterval = 2 * time.Minute
+	defaultStaleThreshold      = 5 * time.Minute
+
+	defaultRemediationMaxAttempts = 3
+	defaultRemediationWindow      = 1 * time.Hour
+	defaultRemediationBackoff     = 2 * time.Minute
+	defaultRemediationMaxBackoff  = 30 * time.Minute
+
+	defaultSelfUpdateCheckInterval = 6 * time.Hour
+	defaultSelfUpdateHealthTimeout = 5 * time.Minute
 
 	// Environment variable prefix
 	envPrefix = "AKS_NODE_CONTROLLER"
@@ -37,47 +61,64 @@ func GetConfig() *Config {
 // LoadConfig loads configuration from a JSON file and environment variables.
 // The configPath parameter is required and cannot be empty.
 // Environment variables can override config file values using the AKS_NODE_CONTROLLER_ prefix.
-// For example: AKS_NODE_CONTROLLER_AZURE_LOCATION=westus2
+// For example: AKS_NODE_CONTROLLER_AZURE_TARGETCLUSTER_LOCATION=westus2
 func LoadConfig(configPath string) (*Config, error) {
+	config, _, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate the configuration
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("config validation failed: %w", err)
+	}
+
+	populateTargetClusterInfoFromConfig(config)
+
+	// Set the singleton instance
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	configInstance = config
+
+	return config, nil
+}
+
+// readConfig reads the config file, applies environment overrides and defaults without validating.
+// The returned viper instance records which keys came from the file.
+func readConfig(configPath string) (*Config, *viper.Viper, error) {
 	// Require config path to be specified
 	if configPath == "" {
-		return nil, fmt.Errorf("config file path is required")
+		return nil, nil, fmt.Errorf("config file path is required")
 	}
 
-	// Set up viper
+	// Set up viper; every config key can be overridden by its environment variable
 	v := viper.New()
 	v.SetConfigType("json")
-	v.AutomaticEnv()
 	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for _, key := range configKeys(reflect.TypeOf(Config{}), "") {
+		if err := v.BindEnv(key); err != nil {
+			return nil, nil, fmt.Errorf("failed to bind environment variable for %s: %w", key, err)
+		}
+	}
 
 	// Load the specified config file
 	v.SetConfigFile(configPath)
 	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read config file at %s: %w", configPath, err)
+		return nil, nil, fmt.Errorf("failed to read config file at %s: %w", configPath, err)
 	}
 
 	// Unmarshal config
 	config := &Config{}
 	if err := v.Unmarshal(config); err != nil {
-		return nil, fmt.Errorf("error unmarshaling config: %w", err)
+		return nil, nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
 	// Set defaults for any missing values
 	config.SetDefaults()
 
-	// Validate the configuration
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
-	}
-
-	populateTargetClusterInfoFromConfig(config)
-
-	// Set the singleton instance
-	configMutex.Lock()
-	defer configMutex.Unlock()
-	configInstance = config
-
-	return config, nil
+	return config, v, nil
 }
 
 // SetDefaults sets default values for any missing configuration fields
@@ -89,6 +130,9 @@ func (c *Config) SetDefaults() {
 	c.setContainerdDefaults()
 	c.setRuncDefaults()
 	c.setNpdDefaults()
+	c.setHookDefaults()
+	c.setArtifactDefaults()
+	c.setSelfUpdateDefaults()
 }
 
 func (c *Config) setAzureCloudDefaults() {
@@ -106,6 +150,71 @@ func (c *Config) setAgentDefaults() {
 	if c.Agent.LogDir == "" {
 		c.Agent.LogDir = defaultLogDir
 	}
+	if c.Agent.MaxParallelSteps <= 0 {
+		c.Agent.MaxParallelSteps = defaultMaxParallelSteps
+	}
+	if c.Agent.StateDir == "" {
+		c.Agent.StateDir = defaultStateDir
+	}
+	if c.Agent.OverallTimeout == 0 {
+		c.Agent.OverallTimeout = defaultOverallTimeout
+	}
+	if c.Agent.Daemon.StatusInterval <= 0 {
+		c.Agent.Daemon.StatusInterval = defaultStatusInterval
+	}
+	if c.Agent.Daemon.RemediationInterval <= 0 {
+		c.Agent.Daemon.RemediationInterval = defaultRemediationInterval
+	}
+	if c.Agent.Daemon.StaleThreshold <= 0 {
+		c.Agent.Daemon.StaleThreshold = defaultStaleThreshold
+	}
+	if c.Agent.Remediation.MaxAttempts <= 0 {
+		c.Agent.Remediation.MaxAttempts = defaultRemediationMaxAttempts
+	}
+	if c.Agent.Remediation.Window <= 0 {
+		c.Agent.Remediation.Window = defaultRemediationWindow
+	}
+	if c.Agent.Remediation.Backoff <= 0 {
+		c.Agent.Remediation.Backoff = defaultRemediationBackoff
+	}
+	if c.Agent.Remediation.MaxBackoff <= 0 {
+		c.Agent.Remediation.MaxBackoff = defaultRemediationMaxBackoff
+	}
+}
+
+func (c *Config) setHookDefaults() {
+	// Set default timeout and failure policy of every configured hook
+	for _, hookSet := range []map[string][]HookConfig{c.Hooks.PreStep, c.Hooks.PostStep, c.Hooks.Events} {
+		for _, hooks := range hookSet {
+			for i := range hooks {
+				if hooks[i].Timeout == 0 {
+					hooks[i].Timeout = defaultHookTimeout
+				}
+				if hooks[i].Policy == "" {
+					hooks[i].Policy = HookPolicyFail
+				}
+			}
+		}
+	}
+}
+
+func (c *Config) setArtifactDefaults() {
+	// Set default file mode of every configured artifact
+	for i := range c.Artifacts {
+		if c.Artifacts[i].Mode == "" {
+			c.Artifacts[i].Mode = defaultArtifactMode
+		}
+	}
+}
+
+func (c *Config) setSelfUpdateDefaults() {
+	// Set default self-update intervals if not provided
+	if c.SelfUpdate.CheckInterval <= 0 {
+		c.SelfUpdate.CheckInterval = defaultSelfUpdateCheckInterval
+	}
+	if c.SelfUpdate.HealthTimeout <= 0 {
+		c.SelfUpdate.HealthTimeout = defaultSelfUpdateHealthTimeout
+	}
 }
 
 func (c *Config) setPathDefaults() {
@@ -161,6 +270,9 @@ func (c *Config) setNodeDefaults() {
 }
 
 func (c *Config) setContainerdDefaults() {
+	if c.Containerd.Version == "" {
+		c.Containerd.Version = "1.7.20"
+	}
 	if c.Containerd.MetricsAddress == "" {
 		c.Containerd.MetricsAddress = "0.0.0.0:10257"
 	}
@@ -209,43 +321,128 @@ var validAzureClouds = map[string]bool{
 	"AzurePublicCloud": true,
 }
 
-// Validate validates the configuration and ensures all required fields are set
+// artifactNamePattern restricts artifact names to what is safe in step names, file names and unit names
+var artifactNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// artifactUnitNamePattern matches the unit file names artifacts may install: the sudoers prefix followed
+// by characters that cannot smuggle further arguments into the systemctl command line
+var artifactUnitNamePattern = regexp.MustCompile(`^` + regexp.QuoteMeta(ArtifactUnitPrefix) + `[a-z0-9-]+\.(service|socket|timer|path)$`)
+
+// sha256Pattern matches a hex encoded SHA-256 digest
+var sha256Pattern = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+
+// validHookEvents defines the run-level events hooks can be attached to
+var validHookEvents = map[string]bool{
+	"bootstrap-start":     true,
+	"bootstrap-success":   true,
+	"bootstrap-failure":   true,
+	"unbootstrap-start":   true,
+	"unbootstrap-success": true,
+	"unbootstrap-failure": true,
+	"reset-start":         true,
+	"reset-success":       true,
+	"reset-failure":       true,
+}
+
+// Validate validates the configuration and ensures all required fields are set.
+// Every problem found is reported in the returned error.
 func (c *Config) Validate() error {
+	return errors.Join(c.ValidationErrors()...)
+}
+
+// ValidationErrors returns every problem of the configuration, in field order
+func (c *Config) ValidationErrors() []error {
+	var errs []error
+
 	// Validate required Azure configuration (core requirements for Arc discovery)
 	if c.Azure.SubscriptionID == "" {
-		return fmt.Errorf("azure.subscriptionId is required")
+		errs = append(errs, fmt.Errorf("azure.subscriptionId is required"))
 	}
 	if c.Azure.TenantID == "" {
-		return fmt.Errorf("azure.tenantId is required")
-	}
-	if c.Azure.TargetCluster.Location == "" {
-		return fmt.Errorf("azure.targetCluster.location is required")
+		errs = append(errs, fmt.Errorf("azure.tenantId is required"))
 	}
-	if c.Azure.TargetCluster.ResourceID == "" {
-		return fmt.Errorf("azure.targetCluster.resourceId is required")
-	}
-
-	// Validate Azure resource ID format
-	if err := validateAzureResourceID(c.Azure.TargetCluster.ResourceID); err != nil {
-		return fmt.Errorf("invalid azure.targetCluster.resourceId: %w", err)
+	if c.Azure.TargetCluster == nil {
+		errs = append(errs, fmt.Errorf("azure.targetCluster is required"))
+	} else {
+		if c.Azure.TargetCluster.Location == "" {
+			errs = append(errs, fmt.Errorf("azure.targetCluster.location is required"))
+		}
+		if c.Azure.TargetCluster.ResourceID == "" {
+			errs = append(errs, fmt.Errorf("azure.targetCluster.resourceId is required"))
+		} else if err := validateAzureResourceID(c.Azure.TargetCluster.ResourceID); err != nil {
+			// Validate Azure resource ID format
+			errs = append(errs, fmt.Errorf("invalid azure.targetCluster.resourceId: %w", err))
+		}
 	}
 
 	// Validate Azure cloud
 	if !validAzureClouds[c.Azure.Cloud] {
-		return fmt.Errorf("invalid azure.cloud: %s. Valid values are: AzurePublicCloud", c.Azure.Cloud)
+		errs = append(errs, fmt.Errorf("invalid azure.cloud: %s. Valid values are: AzurePublicCloud", c.Azure.Cloud))
 	}
 
 	// Validate log level
 	if !validLogLevels[c.Agent.LogLevel] {
-		return fmt.Errorf("invalid agent.logLevel: %s. Valid values are: debug, info, warning, error", c.Agent.LogLevel)
+		errs = append(errs, fmt.Errorf("invalid agent.logLevel: %s. Valid values are: debug, info, warning, error", c.Agent.LogLevel))
 	}
 
-	return nil
+	// Validate metrics listener address
+	if c.Agent.MetricsAddress != "" {
+		if _, _, err := net.SplitHostPort(c.Agent.MetricsAddress); err != nil {
+			errs = append(errs, fmt.Errorf("invalid agent.metricsAddress: %w", err))
+		}
+	}
+
+	errs = append(errs, c.daemonErrors()...)
+	errs = append(errs, c.remediationErrors()...)
+
+	errs = append(errs, c.hookErrors()...)
+	errs = append(errs, c.artifactErrors()...)
+	return append(errs, c.selfUpdateErrors()...)
+}
+
+// hookErrors returns every problem of the hooks section
+func (c *Config) hookErrors() []error {
+	var errs []error
+	for event := range c.Hooks.Events {
+		if !validHookEvents[strings.ToLower(event)] {
+			errs = append(errs, fmt.Errorf("invalid hooks.events key: %s. Valid values are: bootstrap-start, bootstrap-success, "+
+				"bootstrap-failure, unbootstrap-start, unbootstrap-success, unbootstrap-failure, reset-start, reset-success, reset-failure", event))
+		}
+	}
+
+	sections := []struct {
+		name  string
+		hooks map[string][]HookConfig
+	}{
+		{"preStep", c.Hooks.PreStep},
+		{"postStep", c.Hooks.PostStep},
+		{"events", c.Hooks.Events},
+	}
+	for _, section := range sections {
+		for key, hooks := range section.hooks {
+			for i, hook := range hooks {
+				field := fmt.Sprintf("hooks.%s.%s[%d]", section.name, key, i)
+				if hook.Command == "" {
+					errs = append(errs, fmt.Errorf("%s.command is required", field))
+				}
+				if hook.Policy != "" && hook.Policy != HookPolicyFail && hook.Policy != HookPolicyWarn {
+					errs = append(errs, fmt.Errorf("invalid %s.policy: %s. Valid values are: fail, warn", field, hook.Policy))
+				}
+				if hook.Timeout < 0 {
+					errs = append(errs, fmt.Errorf("invalid %s.timeout: %s must not be negative", field, hook.Timeout))
+				}
+			}
+		}
+	}
+	return errs
 }
 
 // populateTargetClusterInfoFromConfig extracts cluster information from the resource ID
 // This function should only be called after validateAzureResourceID confirms the format is correct
 func populateTargetClusterInfoFromConfig(cfg *Config) {
+	if cfg.Azure.TargetCluster == nil {
+		return
+	}
 	matches := AKSClusterResourceIDPattern.FindStringSubmatch(cfg.Azure.TargetCluster.ResourceID)
 	if len(matches) < 4 {
 		// This should not happen if validation occurred first, but handle gracefully
@@ -267,3 +464,94 @@ func populateTargetClusterInfoFromConfig(cfg *Config) {
 	cfg.Azure.TargetCluster.SubscriptionID = subscriptionID
 	cfg.Azure.TargetCluster.NodeResourceGroup = mcResourceGroup
 }
+
+// daemonErrors returns every problem of the agent.daemon section
+func (c *Config) daemonErrors() []error {
+	var errs []error
+	daemon := c.Agent.Daemon
+	if daemon.Jitter < 0 {
+		errs = append(errs, fmt.Errorf("invalid agent.daemon.jitter: %s must not be negative", daemon.Jitter))
+	}
+	if daemon.InitialDelay < 0 {
+		errs = append(errs, fmt.Errorf("invalid agent.daemon.initialDelay: %s must not be negative", daemon.InitialDelay))
+	}
+	// Status is refreshed once per interval plus jitter; a shorter threshold would report it stale between collections
+	if daemon.StaleThreshold > 0 && daemon.StaleThreshold <= daemon.StatusInterval+daemon.Jitter {
+		errs = append(errs, fmt.Errorf("invalid agent.daemon.staleThreshold: %s must exceed statusInterval plus jitter (%s)",
+			daemon.StaleThreshold, daemon.StatusInterval+daemon.Jitter))
+	}
+	return errs
+}
+
+// remediationErrors returns every problem of the agent.remediation section
+func (c *Config) remediationErrors() []error {
+	var errs []error
+	remediation := c.Agent.Remediation
+	if remediation.MaxBackoff > 0 && remediation.MaxBackoff < remediation.Backoff {
+		errs = append(errs, fmt.Errorf("invalid agent.remediation.maxBackoff: %s must not be shorter than backoff (%s)",
+			remediation.MaxBackoff, remediation.Backoff))
+	}
+	return errs
+}
+
+// selfUpdateErrors returns every problem of the selfUpdate section
+func (c *Config) selfUpdateErrors() []error {
+	var errs []error
+	manifestURL := c.SelfUpdate.ManifestURL
+	if manifestURL != "" && !strings.HasPrefix(manifestURL, "https://") && !strings.HasPrefix(manifestURL, "http://") {
+		errs = append(errs, fmt.Errorf("invalid selfUpdate.manifestUrl: %s must be an http or https URL", manifestURL))
+	}
+	if c.SelfUpdate.Auto && manifestURL == "" {
+		errs = append(errs, fmt.Errorf("selfUpdate.manifestUrl is required when selfUpdate.auto is set"))
+	}
+	if c.SelfUpdate.PublicKey != "" && !filepath.IsAbs(c.SelfUpdate.PublicKey) {
+		errs = append(errs, fmt.Errorf("invalid selfUpdate.publicKey: %q must be an absolute path", c.SelfUpdate.PublicKey))
+	}
+	return errs
+}
+
+// artifactErrors returns every problem of the artifacts section
+func (c *Config) artifactErrors() []error {
+	var errs []error
+	seen := make(map[string]bool, len(c.Artifacts))
+	for i, artifact := range c.Artifacts {
+		field := fmt.Sprintf("artifacts[%d]", i)
+		if !artifactNamePattern.MatchString(artifact.Name) {
+			errs = append(errs, fmt.Errorf("invalid %s.name: %q must consist of lowercase letters, digits and hyphens", field, artifact.Name))
+		} else {
+			if seen[artifact.Name] {
+				errs = append(errs, fmt.Errorf("duplicate artifact name: %s", artifact.Name))
+			}
+			seen[artifact.Name] = true
+			field = fmt.Sprintf("artifacts[%s]", artifact.Name)
+		}
+
+		if (artifact.URL == "") == (artifact.Path == "") {
+			errs = append(errs, fmt.Errorf("%s must set exactly one of url and path", field))
+		}
+		if artifact.URL != "" && !strings.HasPrefix(artifact.URL, "https://") && !strings.HasPrefix(artifact.URL, "http://") {
+			errs = append(errs, fmt.Errorf("invalid %s.url: %s must be an http or https URL", field, artifact.URL))
+		}
+		if artifact.SHA256 != "" && !sha256Pattern.MatchString(artifact.SHA256) {
+			errs = append(errs, fmt.Errorf("invalid %s.sha256: must be 64 hexadecimal characters", field))
+		}
+		if !filepath.IsAbs(artifact.Target) {
+			errs = append(errs, fmt.Errorf("invalid %s.target: %q must be an absolute path", field, artifact.Target))
+		}
+		if artifact.Mode != "" {
+			if _, err := strconv.ParseUint(artifact.Mode, 8, 32); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s.mode: %q is not an octal file mode", field, artifact.Mode))
+			}
+		}
+		if artifact.Unit != nil {
+			if !artifactUnitNamePattern.MatchString(artifact.Unit.Name) {
+				errs = append(errs, fmt.Errorf("invalid %s.unit.name: %q must be a unit file name such as %s%s.service",
+					field, artifact.Unit.Name, ArtifactUnitPrefix, artifact.Name))
+			}
+			if artifact.Unit.Content == "" {
+				errs = append(errs, fmt.Errorf("%s.unit.content is required", field))
+			}
+		}
+	}
+	return errs
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestSetDefaults(t *testing.T) {
@@ -20,9 +22,21 @@ func TestSetDefaults(t *testing.T) {
 				return c.Azure.Cloud == "AzurePublicCloud" &&
 					c.Agent.LogLevel == "info" &&
 					c.Agent.LogDir == "/var/log/aks-flex-node" &&
+					c.Agent.MaxParallelSteps == 4 &&
+					c.Agent.StateDir == "/var/lib/aks-flex-node" &&
+					c.Agent.OverallTimeout == 60*time.Minute &&
 					c.Paths.Kubernetes.ConfigDir == "/etc/kubernetes" &&
 					c.Node.MaxPods == 110 &&
-					c.Runc.Version == "1.1.12"
+					c.Runc.Version == "1.1.12" &&
+					c.Agent.Daemon.StatusInterval == time.Minute &&
+					c.Agent.Daemon.RemediationInterval == 2*time.Minute &&
+					c.Agent.Daemon.StaleThreshold == 5*time.Minute &&
+					c.Agent.Remediation.MaxAttempts == 3 &&
+					c.Agent.Remediation.Window == time.Hour &&
+					c.Agent.Remediation.Backoff == 2*time.Minute &&
+					c.Agent.Remediation.MaxBackoff == 30*time.Minute &&
+					c.SelfUpdate.CheckInterval == 6*time.Hour &&
+					c.SelfUpdate.HealthTimeout == 5*time.Minute
 			},
 		},
 		{
@@ -32,13 +46,15 @@ func TestSetDefaults(t *testing.T) {
 					Cloud: "AzurePublicCloud",
 				},
 				Agent: AgentConfig{
-					LogLevel: "debug",
-					LogDir:   "/custom/log/dir",
+					LogLevel:         "debug",
+					LogDir:           "/custom/log/dir",
+					MaxParallelSteps: 2,
 				},
 			},
 			want: func(c *Config) bool {
 				return c.Agent.LogLevel == "debug" &&
-					c.Agent.LogDir == "/custom/log/dir"
+					c.Agent.LogDir == "/custom/log/dir" &&
+					c.Agent.MaxParallelSteps == 2
 			},
 		},
 		{
@@ -205,6 +221,139 @@ func TestValidate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid agent.logLevel: invalid. Valid values are: debug, info, warning, error",
 		},
+		{
+			name: "stale threshold within the status interval fails",
+			config: &Config{
+				Azure: AzureConfig{
+					SubscriptionID: "12345678-1234-1234-1234-123456789012",
+					TenantID:       "12345678-1234-1234-1234-123456789012",
+					Cloud:          "AzurePublicCloud",
+					TargetCluster: &TargetClusterConfig{
+						ResourceID: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+						Location:   "eastus",
+					},
+				},
+				Agent: AgentConfig{
+					LogLevel: "info",
+					Daemon: DaemonConfig{
+						StatusInterval: 5 * time.Minute,
+						StaleThreshold: 5 * time.Minute,
+						Jitter:         time.Minute,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid agent.daemon.staleThreshold: 5m0s must exceed statusInterval plus jitter (6m0s)",
+		},
+		{
+			name: "metrics address without port fails",
+			config: &Config{
+				Azure: AzureConfig{
+					SubscriptionID: "12345678-1234-1234-1234-123456789012",
+					TenantID:       "12345678-1234-1234-1234-123456789012",
+					Cloud:          "AzurePublicCloud",
+					TargetCluster: &TargetClusterConfig{
+						ResourceID: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+						Location:   "eastus",
+					},
+				},
+				Agent: AgentConfig{
+					LogLevel:       "info",
+					MetricsAddress: "localhost",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid agent.metricsAddress: address localhost: missing port in address",
+		},
+		{
+			name: "remediation max backoff below backoff fails",
+			config: &Config{
+				Azure: AzureConfig{
+					SubscriptionID: "12345678-1234-1234-1234-123456789012",
+					TenantID:       "12345678-1234-1234-1234-123456789012",
+					Cloud:          "AzurePublicCloud",
+					TargetCluster: &TargetClusterConfig{
+						ResourceID: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+						Location:   "eastus",
+					},
+				},
+				Agent: AgentConfig{
+					LogLevel: "info",
+					Remediation: RemediationConfig{
+						Backoff:    10 * time.Minute,
+						MaxBackoff: 5 * time.Minute,
+					},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid agent.remediation.maxBackoff: 5m0s must not be shorter than backoff (10m0s)",
+		},
+		{
+			name: "unknown hook event fails",
+			config: &Config{
+				Azure: AzureConfig{
+					SubscriptionID: "12345678-1234-1234-1234-123456789012",
+					TenantID:       "12345678-1234-1234-1234-123456789012",
+					Cloud:          "AzurePublicCloud",
+					TargetCluster: &TargetClusterConfig{
+						ResourceID: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+						Location:   "eastus",
+					},
+				},
+				Agent: AgentConfig{
+					LogLevel: "info",
+				},
+				Hooks: HooksConfig{
+					Events: map[string][]HookConfig{"node-ready": {{Command: "/bin/true"}}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid hooks.events key: node-ready",
+		},
+		{
+			name: "hook without command fails",
+			config: &Config{
+				Azure: AzureConfig{
+					SubscriptionID: "12345678-1234-1234-1234-123456789012",
+					TenantID:       "12345678-1234-1234-1234-123456789012",
+					Cloud:          "AzurePublicCloud",
+					TargetCluster: &TargetClusterConfig{
+						ResourceID: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+						Location:   "eastus",
+					},
+				},
+				Agent: AgentConfig{
+					LogLevel: "info",
+				},
+				Hooks: HooksConfig{
+					PreStep: map[string][]HookConfig{"KubeletInstaller": {{Policy: HookPolicyWarn}}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "hooks.preStep.KubeletInstaller[0].command is required",
+		},
+		{
+			name: "invalid hook policy fails",
+			config: &Config{
+				Azure: AzureConfig{
+					SubscriptionID: "12345678-1234-1234-1234-123456789012",
+					TenantID:       "12345678-1234-1234-1234-123456789012",
+					Cloud:          "AzurePublicCloud",
+					TargetCluster: &TargetClusterConfig{
+						ResourceID: "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+						Location:   "eastus",
+					},
+				},
+				Agent: AgentConfig{
+					LogLevel: "info",
+				},
+				Hooks: HooksConfig{
+					PostStep: map[string][]HookConfig{"ServicesInstaller": {{Command: "/bin/true", Policy: "ignore"}}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid hooks.postStep.ServicesInstaller[0].policy: ignore. Valid values are: fail, warn",
+		},
 		{
 			name: "valid arc config passes",
 			config: &Config{
@@ -433,3 +582,237 @@ func TestPopulateTargetClusterInfoFromConfig(t *testing.T) {
 		t.Errorf("Expected ResourceID %s, got %s", expected.ResourceID, config.Azure.TargetCluster.ResourceID)
 	}
 }
+
+// TestConfigHash verifies that the config fingerprint is stable and reflects changes to what bootstrap installs.
+// Test: Hashes identical configs, configs with a different Kubernetes version or artifact, and configs
+// differing only in agent, hook and self-update settings
+// Expected: Identical configs share a hash, install changes get a different one, operational settings do not
+func TestConfigHash(t *testing.T) {
+	newConfig := func() *Config {
+		return &Config{
+			Agent:      AgentConfig{LogLevel: "info"},
+			Kubernetes: KubernetesConfig{Version: "1.32.7"},
+		}
+	}
+
+	a, b := newConfig(), newConfig()
+	if a.Hash() == "" {
+		t.Fatal("Hash should not be empty")
+	}
+	if a.Hash() != b.Hash() {
+		t.Error("Identical configs should have the same hash")
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(c *Config)
+		wantChange bool
+	}{
+		{name: "kubernetes version", modify: func(c *Config) { c.Kubernetes.Version = "1.33.1" }, wantChange: true},
+		{name: "artifact", modify: func(c *Config) { c.Artifacts = []ArtifactConfig{{Name: "cred", Path: "/tmp/cred"}} }, wantChange: true},
+		{name: "log level", modify: func(c *Config) { c.Agent.LogLevel = "debug" }},
+		{name: "daemon schedule", modify: func(c *Config) { c.Agent.Daemon.StatusInterval = time.Minute }},
+		{name: "remediation", modify: func(c *Config) { c.Agent.Remediation.MaxAttempts = 7 }},
+		{name: "hooks", modify: func(c *Config) { c.Hooks.PreStep = map[string][]HookConfig{"kubelet": {{Command: "/bin/true"}}} }},
+		{name: "self-update", modify: func(c *Config) { c.SelfUpdate.Auto = true }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newConfig()
+			tt.modify(c)
+			if changed := c.Hash() != a.Hash(); changed != tt.wantChange {
+				t.Errorf("Expected hash change %t, got %t", tt.wantChange, changed)
+			}
+		})
+	}
+}
+
+// TestAgentConfigStepTimeout verifies per-step timeout lookup.
+// Test: Looks up step names with different casing, a disabled timeout and a missing entry
+// Expected: Names match case-insensitively, explicit zero is reported as set, unknown steps are not
+func TestAgentConfigStepTimeout(t *testing.T) {
+	agent := AgentConfig{StepTimeouts: map[string]time.Duration{
+		"kubeletinstaller": 3 * time.Minute,
+		"ArcInstaller":     0,
+	}}
+
+	tests := []struct {
+		step    string
+		want    time.Duration
+		wantSet bool
+	}{
+		{step: "KubeletInstaller", want: 3 * time.Minute, wantSet: true},
+		{step: "arcinstaller", want: 0, wantSet: true},
+		{step: "CNIInstaller", want: 0, wantSet: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			got, ok := agent.StepTimeout(tt.step)
+			if got != tt.want || ok != tt.wantSet {
+				t.Errorf("StepTimeout(%s) = %v, %v, want %v, %v", tt.step, got, ok, tt.want, tt.wantSet)
+			}
+		})
+	}
+}
+
+// TestArtifactErrors verifies validation of artifact definitions.
+// Test: Validates artifacts with missing or conflicting sources, bad names, targets, modes, checksums and units
+// Expected: Only the well-formed artifacts pass; each malformed one reports the offending field
+func TestArtifactErrors(t *testing.T) {
+	valid := ArtifactConfig{Name: "cred-provider", URL: "https://example.com/cred.tgz", ArchiveMember: "bin/cred", Target: "/usr/local/bin/cred", Mode: "0755"}
+
+	tests := []struct {
+		name   string
+		modify func(a *ArtifactConfig)
+		errMsg string
+	}{
+		{name: "valid artifact passes", modify: func(a *ArtifactConfig) {}},
+		{name: "invalid name", modify: func(a *ArtifactConfig) { a.Name = "Cred Provider" }, errMsg: "artifacts[0].name"},
+		{name: "no source", modify: func(a *ArtifactConfig) { a.URL = "" }, errMsg: "exactly one of url and path"},
+		{name: "two sources", modify: func(a *ArtifactConfig) { a.Path = "/tmp/cred" }, errMsg: "exactly one of url and path"},
+		{name: "non http url", modify: func(a *ArtifactConfig) { a.URL = "ftp://example.com/cred" }, errMsg: ".url"},
+		{name: "relative target", modify: func(a *ArtifactConfig) { a.Target = "bin/cred" }, errMsg: ".target"},
+		{name: "invalid mode", modify: func(a *ArtifactConfig) { a.Mode = "rwx" }, errMsg: ".mode"},
+		{name: "invalid checksum", modify: func(a *ArtifactConfig) { a.SHA256 = "abc" }, errMsg: ".sha256"},
+		{name: "valid unit passes", modify: func(a *ArtifactConfig) {
+			a.Unit = &ArtifactUnitConfig{Name: "aks-flex-node-artifact-cred.service", Content: "[Service]"}
+		}},
+		{name: "unit without content", modify: func(a *ArtifactConfig) { a.Unit = &ArtifactUnitConfig{Name: "aks-flex-node-artifact-cred.service"} }, errMsg: ".unit.content"},
+		{name: "unit without prefix", modify: func(a *ArtifactConfig) {
+			a.Unit = &ArtifactUnitConfig{Name: "kubelet.service", Content: "[Service]"}
+		}, errMsg: ".unit.name"},
+		{name: "unit name with extra arguments", modify: func(a *ArtifactConfig) {
+			a.Unit = &ArtifactUnitConfig{Name: "aks-flex-node-artifact-cred.service kubelet.service", Content: "[Service]"}
+		}, errMsg: ".unit.name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			artifact := valid
+			tt.modify(&artifact)
+			cfg := &Config{Artifacts: []ArtifactConfig{artifact}}
+
+			err := errors.Join(cfg.artifactErrors()...)
+			if tt.errMsg == "" {
+				if err != nil {
+					t.Errorf("Expected artifact to be valid, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+
+	duplicate := &Config{Artifacts: []ArtifactConfig{valid, valid}}
+	if err := errors.Join(duplicate.artifactErrors()...); err == nil || !strings.Contains(err.Error(), "duplicate artifact name") {
+		t.Errorf("Expected duplicate artifact names to be rejected, got %v", err)
+	}
+}
+
+// TestValidationErrors verifies that validation reports every problem at once.
+// Test: Validates a config missing the subscription, with a bad log level, negative daemon jitter, an invalid
+// artifact and automatic self-update without a manifest
+// Expected: All problems are returned individually and joined by Validate
+func TestValidationErrors(t *testing.T) {
+	cfg := &Config{
+		Azure: AzureConfig{
+			TenantID: "12345678-1234-1234-1234-123456789012",
+			Cloud:    "AzurePublicCloud",
+		},
+		Agent:      AgentConfig{LogLevel: "verbose", Daemon: DaemonConfig{Jitter: -time.Second}},
+		Artifacts:  []ArtifactConfig{{Name: "tool", URL: "ftp://example.com/tool", Target: "tool"}},
+		SelfUpdate: SelfUpdateConfig{Auto: true},
+	}
+
+	errs := cfg.ValidationErrors()
+	want := []string{
+		"azure.subscriptionId is required",
+		"azure.targetCluster is required",
+		"invalid agent.logLevel: verbose",
+		"invalid agent.daemon.jitter",
+		"invalid artifacts[tool].url",
+		"invalid artifacts[tool].target",
+		"selfUpdate.manifestUrl is required",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+	for i, msg := range want {
+		if !strings.Contains(errs[i].Error(), msg) {
+			t.Errorf("Expected error %d to contain %q, got %v", i, msg, errs[i])
+		}
+	}
+
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), want[0]) || !strings.Contains(err.Error(), want[5]) {
+		t.Errorf("Expected Validate to join all errors, got %v", err)
+	}
+}
+
+// TestInspect verifies the effective configuration and the origin of its values.
+// Test: Inspects a config file with a service principal, an environment override and an invalid log level
+// Expected: Values are annotated as file, env, default or derived; the secret is redacted and
+// validation errors are collected instead of failing
+func TestInspect(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.json")
+	configJSON := `{
+		"azure": {
+			"subscriptionId": "12345678-1234-1234-1234-123456789012",
+			"tenantId": "12345678-1234-1234-1234-123456789012",
+			"servicePrincipal": {"tenantId": "t", "clientId": "c", "clientSecret": "s3cret"},
+			"targetCluster": {
+				"resourceId": "/subscriptions/12345678-1234-1234-1234-123456789012/resourceGroups/test-rg/providers/Microsoft.ContainerService/managedClusters/test-cluster",
+				"location": "eastus"
+			}
+		},
+		"agent": {"logLevel": "verbose"}
+	}`
+	if err := os.WriteFile(configFile, []byte(configJSON), 0o600); err != nil {
+		t.Fatalf("Failed to write test config file: %v", err)
+	}
+	t.Setenv("AKS_NODE_CONTROLLER_AZURE_TARGETCLUSTER_LOCATION", "westus2")
+	t.Setenv("AKS_NODE_CONTROLLER_AGENT_MAXPARALLELSTEPS", "2")
+
+	inspection, err := Inspect(configFile)
+	if err != nil {
+		t.Fatalf("Inspect failed: %v", err)
+	}
+	if len(inspection.Errors) != 1 || !strings.Contains(inspection.Errors[0].Error(), "agent.logLevel") {
+		t.Errorf("Expected only the log level error, got %v", inspection.Errors)
+	}
+
+	values := make(map[string]Value, len(inspection.Values))
+	for _, value := range inspection.Values {
+		values[value.Key] = value
+	}
+	tests := []struct {
+		key        string
+		wantValue  interface{}
+		wantSource Source
+	}{
+		{key: "azure.tenantId", wantValue: "12345678-1234-1234-1234-123456789012", wantSource: SourceFile},
+		{key: "azure.servicePrincipal.clientSecret", wantValue: redactedValue, wantSource: SourceFile},
+		{key: "azure.targetCluster.location", wantValue: "westus2", wantSource: SourceEnv},
+		{key: "agent.maxParallelSteps", wantValue: 2, wantSource: SourceEnv},
+		{key: "agent.stateDir", wantValue: defaultStateDir, wantSource: SourceDefault},
+		{key: "agent.overallTimeout", wantValue: "1h0m0s", wantSource: SourceDefault},
+		{key: "azure.targetCluster.name", wantValue: "test-cluster", wantSource: SourceDerived},
+		{key: "azure.targetCluster.nodeResourceGroup", wantValue: "MC_test-rg_test-cluster_westus2", wantSource: SourceDerived},
+	}
+	for _, tt := range tests {
+		got, ok := values[tt.key]
+		if !ok {
+			t.Errorf("Expected value for %s", tt.key)
+			continue
+		}
+		if got.Value != tt.wantValue || got.Source != tt.wantSource {
+			t.Errorf("%s = %v (%s), want %v (%s)", tt.key, got.Value, got.Source, tt.wantValue, tt.wantSource)
+		}
+	}
+
+	if GetConfig() == inspection.Config {
+		t.Error("Inspect should not replace the loaded configuration")
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/viper"
+)
+
+// Source tells where an effective configuration value came from
+type Source string
+
+const (
+	SourceFile    Source = "file"    // set in the config file
+	SourceEnv     Source = "env"     // overridden by an AKS_NODE_CONTROLLER_ environment variable
+	SourceDefault Source = "default" // filled in by SetDefaults or left at its zero value
+	SourceDerived Source = "derived" // computed from other values, such as the target cluster name
+)
+
+// redactedValue replaces secrets in inspected configuration
+const redactedValue = "<redacted>"
+
+// sensitiveKeys are configuration keys whose values are never shown
+var sensitiveKeys = map[string]bool{
+	"azure.servicePrincipal.clientSecret": true,
+}
+
+// Value is a single effective configuration value and where it came from
+type Value struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source Source      `json:"source"`
+}
+
+// Inspection is the effective configuration of a config file with the origin of every value
+type Inspection struct {
+	Config *Config `json:"-"`
+	Values []Value `json:"values"`
+	Errors []error `json:"-"`
+}
+
+// Inspect loads a config file the way LoadConfig does, including environment overrides, defaults and
+// derived fields, but collects validation errors instead of failing on them and does not replace the
+// loaded configuration. Secrets are redacted in the returned values.
+func Inspect(configPath string) (*Inspection, error) {
+	config, v, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection := &Inspection{
+		Config: config,
+		Errors: config.ValidationErrors(),
+	}
+	populateTargetClusterInfoFromConfig(config)
+	collectValues(v, reflect.ValueOf(config).Elem(), "", "", "", false, &inspection.Values)
+	return inspection, nil
+}
+
+// configKeys returns the viper key of every configurable value of a struct type.
+// Nested structs are expanded; maps, slices and fields without a json tag are not.
+func configKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged := jsonName(field)
+		if !field.IsExported() || !tagged {
+			continue
+		}
+
+		key := prefix + strings.ToLower(name)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			keys = append(keys, configKeys(fieldType, key+".")...)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// collectValues flattens value into dotted keys. sourceKey, when set, is the viper key that decides the
+// source of everything below it, which is needed for list elements that viper cannot address.
+func collectValues(v *viper.Viper, value reflect.Value, key, viperKey, sourceKey string, derived bool, values *[]Value) {
+	switch {
+	case value.Kind() == reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, tagged := jsonName(field)
+			collectValues(v, value.Field(i), joinKey(key, name), joinKey(viperKey, strings.ToLower(name)),
+				sourceKey, derived || !tagged, values)
+		}
+		return
+
+	case value.Kind() == reflect.Pointer && !value.IsNil():
+		collectValues(v, value.Elem(), key, viperKey, sourceKey, derived, values)
+		return
+
+	case value.Kind() == reflect.Slice && value.Len() > 0 && isStruct(value.Type().Elem()):
+		if sourceKey == "" {
+			sourceKey = viperKey
+		}
+		for i := 0; i < value.Len(); i++ {
+			collectValues(v, value.Index(i), fmt.Sprintf("%s[%d]", key, i), viperKey, sourceKey, derived, values)
+		}
+		return
+	}
+
+	if sourceKey == "" {
+		sourceKey = viperKey
+	}
+	*values = append(*values, Value{
+		Key:    key,
+		Value:  redact(key, leafValue(value)),
+		Source: valueSource(v, sourceKey, derived),
+	})
+}
+
+// valueSource applies viper's precedence: environment over config file over defaults
+func valueSource(v *viper.Viper, viperKey string, derived bool) Source {
+	switch {
+	case derived:
+		return SourceDerived
+	case os.Getenv(envPrefix+"_"+strings.ToUpper(strings.ReplaceAll(viperKey, ".", "_"))) != "":
+		return SourceEnv
+	case v.InConfig(viperKey):
+		return SourceFile
+	default:
+		return SourceDefault
+	}
+}
+
+// leafValue converts a value to what is displayed for it
+func leafValue(value reflect.Value) interface{} {
+	if (value.Kind() == reflect.Pointer || value.Kind() == reflect.Map || value.Kind() == reflect.Slice) && value.IsNil() {
+		return nil
+	}
+	if duration, ok := value.Interface().(time.Duration); ok {
+		return duration.String()
+	}
+	return value.Interface()
+}
+
+// redact hides secrets and the query string of URLs, which often carries SAS tokens
+func redact(key string, value interface{}) interface{} {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return value
+	}
+	if sensitiveKeys[key] {
+		return redactedValue
+	}
+	if strings.HasSuffix(strings.ToLower(key), "url") {
+		if i := strings.Index(s, "?"); i >= 0 {
+			return s[:i+1] + redactedValue
+		}
+	}
+	return s
+}
+
+// jsonName returns the json name of a struct field and whether it has a json tag.
+// Untagged fields are named after the field with a lowercase first letter.
+func jsonName(field reflect.StructField) (string, bool) {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name, true
+	}
+	runes := []rune(field.Name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes), false
+}
+
+func joinKey(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func isStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
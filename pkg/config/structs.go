@@ -1,6 +1,14 @@
 package config
 
-import "os"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
 
 // Config represents the complete agent configuration structure.
 // It contains Azure-specific settings and agent operational settings.
@@ -14,6 +22,10 @@ type Config struct {
 	Node       NodeConfig       `json:"node"`
 	Paths      PathsConfig      `json:"paths"`
 	Npd        NPDConfig        `json:"npd"`
+	Hooks      HooksConfig      `json:"hooks"`
+	Artifacts  []ArtifactConfig `json:"artifacts"`
+	Preflight  PreflightConfig  `json:"preflight"`
+	SelfUpdate SelfUpdateConfig `json:"selfUpdate"`
 }
 
 // AzureConfig holds Azure-specific configuration required for connecting to Azure services.
@@ -55,8 +67,53 @@ type ArcConfig struct {
 
 // AgentConfig holds agent-specific operational configuration.
 type AgentConfig struct {
-	LogLevel string `json:"logLevel"` // Logging level: debug, info, warning, error
-	LogDir   string `json:"logDir"`   // Directory for log files
+	LogLevel          string `json:"logLevel"`          // Logging level: debug, info, warning, error
+	LogDir            string `json:"logDir"`            // Directory for log files
+	MaxParallelSteps  int    `json:"maxParallelSteps"`  // Maximum number of independent steps executed concurrently
+	StateDir          string `json:"stateDir"`          // Directory for persistent agent state such as the bootstrap journal
+	RollbackOnFailure bool   `json:"rollbackOnFailure"` // Undo the steps a failed bootstrap run changed
+	AutoUpgrade       bool   `json:"autoUpgrade"`       // Let the daemon upgrade components whose installed version differs from the configured one
+	AdminSocket       string `json:"adminSocket"`       // Unix socket of the daemon's admin API; defaults to admin.sock next to the status file
+	MetricsAddress    string `json:"metricsAddress"`    // TCP address of the daemon's Prometheus metrics listener, such as :9810; empty disables it
+
+	// StepTimeouts overrides the built-in timeout of individual steps by step name; 0 disables the timeout
+	StepTimeouts map[string]time.Duration `json:"stepTimeouts"`
+	// OverallTimeout bounds a complete bootstrap or unbootstrap run; a negative value disables the deadline
+	OverallTimeout time.Duration `json:"overallTimeout"`
+
+	// Daemon controls how often the daemon collects status and remediates the node
+	Daemon DaemonConfig `json:"daemon"`
+
+	// Remediation limits how often the daemon re-bootstraps or repairs a node that keeps breaking
+	Remediation RemediationConfig `json:"remediation"`
+}
+
+// DaemonConfig holds the schedule of the agent daemon.
+type DaemonConfig struct {
+	StatusInterval      time.Duration `json:"statusInterval"`      // How often node status is collected
+	RemediationInterval time.Duration `json:"remediationInterval"` // How often the node is checked and re-bootstrapped if needed
+	StaleThreshold      time.Duration `json:"staleThreshold"`      // Age after which collected status no longer reflects the node
+	Jitter              time.Duration `json:"jitter"`              // Maximum random delay added to every interval, spreading a fleet's checks
+	InitialDelay        time.Duration `json:"initialDelay"`        // Wait after daemon start before the first collection and check
+}
+
+// RemediationConfig is the circuit breaker policy of automatic remediation.
+type RemediationConfig struct {
+	MaxAttempts int           `json:"maxAttempts"` // Attempts allowed within window before automatic remediation stops
+	Window      time.Duration `json:"window"`      // Period over which attempts are counted
+	Backoff     time.Duration `json:"backoff"`     // Wait after a failed attempt, doubled for every further consecutive failure
+	MaxBackoff  time.Duration `json:"maxBackoff"`  // Upper bound of the wait between failed attempts
+}
+
+// StepTimeout returns the configured timeout for the named step and whether one is set.
+// Step names are matched case-insensitively because configuration keys are not case preserving.
+func (a AgentConfig) StepTimeout(stepName string) (time.Duration, bool) {
+	for name, timeout := range a.StepTimeouts {
+		if strings.EqualFold(name, stepName) {
+			return timeout, true
+		}
+	}
+	return 0, false
 }
 
 // KubernetesConfig holds configuration settings for Kubernetes components.
@@ -118,6 +175,102 @@ type NPDConfig struct {
 	Version string `json:"version"`
 }
 
+// ArtifactConfig describes an extra file installed on the node, such as a binary, tool or certificate.
+// Exactly one of URL and Path must be set.
+type ArtifactConfig struct {
+	Name          string              `json:"name"`          // Unique name, used in step and component names
+	URL           string              `json:"url"`           // HTTP(S) location to download the artifact from
+	Path          string              `json:"path"`          // Local file to copy the artifact from
+	SHA256        string              `json:"sha256"`        // Optional hex SHA-256 of the downloaded or copied file
+	ArchiveMember string              `json:"archiveMember"` // Optional file inside a tar or tar.gz source to install
+	Target        string              `json:"target"`        // Absolute path the artifact is installed to
+	Mode          string              `json:"mode"`          // Octal file mode (defaults to 0644)
+	Owner         string              `json:"owner"`         // Optional owner as user or user:group
+	Unit          *ArtifactUnitConfig `json:"unit"`          // Optional systemd unit installed and started with the artifact
+}
+
+// ArtifactUnitPrefix starts the name of every artifact unit, as the sudoers rules only let the agent
+// manage units with this prefix
+const ArtifactUnitPrefix = "aks-flex-node-artifact-"
+
+// ArtifactUnitConfig describes a systemd unit that ships with an artifact.
+type ArtifactUnitConfig struct {
+	Name    string `json:"name"`    // Unit file name, e.g. aks-flex-node-artifact-credential-helper.service
+	Content string `json:"content"` // Unit file content
+}
+
+// Hook failure policies
+const (
+	HookPolicyFail = "fail" // a failing hook fails the step or run it belongs to
+	HookPolicyWarn = "warn" // a failing hook is logged and recorded only
+)
+
+// PreflightConfig controls the host checks run by the doctor command and before installation steps.
+type PreflightConfig struct {
+	SkipChecks []string `json:"skipChecks"` // Names of preflight checks to skip, such as swap or ports
+}
+
+// SkipsCheck reports whether the named preflight check is disabled
+func (p PreflightConfig) SkipsCheck(name string) bool {
+	for _, skipped := range p.SkipChecks {
+		if strings.EqualFold(skipped, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfUpdateConfig controls updating the agent binary from a release manifest.
+type SelfUpdateConfig struct {
+	ManifestURL   string        `json:"manifestUrl"`   // HTTP(S) location of the release manifest
+	PublicKey     string        `json:"publicKey"`     // Optional PEM file with the Ed25519 key release binaries must be signed with
+	Auto          bool          `json:"auto"`          // Let the daemon install a release whose version differs from the running one
+	CheckInterval time.Duration `json:"checkInterval"` // How often the daemon fetches the manifest when auto is set
+	HealthTimeout time.Duration `json:"healthTimeout"` // Time the restarted agent has to report healthy before it is rolled back
+}
+
+// HooksConfig holds user-defined executables run around bootstrap and unbootstrap.
+// Step hooks are keyed by step name, event hooks by event name such as bootstrap-start.
+type HooksConfig struct {
+	PreStep  map[string][]HookConfig `json:"preStep"`  // Run before the named step executes
+	PostStep map[string][]HookConfig `json:"postStep"` // Run after the named step, with its result on stdin
+	Events   map[string][]HookConfig `json:"events"`   // Run on run-level events, with the run result on stdin
+}
+
+// HookConfig describes a single hook executable.
+type HookConfig struct {
+	Command string        `json:"command"` // Path of the executable
+	Args    []string      `json:"args"`    // Arguments passed to the executable
+	Timeout time.Duration `json:"timeout"` // Maximum run time of the hook (defaults to 5m)
+	Policy  string        `json:"policy"`  // fail or warn (defaults to fail)
+}
+
+// PreStepHooks returns the hooks to run before the named step
+func (h HooksConfig) PreStepHooks(stepName string) []HookConfig {
+	return lookupHooks(h.PreStep, stepName)
+}
+
+// PostStepHooks returns the hooks to run after the named step
+func (h HooksConfig) PostStepHooks(stepName string) []HookConfig {
+	return lookupHooks(h.PostStep, stepName)
+}
+
+// EventHooks returns the hooks to run on the named event
+func (h HooksConfig) EventHooks(event string) []HookConfig {
+	return lookupHooks(h.Events, event)
+}
+
+// lookupHooks matches keys case-insensitively because configuration keys are not case preserving
+func lookupHooks(hooks map[string][]HookConfig, name string) []HookConfig {
+	var matched []HookConfig
+	for key, keyHooks := range hooks {
+		if strings.EqualFold(key, name) {
+			matched = append(matched, keyHooks...)
+		}
+	}
+	return matched
+}
+
 // IsSPConfigured checks if service principal credentials are provided in the configuration
 func (cfg *Config) IsSPConfigured() bool {
 	return cfg.Azure.ServicePrincipal != nil &&
@@ -217,3 +370,29 @@ func (cfg *Config) GetTenantID() string {
 func (cfg *Config) GetKubernetesVersion() string {
 	return cfg.Kubernetes.Version
 }
+
+// Hash returns a stable SHA-256 fingerprint of the configuration that decides what the bootstrap
+// steps install: the Azure cluster and Arc settings, the node, component versions, paths and artifacts.
+// Agent, hook, preflight and self-update settings are left out, so that changing for example the log
+// level between agent runs does not discard the journal of an interrupted bootstrap.
+func (cfg *Config) Hash() string {
+	desired := struct {
+		Azure      AzureConfig      `json:"azure"`
+		Containerd ContainerdConfig `json:"containerd"`
+		Kubernetes KubernetesConfig `json:"kubernetes"`
+		CNI        CNIConfig        `json:"cni"`
+		Runc       RuntimeConfig    `json:"runc"`
+		Node       NodeConfig       `json:"node"`
+		Paths      PathsConfig      `json:"paths"`
+		Npd        NPDConfig        `json:"npd"`
+		Artifacts  []ArtifactConfig `json:"artifacts"`
+	}{cfg.Azure, cfg.Containerd, cfg.Kubernetes, cfg.CNI, cfg.Runc, cfg.Node, cfg.Paths, cfg.Npd, cfg.Artifacts}
+
+	data, err := json.Marshal(desired)
+	if err != nil {
+		// Fall back to the formatted struct so callers always get a usable fingerprint
+		data = []byte(fmt.Sprintf("%+v", desired))
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,196 @@
+// Package drift compares the state node components install with the state of the machine, so that
+// the daemon can notice and repair local changes such as a hand-edited configuration file.
+package drift
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Kind identifies the type of state an expectation describes
+type Kind string
+
+const (
+	KindFile   Kind = "file"
+	KindBinary Kind = "binary"
+	KindUnit   Kind = "systemd-unit"
+)
+
+// Observed values that are not a content digest or version
+const (
+	Missing = "missing"
+	Unknown = "unknown"
+	Enabled = "enabled"
+)
+
+// Expectation is a single piece of state a component installs
+type Expectation struct {
+	Kind   Kind   `json:"kind"`
+	Target string `json:"target"`
+	// Desired is the SHA-256 of a file's content, the version of a binary or the enablement of a unit
+	Desired string `json:"desired"`
+
+	// versionArgs make a binary print its version
+	versionArgs []string
+}
+
+// Fingerprint is the desired state of a component
+type Fingerprint []Expectation
+
+// File expects path to hold content
+func File(path string, content []byte) Expectation {
+	return Expectation{Kind: KindFile, Target: path, Desired: digest(content)}
+}
+
+// Binary expects the binary at path to print version when run with versionArgs
+func Binary(path, version string, versionArgs ...string) Expectation {
+	return Expectation{Kind: KindBinary, Target: path, Desired: version, versionArgs: versionArgs}
+}
+
+// UnitEnabled expects the systemd unit to be enabled
+func UnitEnabled(name string) Expectation {
+	return Expectation{Kind: KindUnit, Target: name, Desired: Enabled}
+}
+
+// Hash returns a SHA-256 digest of the fingerprint that changes whenever the desired state does
+func (f Fingerprint) Hash() string {
+	var builder strings.Builder
+	for _, expectation := range f {
+		fmt.Fprintf(&builder, "%s\t%s\t%s\n", expectation.Kind, expectation.Target, expectation.Desired)
+	}
+	return digest([]byte(builder.String()))
+}
+
+// Difference is an expectation the machine does not meet
+type Difference struct {
+	Kind    Kind   `json:"kind"`
+	Target  string `json:"target"`
+	Desired string `json:"desired"`
+	Actual  string `json:"actual"`
+}
+
+// String describes the difference for logs and status output
+func (d Difference) String() string {
+	if d.Actual == Missing {
+		return fmt.Sprintf("%s is missing", d.Target)
+	}
+	switch d.Kind {
+	case KindFile:
+		return fmt.Sprintf("%s was modified", d.Target)
+	case KindBinary:
+		return fmt.Sprintf("%s reports %q, want version %s", d.Target, d.Actual, d.Desired)
+	default:
+		return fmt.Sprintf("%s is %s, want %s", d.Target, d.Actual, d.Desired)
+	}
+}
+
+// Component is the drift of a single node component from its desired state
+type Component struct {
+	Name        string       `json:"name"`
+	Fingerprint string       `json:"fingerprint"`
+	Differences []Difference `json:"differences"`
+}
+
+// Repairable reports whether re-running the component repairs some of its drift. Binary versions
+// are left to the upgrade command, which drains the node before replacing them.
+func (c Component) Repairable() bool {
+	for _, difference := range c.Differences {
+		if difference.Kind != KindBinary || difference.Actual == Missing {
+			return true
+		}
+	}
+	return false
+}
+
+// Summary joins the differences of the component into a single line
+func (c Component) Summary() string {
+	descriptions := make([]string, 0, len(c.Differences))
+	for _, difference := range c.Differences {
+		descriptions = append(descriptions, difference.String())
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// Overridable for tests
+var (
+	readFile   = readSystemFile
+	runCommand = utils.RunCommandWithOutput
+)
+
+// Compare returns the expectations of fingerprint that the machine does not meet
+func Compare(fingerprint Fingerprint) []Difference {
+	var differences []Difference
+	for _, expectation := range fingerprint {
+		if actual := observe(expectation); actual != expectation.Desired {
+			differences = append(differences, Difference{
+				Kind:    expectation.Kind,
+				Target:  expectation.Target,
+				Desired: expectation.Desired,
+				Actual:  actual,
+			})
+		}
+	}
+	return differences
+}
+
+// observe returns the actual value of the state an expectation describes
+func observe(expectation Expectation) string {
+	switch expectation.Kind {
+	case KindFile:
+		content, err := readFile(expectation.Target)
+		if errors.Is(err, fs.ErrNotExist) {
+			return Missing
+		}
+		if err != nil {
+			return Unknown
+		}
+		return digest(content)
+	case KindBinary:
+		if _, err := os.Stat(expectation.Target); errors.Is(err, fs.ErrNotExist) {
+			return Missing
+		}
+		output, err := runCommand(expectation.Target, expectation.versionArgs...)
+		if err != nil {
+			return Unknown
+		}
+		if strings.Contains(output, expectation.Desired) {
+			return expectation.Desired
+		}
+		return strings.TrimSpace(strings.SplitN(output, "\n", 2)[0])
+	case KindUnit:
+		// is-enabled exits non-zero for disabled units but still prints their state
+		output, _ := runCommand("systemctl", "is-enabled", expectation.Target)
+		if state := strings.TrimSpace(output); state != "" {
+			return state
+		}
+		return Unknown
+	default:
+		return Unknown
+	}
+}
+
+// readSystemFile reads a file, through sudo when the agent user may not read it directly
+func readSystemFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if !errors.Is(err, fs.ErrPermission) {
+		return content, err
+	}
+	output, err := runCommand("cat", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return []byte(output), nil
+}
+
+// digest returns the hex encoded SHA-256 of content
+func digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
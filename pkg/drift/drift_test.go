@@ -0,0 +1,108 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestCompare verifies that drift of files, binaries and units is detected.
+// Test: Compares expectations against temp files and faked version and systemctl output
+// Expected: Only modified or missing files, other versions and disabled units are reported
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	intact := filepath.Join(dir, "intact.conf")
+	edited := filepath.Join(dir, "edited.conf")
+	binary := filepath.Join(dir, "runc")
+	for path, content := range map[string]string{intact: "a = 1", edited: "a = 2", binary: ""} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	previous := runCommand
+	defer func() { runCommand = previous }()
+	runCommand = func(name string, args ...string) (string, error) {
+		if name == binary {
+			return "runc version 1.1.12\ncommit: v1.1.12-0-g51d5e946\n", nil
+		}
+		if args[len(args)-1] == "kubelet" {
+			return "disabled\n", nil
+		}
+		return "enabled\n", nil
+	}
+
+	fingerprint := Fingerprint{
+		File(intact, []byte("a = 1")),
+		File(edited, []byte("a = 1")),
+		File(filepath.Join(dir, "10-bridge.conf"), []byte("{}")),
+		Binary(binary, "1.1.12", "--version"),
+		Binary(binary, "1.2.0", "--version"),
+		Binary(filepath.Join(dir, "containerd"), "1.7.20", "--version"),
+		UnitEnabled("containerd"),
+		UnitEnabled("kubelet"),
+	}
+
+	var got []string
+	for _, difference := range Compare(fingerprint) {
+		got = append(got, difference.String())
+	}
+	want := []string{
+		edited + " was modified",
+		filepath.Join(dir, "10-bridge.conf") + " is missing",
+		binary + ` reports "runc version 1.1.12", want version 1.2.0`,
+		filepath.Join(dir, "containerd") + " is missing",
+		"kubelet is disabled, want enabled",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Compare() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestRepairable verifies which drift re-running a component repairs.
+// Test: Builds components drifted in binary versions, missing binaries and files
+// Expected: Only a component whose sole drift is a binary version is left to the upgrade command
+func TestRepairable(t *testing.T) {
+	tests := []struct {
+		name        string
+		differences []Difference
+		want        bool
+	}{
+		{name: "version only", differences: []Difference{{Kind: KindBinary, Actual: "1.1.12", Desired: "1.2.0"}}, want: false},
+		{name: "binary missing", differences: []Difference{{Kind: KindBinary, Actual: Missing, Desired: "1.2.0"}}, want: true},
+		{
+			name: "version and file",
+			differences: []Difference{
+				{Kind: KindBinary, Actual: "1.1.12", Desired: "1.2.0"},
+				{Kind: KindFile, Actual: "0123", Desired: "4567"},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := Component{Name: "runc", Differences: tt.differences}
+			if got := component.Repairable(); got != tt.want {
+				t.Errorf("Repairable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFingerprintHash verifies that the hash follows the desired state.
+// Test: Hashes equal fingerprints and one with different file content
+// Expected: Equal fingerprints hash equally, a changed one hashes differently
+func TestFingerprintHash(t *testing.T) {
+	a := Fingerprint{File("/etc/containerd/config.toml", []byte("version = 2")), UnitEnabled("containerd")}
+	b := Fingerprint{File("/etc/containerd/config.toml", []byte("version = 2")), UnitEnabled("containerd")}
+	c := Fingerprint{File("/etc/containerd/config.toml", []byte("version = 3")), UnitEnabled("containerd")}
+
+	if a.Hash() != b.Hash() {
+		t.Error("Expected equal fingerprints to hash equally")
+	}
+	if a.Hash() == c.Hash() {
+		t.Error("Expected a changed fingerprint to hash differently")
+	}
+}
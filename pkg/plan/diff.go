@@ -0,0 +1,68 @@
+package plan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffLines bounds the size of files diffed line by line
+const maxDiffLines = 2000
+
+// Diff returns a line based diff between old and new content.
+// Removed lines are prefixed with "-", added lines with "+" and unchanged lines with a space.
+func Diff(oldContent, newContent string) string {
+	oldLines := splitLines(oldContent)
+	newLines := splitLines(newContent)
+
+	if len(oldLines) > maxDiffLines || len(newLines) > maxDiffLines {
+		return fmt.Sprintf("(diff omitted: %d lines -> %d lines)\n", len(oldLines), len(newLines))
+	}
+
+	// lcs[i][j] holds the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var builder strings.Builder
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			builder.WriteString("  " + oldLines[i] + "\n")
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			builder.WriteString("- " + oldLines[i] + "\n")
+			i++
+		default:
+			builder.WriteString("+ " + newLines[j] + "\n")
+			j++
+		}
+	}
+	for ; i < len(oldLines); i++ {
+		builder.WriteString("- " + oldLines[i] + "\n")
+	}
+	for ; j < len(newLines); j++ {
+		builder.WriteString("+ " + newLines[j] + "\n")
+	}
+
+	return builder.String()
+}
+
+// splitLines splits content into lines, ignoring a single trailing newline
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
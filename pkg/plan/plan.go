@@ -0,0 +1,208 @@
+package plan
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ActionKind identifies the type of change an action describes
+type ActionKind string
+
+const (
+	KindFile           ActionKind = "file"
+	KindDirectory      ActionKind = "directory"
+	KindDownload       ActionKind = "download"
+	KindSystemdUnit    ActionKind = "systemd-unit"
+	KindPackage        ActionKind = "package"
+	KindAzureResource  ActionKind = "azure-resource"
+	KindRoleAssignment ActionKind = "role-assignment"
+	KindCommand        ActionKind = "command"
+)
+
+// Operations an action can perform on its target
+const (
+	OpCreate    = "create"
+	OpUpdate    = "update"
+	OpDelete    = "delete"
+	OpUnchanged = "unchanged"
+	OpLink      = "link"
+	OpInstall   = "install"
+	OpEnable    = "enable"
+	OpRestart   = "restart"
+	OpStop      = "stop"
+	OpDisable   = "disable"
+	OpRun       = "run"
+)
+
+// Action describes a single change an executor would make without performing it
+type Action struct {
+	Kind      ActionKind `json:"kind"`
+	Operation string     `json:"operation"`
+	Target    string     `json:"target"`
+	Source    string     `json:"source,omitempty"`
+	Details   string     `json:"details,omitempty"`
+	Diff      string     `json:"diff,omitempty"`
+}
+
+// WriteFile describes writing content to path, including a diff against the current content
+func WriteFile(path string, content []byte, mode os.FileMode) Action {
+	action := Action{
+		Kind:    KindFile,
+		Target:  path,
+		Details: fmt.Sprintf("mode %04o", mode),
+	}
+
+	current, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		action.Operation = OpCreate
+		action.Diff = Diff("", string(content))
+	case err != nil:
+		action.Operation = OpUpdate
+		action.Details = fmt.Sprintf("%s, current content not readable: %v", action.Details, err)
+	case bytes.Equal(current, content):
+		action.Operation = OpUnchanged
+	default:
+		action.Operation = OpUpdate
+		action.Diff = Diff(string(current), string(content))
+	}
+	return action
+}
+
+// WriteGeneratedFile describes writing a file whose content is only known at execution time
+func WriteGeneratedFile(path, details string) Action {
+	action := Action{
+		Kind:      KindFile,
+		Operation: OpUpdate,
+		Target:    path,
+		Details:   details,
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		action.Operation = OpCreate
+	}
+	return action
+}
+
+// Symlink describes pointing path at source
+func Symlink(path, source string) Action {
+	action := Action{
+		Kind:      KindFile,
+		Operation: OpLink,
+		Target:    path,
+		Source:    source,
+	}
+	if current, err := os.Readlink(path); err == nil && current == source {
+		action.Operation = OpUnchanged
+	}
+	return action
+}
+
+// RemoveFile describes deleting a file
+func RemoveFile(path string) Action {
+	return removal(KindFile, path)
+}
+
+// RemoveDirectory describes deleting a directory and its content
+func RemoveDirectory(path string) Action {
+	return removal(KindDirectory, path)
+}
+
+// removal describes deleting a path, reporting unchanged when it is already absent
+func removal(kind ActionKind, path string) Action {
+	action := Action{
+		Kind:      kind,
+		Operation: OpDelete,
+		Target:    path,
+	}
+	if _, err := os.Lstat(path); errors.Is(err, os.ErrNotExist) {
+		action.Operation = OpUnchanged
+		action.Details = "already absent"
+	}
+	return action
+}
+
+// CreateDirectory describes creating a directory
+func CreateDirectory(path string) Action {
+	action := Action{
+		Kind:      KindDirectory,
+		Operation: OpCreate,
+		Target:    path,
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		action.Operation = OpUnchanged
+	}
+	return action
+}
+
+// Download describes fetching url and installing the result at target
+func Download(url, target string) Action {
+	return Action{
+		Kind:      KindDownload,
+		Operation: OpInstall,
+		Target:    target,
+		Source:    url,
+	}
+}
+
+// Unit describes a systemd unit operation such as enable, restart, stop or disable
+func Unit(name, operation string) Action {
+	return Action{
+		Kind:      KindSystemdUnit,
+		Operation: operation,
+		Target:    name,
+	}
+}
+
+// Package describes apt-installing a package that provides the given binary
+func Package(name, binary string) Action {
+	action := Action{
+		Kind:      KindPackage,
+		Operation: OpInstall,
+		Target:    name,
+		Details:   "apt install -y " + name,
+	}
+	if _, err := exec.LookPath(binary); err == nil {
+		action.Operation = OpUnchanged
+		action.Details = fmt.Sprintf("%s already available", binary)
+	}
+	return action
+}
+
+// AzureResource describes creating or deleting an Azure resource
+func AzureResource(resourceType, name, operation, details string) Action {
+	return Action{
+		Kind:      KindAzureResource,
+		Operation: operation,
+		Target:    fmt.Sprintf("%s/%s", resourceType, name),
+		Details:   details,
+	}
+}
+
+// RoleAssignment describes creating or deleting an Azure RBAC role assignment
+func RoleAssignment(roleName, scope, principal, operation string) Action {
+	return Action{
+		Kind:      KindRoleAssignment,
+		Operation: operation,
+		Target:    scope,
+		Details:   fmt.Sprintf("role %q for %s", roleName, principal),
+	}
+}
+
+// Command describes running a system command
+func Command(name string, args ...string) Action {
+	return Action{
+		Kind:      KindCommand,
+		Operation: OpRun,
+		Target:    strings.TrimSpace(name + " " + strings.Join(args, " ")),
+	}
+}
+
+// WithDetails returns a copy of the action with details set
+func (a Action) WithDetails(details string) Action {
+	a.Details = details
+	return a
+}
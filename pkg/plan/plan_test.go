@@ -0,0 +1,138 @@
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDiff verifies the line based diff used to preview file changes.
+// Test: Diffs identical, added, removed and changed content
+// Expected: Unchanged lines are prefixed with spaces, removals with "-" and additions with "+"
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      string
+		new      string
+		expected string
+	}{
+		{"identical", "a\nb\n", "a\nb\n", "  a\n  b\n"},
+		{"new file", "", "a\nb", "+ a\n+ b\n"},
+		{"removed content", "a\nb", "", "- a\n- b\n"},
+		{"changed line", "a\nb\nc", "a\nx\nc", "  a\n- b\n+ x\n  c\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.old, tt.new); got != tt.expected {
+				t.Errorf("Diff() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestWriteFile verifies that file actions reflect the current state of the target.
+// Test: Plans writes to a missing file, a file with different content and a file with identical content
+// Expected: Operations are create, update and unchanged; only changes carry a diff
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.conf")
+	if err := os.WriteFile(existing, []byte("key = old\n"), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		wantOp   string
+		wantDiff bool
+	}{
+		{"missing file", filepath.Join(dir, "missing.conf"), "key = new\n", OpCreate, true},
+		{"changed file", existing, "key = new\n", OpUpdate, true},
+		{"identical file", existing, "key = old\n", OpUnchanged, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action := WriteFile(tt.path, []byte(tt.content), 0644)
+			if action.Operation != tt.wantOp {
+				t.Errorf("Operation = %s, want %s", action.Operation, tt.wantOp)
+			}
+			if (action.Diff != "") != tt.wantDiff {
+				t.Errorf("Diff = %q, want diff present: %v", action.Diff, tt.wantDiff)
+			}
+		})
+	}
+}
+
+// TestRemoveFile verifies that removing an absent path is reported as unchanged.
+// Test: Plans removal of an existing and a missing file
+// Expected: Existing file is deleted, missing file is unchanged
+func TestRemoveFile(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	if op := RemoveFile(existing).Operation; op != OpDelete {
+		t.Errorf("Expected %s for existing file, got %s", OpDelete, op)
+	}
+	if op := RemoveFile(filepath.Join(dir, "missing")).Operation; op != OpUnchanged {
+		t.Errorf("Expected %s for missing file, got %s", OpUnchanged, op)
+	}
+}
+
+// TestResultOutput verifies the text and JSON renderings of a plan.
+// Test: Renders a plan with a changed step, a skipped step and an unsupported step
+// Expected: Text output lists each step with its actions and diff; JSON output round-trips
+func TestResultOutput(t *testing.T) {
+	result := &Result{
+		Operation: "bootstrap",
+		Steps: []StepPlan{
+			{
+				StepName:  "Writer",
+				Supported: true,
+				Actions: []Action{
+					{Kind: KindFile, Operation: OpCreate, Target: "/etc/example.conf", Diff: "+ key = value\n"},
+				},
+			},
+			{StepName: "Skipped", DependsOn: []string{"Writer"}, SkipReason: "already completed"},
+			{StepName: "Legacy"},
+		},
+	}
+
+	var text bytes.Buffer
+	if err := result.WriteText(&text); err != nil {
+		t.Fatalf("WriteText failed: %v", err)
+	}
+	for _, want := range []string{
+		"Plan for bootstrap (3 steps)",
+		"[1/3] Writer",
+		"create    file            /etc/example.conf",
+		"        + key = value",
+		"[2/3] Skipped (after Writer)",
+		"skipped: already completed",
+		"step does not support planning",
+	} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Expected text output to contain %q, got:\n%s", want, text.String())
+		}
+	}
+
+	var jsonOut bytes.Buffer
+	if err := result.WriteJSON(&jsonOut); err != nil {
+		t.Fatalf("WriteJSON failed: %v", err)
+	}
+	decoded := &Result{}
+	if err := json.Unmarshal(jsonOut.Bytes(), decoded); err != nil {
+		t.Fatalf("Failed to decode JSON output: %v", err)
+	}
+	if len(decoded.Steps) != 3 || decoded.Steps[0].Actions[0].Target != "/etc/example.conf" {
+		t.Errorf("Unexpected decoded plan: %+v", decoded)
+	}
+}
@@ -0,0 +1,80 @@
+package plan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// StepPlan holds the intended actions of a single bootstrap or unbootstrap step
+type StepPlan struct {
+	StepName   string   `json:"step_name"`
+	DependsOn  []string `json:"depends_on,omitempty"`
+	SkipReason string   `json:"skip_reason,omitempty"`
+	Supported  bool     `json:"supported"`
+	Actions    []Action `json:"actions,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// Result is the full plan of a bootstrap or unbootstrap run
+type Result struct {
+	Operation string     `json:"operation"`
+	Steps     []StepPlan `json:"steps"`
+}
+
+// WriteJSON writes the plan as indented JSON
+func (r *Result) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal plan to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// WriteText writes the plan in a human readable form
+func (r *Result) WriteText(w io.Writer) error {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "Plan for %s (%d steps)\n", r.Operation, len(r.Steps))
+	for idx, step := range r.Steps {
+		fmt.Fprintf(&builder, "\n[%d/%d] %s", idx+1, len(r.Steps), step.StepName)
+		if len(step.DependsOn) > 0 {
+			fmt.Fprintf(&builder, " (after %s)", strings.Join(step.DependsOn, ", "))
+		}
+		builder.WriteString("\n")
+
+		switch {
+		case step.SkipReason != "":
+			fmt.Fprintf(&builder, "    skipped: %s\n", step.SkipReason)
+			continue
+		case step.Error != "":
+			fmt.Fprintf(&builder, "    error: %s\n", step.Error)
+			continue
+		case !step.Supported:
+			builder.WriteString("    step does not support planning\n")
+			continue
+		case len(step.Actions) == 0:
+			builder.WriteString("    no changes\n")
+			continue
+		}
+
+		for _, action := range step.Actions {
+			fmt.Fprintf(&builder, "    %-9s %-15s %s", action.Operation, action.Kind, action.Target)
+			if action.Source != "" {
+				fmt.Fprintf(&builder, " <- %s", action.Source)
+			}
+			if action.Details != "" {
+				fmt.Fprintf(&builder, " (%s)", action.Details)
+			}
+			builder.WriteString("\n")
+			for _, line := range splitLines(action.Diff) {
+				fmt.Fprintf(&builder, "        %s\n", line)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
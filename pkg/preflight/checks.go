@@ -0,0 +1,229 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// host gives checks access to the machine so tests can substitute it
+type host struct {
+	readFile  func(path string) ([]byte, error)
+	exists    func(path string) bool
+	freeBytes func(path string) (uint64, error)
+	portFree  func(port int) bool
+	active    func(service string) bool
+	output    func(name string, args ...string) (string, error)
+}
+
+// newHost returns a host backed by the local machine
+func newHost() *host {
+	return &host{
+		readFile: os.ReadFile,
+		exists:   utils.FileExists,
+		freeBytes: func(path string) (uint64, error) {
+			var stat syscall.Statfs_t
+			if err := syscall.Statfs(path, &stat); err != nil {
+				return 0, err
+			}
+			return stat.Bavail * uint64(stat.Bsize), nil
+		},
+		portFree: func(port int) bool {
+			listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+			if err != nil {
+				return false
+			}
+			_ = listener.Close()
+			return true
+		},
+		active: utils.IsServiceActive,
+		output: utils.RunCommandWithOutput,
+	}
+}
+
+// checkSwap fails when any swap device is active because kubelet refuses to start with swap
+func (h *host) checkSwap(_ context.Context) Result {
+	data, err := h.readFile(procSwapsPath)
+	if err != nil {
+		return Result{Status: StatusWarn, Message: fmt.Sprintf("could not read %s: %v", procSwapsPath, err)}
+	}
+
+	var devices []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n")[1:] {
+		if fields := strings.Fields(line); len(fields) > 0 {
+			devices = append(devices, fields[0])
+		}
+	}
+	if len(devices) > 0 {
+		return Result{
+			Status:      StatusFail,
+			Message:     "swap is enabled on " + strings.Join(devices, ", "),
+			Remediation: "Run 'swapoff -a' and remove swap entries from /etc/fstab",
+		}
+	}
+	return Result{Status: StatusPass, Message: "no swap devices are active"}
+}
+
+// checkCgroup warns on cgroup v1 hosts, which kubelet only supports in maintenance mode
+func (h *host) checkCgroup(_ context.Context) Result {
+	if h.exists(cgroupControllersPath) {
+		return Result{Status: StatusPass, Message: "cgroup v2 is in use"}
+	}
+	return Result{
+		Status:      StatusWarn,
+		Message:     "cgroup v1 is in use; kubelet support for cgroup v1 is deprecated",
+		Remediation: "Boot with the kernel parameter systemd.unified_cgroup_hierarchy=1 to switch to cgroup v2",
+	}
+}
+
+// checkKernelModules fails for modules the kernel cannot load and warns for modules bootstrap will load
+func (h *host) checkKernelModules(_ context.Context) Result {
+	var notLoaded, missing []string
+	for _, module := range requiredKernelModules {
+		if h.exists(filepath.Join(sysModuleDir, module)) {
+			continue
+		}
+		// Without modinfo availability is unknown, so leave it to modprobe during bootstrap
+		if _, err := h.output("modinfo", module); err != nil && !errors.Is(err, exec.ErrNotFound) {
+			missing = append(missing, module)
+		} else {
+			notLoaded = append(notLoaded, module)
+		}
+	}
+
+	switch {
+	case len(missing) > 0:
+		return Result{
+			Status:      StatusFail,
+			Message:     "kernel modules not available: " + strings.Join(missing, ", "),
+			Remediation: "Install the extra modules package of the running kernel, e.g. 'apt-get install linux-modules-extra-$(uname -r)'",
+		}
+	case len(notLoaded) > 0:
+		return Result{
+			Status:      StatusWarn,
+			Message:     "kernel modules not loaded yet, bootstrap will load them: " + strings.Join(notLoaded, ", "),
+			Remediation: "Load them now with 'modprobe " + strings.Join(notLoaded, " ") + "'",
+		}
+	}
+	return Result{Status: StatusPass, Message: "kernel modules loaded: " + strings.Join(requiredKernelModules, ", ")}
+}
+
+// checkSystemdResolved warns when systemd-resolved is absent, since kubelet then uses /etc/resolv.conf as is
+func (h *host) checkSystemdResolved(_ context.Context) Result {
+	if h.exists(resolvedResolvConf) {
+		return Result{Status: StatusPass, Message: "systemd-resolved is running"}
+	}
+	return Result{
+		Status:      StatusWarn,
+		Message:     "systemd-resolved is not running; pods will use /etc/resolv.conf directly",
+		Remediation: "Run 'systemctl enable --now systemd-resolved', or make sure /etc/resolv.conf has no loopback nameserver",
+	}
+}
+
+// listenerOwnerPattern extracts the process name from ss -p output such as users:(("kubelet",pid=1,fd=3))
+var listenerOwnerPattern = regexp.MustCompile(`users:\(\("([^"]+)"`)
+
+// checkPorts fails when a node port is taken by a process other than the node component owning it
+func (h *host) checkPorts(_ context.Context) Result {
+	var conflicts, owned []string
+	for _, p := range nodePorts {
+		if h.portFree(p.port) {
+			continue
+		}
+
+		// Process names are only visible to root; otherwise assume an active owner service holds the port
+		owner := "an unknown process"
+		if out, err := h.output("ss", "-Hltnp", fmt.Sprintf("sport = :%d", p.port)); err == nil {
+			if match := listenerOwnerPattern.FindStringSubmatch(out); match != nil {
+				owner = match[1]
+			}
+		}
+		if owner == "an unknown process" && h.active(p.owner) {
+			owner = p.owner
+		}
+		if owner == p.owner {
+			owned = append(owned, fmt.Sprintf("%d (%s)", p.port, owner))
+			continue
+		}
+		conflicts = append(conflicts, fmt.Sprintf("%d is used by %s", p.port, owner))
+	}
+
+	if len(conflicts) > 0 {
+		return Result{
+			Status:      StatusFail,
+			Message:     "ports in use: " + strings.Join(conflicts, ", "),
+			Remediation: "Stop the processes listening on these ports; list them with 'ss -ltnp'",
+		}
+	}
+	if len(owned) > 0 {
+		return Result{Status: StatusPass, Message: "ports already served by node components: " + strings.Join(owned, ", ")}
+	}
+	return Result{Status: StatusPass, Message: "ports are free"}
+}
+
+// checkDiskSpace checks the free space of the filesystem holding containerd and kubelet state
+func (h *host) checkDiskSpace(_ context.Context) Result {
+	free, err := h.freeBytes(varLibDir)
+	if err != nil {
+		return Result{Status: StatusWarn, Message: fmt.Sprintf("could not determine free space in %s: %v", varLibDir, err)}
+	}
+
+	message := fmt.Sprintf("%.1f GiB free in %s", float64(free)/float64(gib), varLibDir)
+	remediation := fmt.Sprintf("Free up or add space so at least %d GiB are available in %s", recommendedFreeDiskBytes/gib, varLibDir)
+	switch {
+	case free < minFreeDiskBytes:
+		return Result{Status: StatusFail, Message: message, Remediation: remediation}
+	case free < recommendedFreeDiskBytes:
+		return Result{Status: StatusWarn, Message: message, Remediation: remediation}
+	}
+	return Result{Status: StatusPass, Message: message}
+}
+
+// checkClock warns when the clock is not NTP synchronized; skew breaks TLS and Azure token validation
+func (h *host) checkClock(_ context.Context) Result {
+	out, err := h.output("timedatectl", "show", "--property=NTPSynchronized", "--value")
+	if err != nil {
+		return Result{Status: StatusWarn, Message: fmt.Sprintf("could not determine clock synchronization: %v", err)}
+	}
+	if strings.TrimSpace(out) == "yes" {
+		return Result{Status: StatusPass, Message: "clock is synchronized via NTP"}
+	}
+	return Result{
+		Status:      StatusWarn,
+		Message:     "clock is not synchronized; skew breaks TLS and Azure token validation",
+		Remediation: "Enable time synchronization with 'timedatectl set-ntp true'",
+	}
+}
+
+// checkConflictingPackages fails when a packaged container runtime would compete with the one the agent installs
+func (h *host) checkConflictingPackages(_ context.Context) Result {
+	if _, err := h.output("dpkg-query", "--version"); err != nil {
+		return Result{Status: StatusPass, Message: "dpkg is not available, no packages to check"}
+	}
+
+	var installed []string
+	for _, pkg := range conflictingPackages {
+		out, err := h.output("dpkg-query", "-W", "-f=${db:Status-Abbrev}", pkg)
+		if err == nil && strings.HasPrefix(out, "ii") {
+			installed = append(installed, pkg)
+		}
+	}
+	if len(installed) > 0 {
+		return Result{
+			Status:      StatusFail,
+			Message:     "conflicting packages installed: " + strings.Join(installed, ", "),
+			Remediation: "Remove them with 'apt-get purge " + strings.Join(installed, " ") + "'",
+		}
+	}
+	return Result{Status: StatusPass, Message: "no conflicting packages installed"}
+}
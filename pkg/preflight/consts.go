@@ -0,0 +1,44 @@
+package preflight
+
+// Check names, as used by the doctor command and preflight.skipChecks
+const (
+	CheckSwap                = "swap"
+	CheckCgroup              = "cgroup"
+	CheckKernelModules       = "kernel-modules"
+	CheckSystemdResolved     = "systemd-resolved"
+	CheckPorts               = "ports"
+	CheckDiskSpace           = "disk-space"
+	CheckClock               = "clock"
+	CheckConflictingPackages = "conflicting-packages"
+)
+
+const (
+	procSwapsPath         = "/proc/swaps"
+	cgroupControllersPath = "/sys/fs/cgroup/cgroup.controllers"
+	sysModuleDir          = "/sys/module"
+	resolvedResolvConf    = "/run/systemd/resolve/resolv.conf"
+	varLibDir             = "/var/lib"
+
+	gib = uint64(1) << 30
+
+	// minFreeDiskBytes fails the disk check, recommendedFreeDiskBytes warns below the documented minimum
+	minFreeDiskBytes         = 10 * gib
+	recommendedFreeDiskBytes = 25 * gib
+)
+
+// requiredKernelModules are loaded by containerd (overlay) and CNI setup (br_netfilter)
+var requiredKernelModules = []string{"overlay", "br_netfilter"}
+
+// nodePort is a port the node components listen on and the process expected to own it
+type nodePort struct {
+	port  int
+	owner string
+}
+
+var nodePorts = []nodePort{
+	{port: 10250, owner: "kubelet"},
+	{port: 10257, owner: "kube-controller-manager"},
+}
+
+// conflictingPackages are distribution packages that install a second container runtime
+var conflictingPackages = []string{"docker.io", "docker-ce", "containerd", "containerd.io", "moby-engine", "moby-containerd"}
@@ -0,0 +1,177 @@
+// Package preflight checks the host for conditions that commonly break a bootstrap,
+// such as enabled swap, missing kernel modules or ports already in use.
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	StatusSkip Status = "skip"
+)
+
+// Result is the outcome of a single check with a hint on how to fix it
+type Result struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Status      Status `json:"status"`
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// Report is the outcome of a set of checks
+type Report struct {
+	Results []Result `json:"results"`
+	Passed  int      `json:"passed"`
+	Warned  int      `json:"warned"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+}
+
+// OK reports whether no check failed
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// check is a single named host check
+type check struct {
+	name        string
+	description string
+	run         func(h *host, ctx context.Context) Result
+}
+
+// checks lists every check in the order they are reported
+var checks = []check{
+	{CheckSwap, "Swap is disabled", (*host).checkSwap},
+	{CheckCgroup, "Unified cgroup v2 hierarchy is mounted", (*host).checkCgroup},
+	{CheckKernelModules, "Kernel modules for containers and bridged networking are available", (*host).checkKernelModules},
+	{CheckSystemdResolved, "systemd-resolved manages DNS", (*host).checkSystemdResolved},
+	{CheckPorts, "Kubernetes node ports are free", (*host).checkPorts},
+	{CheckDiskSpace, "Enough disk space is free in " + varLibDir, (*host).checkDiskSpace},
+	{CheckClock, "System clock is synchronized", (*host).checkClock},
+	{CheckConflictingPackages, "No conflicting container runtime packages are installed", (*host).checkConflictingPackages},
+}
+
+// CheckNames returns the names of all checks
+func CheckNames() []string {
+	names := make([]string, 0, len(checks))
+	for _, c := range checks {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// ValidateCheckNames returns an error naming every unknown check in names
+func ValidateCheckNames(names ...string) error {
+	_, err := selectChecks(names)
+	return err
+}
+
+// Run runs the named checks, or every check when no name is given. Checks skipped in
+// cfg are reported as skipped rather than run.
+func Run(ctx context.Context, cfg *config.Config, names ...string) (*Report, error) {
+	return runChecks(ctx, newHost(), cfg, names...)
+}
+
+// Validate runs the named checks before an installation step. Warnings are logged;
+// failures are returned as an error that needs user action.
+func Validate(ctx context.Context, cfg *config.Config, logger *logrus.Logger, names ...string) error {
+	report, err := Run(ctx, cfg, names...)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, result := range report.Results {
+		switch result.Status {
+		case StatusWarn:
+			logger.Warnf("Preflight check %s: %s", result.Name, result.Message)
+		case StatusFail:
+			logger.Errorf("Preflight check %s failed: %s (%s)", result.Name, result.Message, result.Remediation)
+			failures = append(failures, fmt.Sprintf("%s: %s", result.Name, result.Message))
+		}
+	}
+	if len(failures) > 0 {
+		return retry.NeedsUserAction(fmt.Errorf("preflight checks failed: %s; run 'aks-flex-node doctor' for remediation hints or skip checks with preflight.skipChecks",
+			strings.Join(failures, "; ")))
+	}
+	return nil
+}
+
+// runChecks runs the named checks against h
+func runChecks(ctx context.Context, h *host, cfg *config.Config, names ...string) (*Report, error) {
+	selected, err := selectChecks(names)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Results: make([]Result, 0, len(selected))}
+	for _, c := range selected {
+		var result Result
+		if cfg != nil && cfg.Preflight.SkipsCheck(c.name) {
+			result = Result{Status: StatusSkip, Message: "skipped by preflight.skipChecks"}
+		} else {
+			result = c.run(h, ctx)
+		}
+		result.Name = c.name
+		result.Description = c.description
+
+		switch result.Status {
+		case StatusPass:
+			report.Passed++
+		case StatusWarn:
+			report.Warned++
+		case StatusFail:
+			report.Failed++
+		case StatusSkip:
+			report.Skipped++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// selectChecks returns the named checks in report order, or all checks when names is empty
+func selectChecks(names []string) ([]check, error) {
+	if len(names) == 0 {
+		return checks, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = true
+	}
+
+	var selected []check
+	for _, c := range checks {
+		if wanted[c.name] {
+			selected = append(selected, c)
+			delete(wanted, c.name)
+		}
+	}
+	if len(wanted) > 0 {
+		unknown := make([]string, 0, len(wanted))
+		for name := range wanted {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		return nil, errors.New("unknown preflight checks " + strings.Join(unknown, ", ") +
+			", expected one of " + strings.Join(CheckNames(), ", "))
+	}
+	return selected, nil
+}
@@ -0,0 +1,196 @@
+package preflight
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// fakeHost returns a host on which every check passes; tests override single functions
+func fakeHost() *host {
+	return &host{
+		readFile: func(string) ([]byte, error) {
+			return []byte("Filename\tType\tSize\tUsed\tPriority\n"), nil
+		},
+		exists:    func(string) bool { return true },
+		freeBytes: func(string) (uint64, error) { return 100 * gib, nil },
+		portFree:  func(int) bool { return true },
+		active:    func(string) bool { return false },
+		output: func(name string, args ...string) (string, error) {
+			switch name {
+			case "timedatectl":
+				return "yes\n", nil
+			case "dpkg-query":
+				if args[0] == "--version" {
+					return "1.21", nil
+				}
+				return "", errors.New("no packages found")
+			}
+			return "", nil
+		},
+	}
+}
+
+// TestChecks verifies the outcome of each check for healthy and broken hosts.
+// Test: Runs single checks against fake hosts in various states
+// Expected: Each check reports the expected status, and failures carry a remediation hint
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		check      string
+		modify     func(h *host)
+		wantStatus Status
+		wantText   string
+	}{
+		{name: "swap off", check: CheckSwap, modify: func(*host) {}, wantStatus: StatusPass},
+		{
+			name:  "swap on",
+			check: CheckSwap,
+			modify: func(h *host) {
+				h.readFile = func(string) ([]byte, error) {
+					return []byte("Filename\tType\tSize\tUsed\tPriority\n/swap.img file 4194300 0 -2\n"), nil
+				}
+			},
+			wantStatus: StatusFail,
+			wantText:   "/swap.img",
+		},
+		{
+			name:       "cgroup v1",
+			check:      CheckCgroup,
+			modify:     func(h *host) { h.exists = func(string) bool { return false } },
+			wantStatus: StatusWarn,
+			wantText:   "cgroup v1",
+		},
+		{
+			name:  "module loadable",
+			check: CheckKernelModules,
+			modify: func(h *host) {
+				h.exists = func(path string) bool { return !strings.HasSuffix(path, "br_netfilter") }
+			},
+			wantStatus: StatusWarn,
+			wantText:   "br_netfilter",
+		},
+		{
+			name:  "module missing",
+			check: CheckKernelModules,
+			modify: func(h *host) {
+				h.exists = func(path string) bool { return !strings.HasSuffix(path, "overlay") }
+				h.output = func(string, ...string) (string, error) { return "", errors.New("not found") }
+			},
+			wantStatus: StatusFail,
+			wantText:   "overlay",
+		},
+		{
+			name:       "resolved absent",
+			check:      CheckSystemdResolved,
+			modify:     func(h *host) { h.exists = func(string) bool { return false } },
+			wantStatus: StatusWarn,
+		},
+		{
+			name:  "port used by kubelet",
+			check: CheckPorts,
+			modify: func(h *host) {
+				h.portFree = func(port int) bool { return port != 10250 }
+				h.output = func(string, ...string) (string, error) {
+					return `LISTEN 0 4096 *:10250 *:* users:(("kubelet",pid=812,fd=22))`, nil
+				}
+			},
+			wantStatus: StatusPass,
+			wantText:   "10250 (kubelet)",
+		},
+		{
+			name:  "port used by other process",
+			check: CheckPorts,
+			modify: func(h *host) {
+				h.portFree = func(port int) bool { return port != 10250 }
+				h.output = func(string, ...string) (string, error) {
+					return `LISTEN 0 4096 *:10250 *:* users:(("nginx",pid=812,fd=22))`, nil
+				}
+			},
+			wantStatus: StatusFail,
+			wantText:   "10250 is used by nginx",
+		},
+		{
+			name:       "low disk",
+			check:      CheckDiskSpace,
+			modify:     func(h *host) { h.freeBytes = func(string) (uint64, error) { return 15 * gib, nil } },
+			wantStatus: StatusWarn,
+			wantText:   "15.0 GiB",
+		},
+		{
+			name:       "no disk",
+			check:      CheckDiskSpace,
+			modify:     func(h *host) { h.freeBytes = func(string) (uint64, error) { return gib, nil } },
+			wantStatus: StatusFail,
+		},
+		{
+			name:  "clock unsynchronized",
+			check: CheckClock,
+			modify: func(h *host) {
+				h.output = func(string, ...string) (string, error) { return "no\n", nil }
+			},
+			wantStatus: StatusWarn,
+		},
+		{
+			name:  "docker installed",
+			check: CheckConflictingPackages,
+			modify: func(h *host) {
+				h.output = func(_ string, args ...string) (string, error) {
+					if args[len(args)-1] == "docker.io" {
+						return "ii ", nil
+					}
+					return "", nil
+				}
+			},
+			wantStatus: StatusFail,
+			wantText:   "docker.io",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := fakeHost()
+			tt.modify(h)
+
+			report, err := runChecks(context.Background(), h, nil, tt.check)
+			if err != nil {
+				t.Fatalf("runChecks failed: %v", err)
+			}
+			result := report.Results[0]
+			if result.Status != tt.wantStatus || !strings.Contains(result.Message, tt.wantText) {
+				t.Errorf("Expected %s containing %q, got %+v", tt.wantStatus, tt.wantText, result)
+			}
+			if result.Status == StatusFail && result.Remediation == "" {
+				t.Errorf("Expected a remediation hint for failed check %s", result.Name)
+			}
+		})
+	}
+}
+
+// TestRunChecks_SkipAndSelect verifies check selection and skipping through configuration.
+// Test: Runs all checks with two skipped in config, then selects an unknown check
+// Expected: Skipped checks are reported as skipped and counted; unknown names are rejected
+func TestRunChecks_SkipAndSelect(t *testing.T) {
+	cfg := &config.Config{Preflight: config.PreflightConfig{SkipChecks: []string{"Swap", "ports"}}}
+
+	report, err := runChecks(context.Background(), fakeHost(), cfg)
+	if err != nil {
+		t.Fatalf("runChecks failed: %v", err)
+	}
+	if len(report.Results) != len(CheckNames()) || report.Skipped != 2 || report.Passed != len(CheckNames())-2 || !report.OK() {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	for _, result := range report.Results {
+		skipped := result.Name == CheckSwap || result.Name == CheckPorts
+		if skipped != (result.Status == StatusSkip) {
+			t.Errorf("Unexpected status %s for check %s", result.Status, result.Name)
+		}
+	}
+
+	if _, err := runChecks(context.Background(), fakeHost(), nil, "swap", "firewall"); err == nil || !strings.Contains(err.Error(), "firewall") {
+		t.Errorf("Expected unknown check error naming firewall, got: %v", err)
+	}
+}
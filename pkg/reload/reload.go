@@ -0,0 +1,216 @@
+// Package reload works out which parts of the node a configuration change affects and re-applies
+// only those, so that a running daemon can pick up an edited configuration file. The same path
+// repairs components that drifted from their desired state.
+package reload
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Change is a changed configuration section and how the change is applied
+type Change struct {
+	Section    string   `json:"section"`
+	Components []string `json:"components,omitempty"` // components re-run to apply the change
+	Services   []string `json:"services,omitempty"`   // services restarted after the components ran
+	Note       string   `json:"note,omitempty"`       // how the change takes effect when nothing is re-run
+}
+
+// rule describes how a change of one configuration section is applied
+type rule struct {
+	section    string
+	value      func(cfg *config.Config) interface{}
+	components []string
+	note       string
+}
+
+// Notes for sections that are not applied by re-running components
+const (
+	noteUpgrade     = "applied by the upgrade command or agent.autoUpgrade"
+	noteRebootstrap = "requires unbootstrap and bootstrap to take effect"
+	noteDaemon      = "applied by the daemon immediately"
+	noteNextRun     = "used from the next bootstrap or component run"
+)
+
+// rules lists every configuration section in field order
+var rules = []rule{
+	{section: "azure", value: func(cfg *config.Config) interface{} { return cfg.Azure }, note: noteRebootstrap},
+	{section: "agent", value: func(cfg *config.Config) interface{} { return cfg.Agent }, note: noteDaemon},
+	{
+		section:    "containerd",
+		value:      func(cfg *config.Config) interface{} { c := cfg.Containerd; c.Version = ""; return c },
+		components: []string{bootstrapper.ComponentContainerd},
+	},
+	{section: "containerd.version", value: func(cfg *config.Config) interface{} { return cfg.Containerd.Version }, note: noteUpgrade},
+	{section: "kubernetes", value: func(cfg *config.Config) interface{} { return cfg.Kubernetes }, note: noteUpgrade},
+	{
+		section:    "cni",
+		value:      func(cfg *config.Config) interface{} { return cfg.CNI },
+		components: []string{bootstrapper.ComponentCNI},
+	},
+	{section: "runc", value: func(cfg *config.Config) interface{} { return cfg.Runc }, note: noteUpgrade},
+	{
+		section:    "node",
+		value:      func(cfg *config.Config) interface{} { return cfg.Node },
+		components: []string{bootstrapper.ComponentKubelet},
+	},
+	{section: "paths", value: func(cfg *config.Config) interface{} { return cfg.Paths }, note: noteRebootstrap},
+	{
+		section:    "npd",
+		value:      func(cfg *config.Config) interface{} { return cfg.Npd },
+		components: []string{bootstrapper.ComponentNPD},
+	},
+	{section: "hooks", value: func(cfg *config.Config) interface{} { return cfg.Hooks }, note: noteNextRun},
+	{section: "artifacts", value: func(cfg *config.Config) interface{} { return cfg.Artifacts }, note: noteNextRun},
+	{section: "preflight", value: func(cfg *config.Config) interface{} { return cfg.Preflight }, note: noteNextRun},
+	{section: "selfUpdate", value: func(cfg *config.Config) interface{} { return cfg.SelfUpdate }, note: noteDaemon},
+}
+
+// componentServices lists the services restarted after a component is re-run outside a bootstrap
+var componentServices = map[string][]string{
+	bootstrapper.ComponentRunc:       {"containerd"},
+	bootstrapper.ComponentContainerd: {"containerd"},
+	// containerd loads the CNI configuration
+	bootstrapper.ComponentCNI:          {"containerd"},
+	bootstrapper.ComponentKubeBinaries: {"kubelet"},
+	bootstrapper.ComponentKubelet:      {"kubelet"},
+	bootstrapper.ComponentNPD:          {"node-problem-detector"},
+}
+
+// serviceOrder is the order services are restarted in; kubelet needs the container runtime running
+var serviceOrder = []string{"containerd", "kubelet", "node-problem-detector"}
+
+// Diff returns the sections that differ between the old and new configuration, in field order
+func Diff(oldCfg, newCfg *config.Config) []Change {
+	var changes []Change
+	for _, r := range rules {
+		if reflect.DeepEqual(r.value(oldCfg), r.value(newCfg)) {
+			continue
+		}
+		changes = append(changes, Change{
+			Section:    r.section,
+			Components: r.components,
+			Services:   servicesOf(r.components),
+			Note:       r.note,
+		})
+	}
+	return changes
+}
+
+// componentInstaller re-runs a single node component; implemented by bootstrapper.Bootstrapper
+type componentInstaller interface {
+	InstallComponent(ctx context.Context, name string, withPrerequisites bool) (*bootstrapper.ExecutionResult, error)
+}
+
+// Reloader re-applies configuration changes to the node
+type Reloader struct {
+	logger    *logrus.Logger
+	installer componentInstaller
+
+	// Overridable for tests
+	reloadSystemd  func() error
+	restartService func(name string) error
+}
+
+// New creates a reloader that re-runs components with installer, which must use the new configuration
+func New(logger *logrus.Logger, installer componentInstaller) *Reloader {
+	return &Reloader{
+		logger:         logger,
+		installer:      installer,
+		reloadSystemd:  utils.ReloadSystemd,
+		restartService: utils.RestartService,
+	}
+}
+
+// Apply re-runs the components affected by changes in install order, then restarts their services.
+// It returns the components that were re-run.
+func (r *Reloader) Apply(ctx context.Context, changes []Change) ([]string, error) {
+	components, services := affected(changes)
+	for _, change := range changes {
+		if change.Note != "" {
+			r.logger.Infof("Configuration section %s changed: %s", change.Section, change.Note)
+		}
+	}
+	return r.rerun(ctx, components, services)
+}
+
+// Repair re-runs components that drifted from their desired state, then restarts their services.
+// It returns the components that were re-run.
+func (r *Reloader) Repair(ctx context.Context, components []string) ([]string, error) {
+	components, services := affected([]Change{{Components: components, Services: servicesOf(components)}})
+	return r.rerun(ctx, components, services)
+}
+
+// rerun re-runs components in the given order, then restarts services
+func (r *Reloader) rerun(ctx context.Context, components, services []string) ([]string, error) {
+	if len(components) == 0 {
+		return nil, nil
+	}
+
+	var applied []string
+	for _, name := range components {
+		r.logger.Infof("Re-running component %s to apply the configuration change", name)
+		result, err := r.installer.InstallComponent(ctx, name, false)
+		if err == nil && result != nil && !result.Success {
+			err = fmt.Errorf("%s", result.Error)
+		}
+		if err != nil {
+			return applied, fmt.Errorf("failed to re-run component %s: %w", name, err)
+		}
+		applied = append(applied, name)
+	}
+
+	if err := r.reloadSystemd(); err != nil {
+		return applied, fmt.Errorf("failed to reload systemd: %w", err)
+	}
+	for _, service := range services {
+		r.logger.Infof("Restarting %s to apply the configuration change", service)
+		if err := r.restartService(service); err != nil {
+			return applied, fmt.Errorf("failed to restart %s: %w", service, err)
+		}
+	}
+	return applied, nil
+}
+
+// servicesOf returns the services restarted after components are re-run
+func servicesOf(components []string) []string {
+	var services []string
+	for _, component := range components {
+		services = append(services, componentServices[component]...)
+	}
+	return services
+}
+
+// affected returns the components to re-run in install order and the services to restart in restart order
+func affected(changes []Change) ([]string, []string) {
+	componentSet := make(map[string]bool)
+	serviceSet := make(map[string]bool)
+	for _, change := range changes {
+		for _, component := range change.Components {
+			componentSet[component] = true
+		}
+		for _, service := range change.Services {
+			serviceSet[service] = true
+		}
+	}
+
+	var components, services []string
+	for _, name := range bootstrapper.ComponentNames() {
+		if componentSet[name] {
+			components = append(components, name)
+		}
+	}
+	for _, name := range serviceOrder {
+		if serviceSet[name] {
+			services = append(services, name)
+		}
+	}
+	return components, services
+}
@@ -0,0 +1,232 @@
+package reload
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// fakeInstaller records the components it is asked to re-run
+type fakeInstaller struct {
+	installed []string
+	failOn    string
+}
+
+func (f *fakeInstaller) InstallComponent(ctx context.Context, name string, withPrerequisites bool) (*bootstrapper.ExecutionResult, error) {
+	f.installed = append(f.installed, name)
+	if name == f.failOn {
+		return &bootstrapper.ExecutionResult{Success: false, Error: "step failed"}, nil
+	}
+	return &bootstrapper.ExecutionResult{Success: true}, nil
+}
+
+// baseConfig returns a configuration to derive changed ones from
+func baseConfig() *config.Config {
+	return &config.Config{
+		Agent:      config.AgentConfig{LogLevel: "info"},
+		Containerd: config.ContainerdConfig{Version: "1.7.20", PauseImage: "mcr.microsoft.com/oss/kubernetes/pause:3.6"},
+		Kubernetes: config.KubernetesConfig{Version: "1.32.7"},
+		Node: config.NodeConfig{
+			MaxPods: 110,
+			Labels:  map[string]string{"team": "edge"},
+			Kubelet: config.KubeletConfig{EvictionHard: map[string]string{"memory.available": "100Mi"}},
+		},
+	}
+}
+
+// TestDiff verifies the changed sections and how they are applied.
+// Test: Compares a base configuration with copies changing labels, eviction, versions and agent settings
+// Expected: Each change maps to its section with the components to re-run or a note
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name         string
+		mutate       func(*config.Config)
+		wantSections []string
+	}{
+		{name: "unchanged", mutate: func(*config.Config) {}},
+		{
+			name:         "node labels",
+			mutate:       func(c *config.Config) { c.Node.Labels = map[string]string{"team": "retail"} },
+			wantSections: []string{"node"},
+		},
+		{
+			name:         "kubelet eviction and pause image",
+			mutate:       func(c *config.Config) { c.Node.Kubelet.EvictionHard = nil; c.Containerd.PauseImage = "pause:3.9" },
+			wantSections: []string{"containerd", "node"},
+		},
+		{
+			name:         "containerd version only",
+			mutate:       func(c *config.Config) { c.Containerd.Version = "1.7.22" },
+			wantSections: []string{"containerd.version"},
+		},
+		{
+			name:         "agent and npd",
+			mutate:       func(c *config.Config) { c.Agent.LogLevel = "debug"; c.Npd.Version = "v0.8.20" },
+			wantSections: []string{"agent", "npd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newCfg := baseConfig()
+			tt.mutate(newCfg)
+
+			var sections []string
+			for _, change := range Diff(baseConfig(), newCfg) {
+				sections = append(sections, change.Section)
+				if len(change.Components) == 0 && change.Note == "" {
+					t.Errorf("Change of %s neither re-runs components nor has a note", change.Section)
+				}
+			}
+			if !reflect.DeepEqual(sections, tt.wantSections) {
+				t.Errorf("Diff() sections = %v, want %v", sections, tt.wantSections)
+			}
+		})
+	}
+}
+
+// TestApply verifies re-running affected components and restarting their services.
+// Test: Applies changes of the node, cni and containerd sections, with and without a failing component
+// Expected: Components run in install order, services restart in dependency order once each,
+// and a failing component stops the reload before any restart
+func TestApply(t *testing.T) {
+	changes := []Change{
+		{Section: "node", Components: []string{bootstrapper.ComponentKubelet}, Services: []string{"kubelet"}},
+		{Section: "cni", Components: []string{bootstrapper.ComponentCNI}, Services: []string{"containerd"}},
+		{Section: "containerd", Components: []string{bootstrapper.ComponentContainerd}, Services: []string{"containerd"}},
+		{Section: "agent", Note: noteDaemon},
+	}
+
+	tests := []struct {
+		name          string
+		failOn        string
+		wantInstalled []string
+		wantApplied   []string
+		wantRestarts  []string
+		wantErr       bool
+	}{
+		{
+			name:          "all succeed",
+			wantInstalled: []string{"containerd", "cni", "kubelet"},
+			wantApplied:   []string{"containerd", "cni", "kubelet"},
+			wantRestarts:  []string{"containerd", "kubelet"},
+		},
+		{
+			name:          "cni fails",
+			failOn:        "cni",
+			wantInstalled: []string{"containerd", "cni"},
+			wantApplied:   []string{"containerd"},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			installer := &fakeInstaller{failOn: tt.failOn}
+			reloader := New(logger, installer)
+			var restarts []string
+			reloader.reloadSystemd = func() error { return nil }
+			reloader.restartService = func(name string) error { restarts = append(restarts, name); return nil }
+
+			applied, err := reloader.Apply(context.Background(), changes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(installer.installed, tt.wantInstalled) {
+				t.Errorf("installed = %v, want %v", installer.installed, tt.wantInstalled)
+			}
+			if !reflect.DeepEqual(applied, tt.wantApplied) {
+				t.Errorf("applied = %v, want %v", applied, tt.wantApplied)
+			}
+			if !reflect.DeepEqual(restarts, tt.wantRestarts) {
+				t.Errorf("restarts = %v, want %v", restarts, tt.wantRestarts)
+			}
+		})
+	}
+}
+
+// TestRepair verifies re-running drifted components.
+// Test: Repairs kubelet, services and containerd given out of order
+// Expected: Components run in install order and only the services of re-run components restart
+func TestRepair(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	installer := &fakeInstaller{}
+	reloader := New(logger, installer)
+	var restarts []string
+	reloader.reloadSystemd = func() error { return nil }
+	reloader.restartService = func(name string) error { restarts = append(restarts, name); return nil }
+
+	components := []string{bootstrapper.ComponentKubelet, bootstrapper.ComponentServices, bootstrapper.ComponentContainerd}
+	if _, err := reloader.Repair(context.Background(), components); err != nil {
+		t.Fatalf("Repair() error = %v", err)
+	}
+	if want := []string{"containerd", "kubelet", "services"}; !reflect.DeepEqual(installer.installed, want) {
+		t.Errorf("installed = %v, want %v", installer.installed, want)
+	}
+	if want := []string{"containerd", "kubelet"}; !reflect.DeepEqual(restarts, want) {
+		t.Errorf("restarts = %v, want %v", restarts, want)
+	}
+}
+
+// TestWatch verifies that changes of the watched file are reported.
+// Test: Writes an unrelated file, then replaces the config file by rename
+// Expected: Only the config file change is reported, once
+func TestWatch(t *testing.T) {
+	previous := settleDelay
+	settleDelay = 50 * time.Millisecond
+	defer func() { settleDelay = previous }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	changed, err := Watch(ctx, path, logger)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatalf("failed to write other file: %v", err)
+	}
+	select {
+	case <-changed:
+		t.Fatal("Expected no change to be reported for another file")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	temp := filepath.Join(dir, "config.json.tmp")
+	if err := os.WriteFile(temp, []byte(`{"agent":{}}`), 0o600); err != nil {
+		t.Fatalf("failed to write temp config: %v", err)
+	}
+	if err := os.Rename(temp, path); err != nil {
+		t.Fatalf("failed to replace config: %v", err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the config change to be reported")
+	}
+	select {
+	case <-changed:
+		t.Fatal("Expected the burst of events to be reported once")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,160 @@
+// Package remediation limits how often the daemon remediates a node that keeps breaking. Attempts
+// are counted within a window and failed attempts back off exponentially. When the attempts of a
+// window are used up the breaker trips: automatic remediation stops until an operator resets it.
+// The breaker state is kept in the agent state directory so that it survives restarts.
+package remediation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// stateFileName is the name of the breaker state file in the agent state directory
+const stateFileName = "remediation.json"
+
+// State is the persisted state of the breaker
+type State struct {
+	Attempts            []time.Time `json:"attempts,omitempty"` // when the attempts within the window ran
+	ConsecutiveFailures int         `json:"consecutiveFailures"`
+	LastError           string      `json:"lastError,omitempty"`
+	NextAttempt         *time.Time  `json:"nextAttempt,omitempty"` // earliest next attempt while backing off
+	Tripped             bool        `json:"tripped"`
+	TrippedAt           *time.Time  `json:"trippedAt,omitempty"`
+	Reason              string      `json:"reason,omitempty"` // why the breaker tripped
+}
+
+// Breaker decides whether the daemon may remediate the node
+type Breaker struct {
+	path   string
+	policy config.RemediationConfig
+	state  State
+}
+
+// Path returns the breaker state file in stateDir
+func Path(stateDir string) string {
+	return filepath.Join(stateDir, stateFileName)
+}
+
+// ReadState reads the breaker state from path; a missing file is a breaker that never tripped
+func ReadState(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remediation state %s: %w", path, err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse remediation state %s: %w", path, err)
+	}
+	return &state, nil
+}
+
+// Load reads the breaker of the configured agent state directory
+func Load(cfg *config.Config) (*Breaker, error) {
+	path := Path(cfg.Agent.StateDir)
+	state, err := ReadState(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Breaker{path: path, policy: cfg.Agent.Remediation, state: *state}, nil
+}
+
+// State returns the current breaker state
+func (b *Breaker) State() State {
+	return b.state
+}
+
+// Allow reports whether a remediation may start at now, and if not, why. Using up the attempts of
+// the window trips the breaker.
+func (b *Breaker) Allow(now time.Time) (bool, string, error) {
+	if b.state.Tripped {
+		return false, fmt.Sprintf("automatic remediation is stopped: %s", b.state.Reason), nil
+	}
+
+	b.prune(now)
+	if b.state.NextAttempt != nil && now.Before(*b.state.NextAttempt) {
+		return false, fmt.Sprintf("backing off after %d failed attempts until %s",
+			b.state.ConsecutiveFailures, b.state.NextAttempt.Format(time.RFC3339)), nil
+	}
+
+	if len(b.state.Attempts) >= b.policy.MaxAttempts {
+		b.state.Tripped = true
+		b.state.TrippedAt = &now
+		b.state.Reason = fmt.Sprintf("%d remediation attempts within %s did not keep the node healthy", len(b.state.Attempts), b.policy.Window)
+		if b.state.LastError != "" {
+			b.state.Reason += fmt.Sprintf(", the last one failed: %s", b.state.LastError)
+		}
+		return false, fmt.Sprintf("automatic remediation is stopped: %s", b.state.Reason), b.save()
+	}
+	return true, "", nil
+}
+
+// Record records the outcome of an attempt that ended at now, backing off after a failure
+func (b *Breaker) Record(now time.Time, err error) error {
+	b.state.Attempts = append(b.state.Attempts, now)
+	if err == nil {
+		b.state.ConsecutiveFailures = 0
+		b.state.LastError = ""
+		b.state.NextAttempt = nil
+		return b.save()
+	}
+
+	b.state.ConsecutiveFailures++
+	b.state.LastError = err.Error()
+	next := now.Add(b.backoff())
+	b.state.NextAttempt = &next
+	return b.save()
+}
+
+// Reset forgets all attempts and closes a tripped breaker
+func (b *Breaker) Reset() error {
+	b.state = State{}
+	return b.save()
+}
+
+// backoff returns the wait after the current number of consecutive failures
+func (b *Breaker) backoff() time.Duration {
+	backoff := b.policy.Backoff
+	for i := 1; i < b.state.ConsecutiveFailures && backoff < b.policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, b.policy.MaxBackoff)
+}
+
+// prune drops the attempts that fell out of the window
+func (b *Breaker) prune(now time.Time) {
+	cutoff := now.Add(-b.policy.Window)
+	attempts := b.state.Attempts[:0]
+	for _, attempt := range b.state.Attempts {
+		if attempt.After(cutoff) {
+			attempts = append(attempts, attempt)
+		}
+	}
+	b.state.Attempts = attempts
+}
+
+// save writes the breaker state. The file is world-readable so that a reset run as root leaves it
+// readable for the daemon user.
+func (b *Breaker) save() error {
+	data, err := json.MarshalIndent(b.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal remediation state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(b.path), 0750); err != nil {
+		return fmt.Errorf("failed to create remediation state directory: %w", err)
+	}
+	if err := utils.WriteFileAtomic(b.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write remediation state %s: %w", b.path, err)
+	}
+	return nil
+}
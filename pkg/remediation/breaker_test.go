@@ -0,0 +1,97 @@
+package remediation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// testConfig returns a configuration with a breaker state file in a temp directory
+func testConfig(t *testing.T) *config.Config {
+	return &config.Config{Agent: config.AgentConfig{
+		StateDir: t.TempDir(),
+		Remediation: config.RemediationConfig{
+			MaxAttempts: 3,
+			Window:      time.Hour,
+			Backoff:     2 * time.Minute,
+			MaxBackoff:  5 * time.Minute,
+		},
+	}}
+}
+
+// TestBreaker verifies backoff between failed attempts and tripping.
+// Test: Records failing attempts and asks for permission before and after each backoff
+// Expected: The backoff doubles up to its maximum, the breaker trips when the attempts of the window
+// are used up, and the tripped state survives reloading
+func TestBreaker(t *testing.T) {
+	cfg := testConfig(t)
+	breaker, err := Load(cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	failure := errors.New("kubelet failed to start")
+
+	for i, wantBackoff := range []time.Duration{2 * time.Minute, 4 * time.Minute, 5 * time.Minute} {
+		if allowed, reason, err := breaker.Allow(now); !allowed || err != nil {
+			t.Fatalf("Attempt %d: Allow() = %v, %q, %v, want allowed", i+1, allowed, reason, err)
+		}
+		if err := breaker.Record(now, failure); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		if allowed, reason, _ := breaker.Allow(now.Add(wantBackoff - time.Second)); allowed || !strings.Contains(reason, "backing off") {
+			t.Fatalf("Attempt %d: Allow() during backoff = %v, %q, want backing off", i+1, allowed, reason)
+		}
+		now = now.Add(wantBackoff)
+	}
+
+	allowed, reason, err := breaker.Allow(now)
+	if allowed || err != nil || !strings.Contains(reason, "3 remediation attempts within 1h0m0s") || !strings.Contains(reason, failure.Error()) {
+		t.Fatalf("Allow() after the last attempt = %v, %q, %v, want tripped", allowed, reason, err)
+	}
+
+	reloaded, err := Load(cfg)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if state := reloaded.State(); !state.Tripped || state.TrippedAt == nil {
+		t.Errorf("Expected the tripped state to be persisted, got %+v", state)
+	}
+	if allowed, _, _ := reloaded.Allow(now.Add(24 * time.Hour)); allowed {
+		t.Error("Expected a tripped breaker to stay open until reset")
+	}
+
+	if err := reloaded.Reset(); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if allowed, reason, _ := reloaded.Allow(now); !allowed {
+		t.Errorf("Expected a reset breaker to allow remediation, got %q", reason)
+	}
+}
+
+// TestBreakerWindow verifies that only attempts within the window count.
+// Test: Records successful attempts spread over more than the window
+// Expected: Attempts older than the window no longer count towards tripping
+func TestBreakerWindow(t *testing.T) {
+	breaker, err := Load(testConfig(t))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 5; i++ {
+		if allowed, reason, _ := breaker.Allow(now); !allowed {
+			t.Fatalf("Attempt %d: expected remediation to be allowed, got %q", i+1, reason)
+		}
+		if err := breaker.Record(now, nil); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		now = now.Add(25 * time.Minute)
+	}
+	if state := breaker.State(); state.Tripped || state.ConsecutiveFailures != 0 {
+		t.Errorf("Expected an untripped breaker without failures, got %+v", state)
+	}
+}
@@ -0,0 +1,124 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML with one test case per step, so that CI systems
+// can display bootstrap runs like test runs. Rollback steps form a separate test suite.
+func (r *Report) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{
+		Name: "aks-flex-node",
+		Time: formatSeconds(r.DurationSeconds),
+	}
+
+	suites.Suites = append(suites.Suites, r.junitSuite(r.Operation, r.Steps))
+	if len(r.RollbackSteps) > 0 {
+		suites.Suites = append(suites.Suites, r.junitSuite(r.Operation+"-rollback", r.RollbackSteps))
+	}
+	for _, suite := range suites.Suites {
+		suites.Tests += suite.Tests
+		suites.Failures += suite.Failures
+		suites.Skipped += suite.Skipped
+	}
+
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report to JUnit XML: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, data)
+	return err
+}
+
+// junitSuite converts steps into a test suite carrying the run metadata as properties
+func (r *Report) junitSuite(name string, steps []StepReport) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      name,
+		Tests:     len(steps),
+		Timestamp: r.StartedAt.Format("2006-01-02T15:04:05"),
+		Properties: []junitProperty{
+			{Name: "agent_version", Value: r.AgentVersion},
+			{Name: "config_hash", Value: r.ConfigHash},
+			{Name: "hostname", Value: r.Hostname},
+		},
+	}
+
+	var total float64
+	for _, step := range steps {
+		total += step.DurationSeconds
+		testCase := junitTestCase{
+			Name:      step.Name,
+			ClassName: "aks-flex-node." + name,
+			Time:      formatSeconds(step.DurationSeconds),
+		}
+
+		switch step.Status {
+		case StatusSkipped:
+			suite.Skipped++
+			testCase.Skipped = &junitMessage{Message: step.SkipReason}
+		case StatusFailed:
+			suite.Failures++
+			testCase.Failure = &junitMessage{
+				Message: step.Error,
+				Type:    string(step.ErrorClass),
+				Text:    strings.Join(step.LogTail, "\n"),
+			}
+		}
+		if step.Attempts > 1 {
+			testCase.SystemOut = fmt.Sprintf("attempts: %d", step.Attempts)
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+	suite.Time = formatSeconds(total)
+	return suite
+}
+
+// formatSeconds formats a duration in seconds the way JUnit consumers expect
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
@@ -0,0 +1,187 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Supported report formats
+const (
+	FormatJSON  = "json"
+	FormatJUnit = "junit"
+)
+
+// Step statuses
+const (
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusSkipped   = "skipped"
+)
+
+// Report is the machine-readable record of a single bootstrap or unbootstrap run
+type Report struct {
+	Operation       string                    `json:"operation"`
+	AgentVersion    string                    `json:"agent_version"`
+	ConfigHash      string                    `json:"config_hash"`
+	Hostname        string                    `json:"hostname,omitempty"`
+	StartedAt       time.Time                 `json:"started_at"`
+	FinishedAt      time.Time                 `json:"finished_at"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	Success         bool                      `json:"success"`
+	Error           string                    `json:"error,omitempty"`
+	Steps           []StepReport              `json:"steps"`
+	RollbackSteps   []StepReport              `json:"rollback_steps,omitempty"`
+	Hooks           []bootstrapper.HookResult `json:"hooks,omitempty"`
+}
+
+// StepReport is the outcome of a single step
+type StepReport struct {
+	Name            string                    `json:"name"`
+	Status          string                    `json:"status"`
+	SkipReason      string                    `json:"skip_reason,omitempty"`
+	DurationSeconds float64                   `json:"duration_seconds"`
+	Error           string                    `json:"error,omitempty"`
+	ErrorClass      retry.Class               `json:"error_class,omitempty"`
+	Attempts        int                       `json:"attempts,omitempty"`
+	TimedOut        bool                      `json:"timed_out,omitempty"`
+	LogTail         []string                  `json:"log_tail,omitempty"`
+	Hooks           []bootstrapper.HookResult `json:"hooks,omitempty"`
+}
+
+// GetReportsDir returns the directory below the agent state directory where daemon run reports are kept
+func GetReportsDir(stateDir string) string {
+	return filepath.Join(stateDir, "reports")
+}
+
+// ValidateFormat checks that format is a supported report format
+func ValidateFormat(format string) error {
+	if format != FormatJSON && format != FormatJUnit {
+		return fmt.Errorf("unsupported report format %q, expected %s or %s", format, FormatJSON, FormatJUnit)
+	}
+	return nil
+}
+
+// New builds the report of a finished run
+func New(operation string, result *bootstrapper.ExecutionResult, agentVersion, configHash string) *Report {
+	finishedAt := time.Now().UTC()
+	hostname, _ := os.Hostname()
+
+	return &Report{
+		Operation:       operation,
+		AgentVersion:    agentVersion,
+		ConfigHash:      configHash,
+		Hostname:        hostname,
+		StartedAt:       finishedAt.Add(-result.Duration),
+		FinishedAt:      finishedAt,
+		DurationSeconds: result.Duration.Seconds(),
+		Success:         result.Success,
+		Error:           result.Error,
+		Steps:           stepReports(result.StepResults),
+		RollbackSteps:   stepReports(result.RollbackResults),
+		Hooks:           result.Hooks,
+	}
+}
+
+// stepReports converts step results, distinguishing executed from skipped steps
+func stepReports(results []bootstrapper.StepResult) []StepReport {
+	reports := make([]StepReport, 0, len(results))
+	for _, result := range results {
+		status := StatusSucceeded
+		switch {
+		case !result.Success:
+			status = StatusFailed
+		case result.SkipReason != "":
+			status = StatusSkipped
+		}
+
+		reports = append(reports, StepReport{
+			Name:            result.StepName,
+			Status:          status,
+			SkipReason:      result.SkipReason,
+			DurationSeconds: result.Duration.Seconds(),
+			Error:           result.Error,
+			ErrorClass:      result.ErrorClass,
+			Attempts:        result.Attempts,
+			TimedOut:        result.TimedOut,
+			LogTail:         result.LogTail,
+			Hooks:           result.Hooks,
+		})
+	}
+	return reports
+}
+
+// Write writes the report in the given format
+func (r *Report) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatJSON:
+		return r.WriteJSON(w)
+	case FormatJUnit:
+		return r.WriteJUnit(w)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// WriteFile atomically writes the report to path in the given format
+func (r *Report) WriteFile(path, format string) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+
+	var builder strings.Builder
+	if err := r.Write(&builder, format); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create report directory: %w", err)
+	}
+	if err := utils.WriteFileAtomic(path, []byte(builder.String()), 0640); err != nil {
+		return fmt.Errorf("failed to write report to %s: %w", path, err)
+	}
+	return nil
+}
+
+// SaveToDir writes the report as JSON into dir under a name derived from its operation and
+// finish time, and removes the oldest reports of the same operation beyond keep
+func (r *Report) SaveToDir(dir string, keep int) (string, error) {
+	prefix := r.Operation + "-"
+	path := filepath.Join(dir, prefix+r.FinishedAt.Format("20060102T150405.000Z")+".json")
+	if err := r.WriteFile(path, FormatJSON); err != nil {
+		return "", err
+	}
+
+	existing, err := filepath.Glob(filepath.Join(dir, prefix+"*.json"))
+	if err != nil {
+		return path, fmt.Errorf("failed to list reports in %s: %w", dir, err)
+	}
+	// Names embed the finish time, so lexical order is chronological
+	sort.Strings(existing)
+	for len(existing) > keep {
+		if err := os.Remove(existing[0]); err != nil && !os.IsNotExist(err) {
+			return path, fmt.Errorf("failed to remove old report %s: %w", existing[0], err)
+		}
+		existing = existing[1:]
+	}
+	return path, nil
+}
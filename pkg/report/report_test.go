@@ -0,0 +1,138 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
+)
+
+// testResult returns a failed bootstrap result with a skipped, an executed and a failed step
+func testResult() *bootstrapper.ExecutionResult {
+	return &bootstrapper.ExecutionResult{
+		Success:   false,
+		StepCount: 3,
+		Duration:  90 * time.Second,
+		Error:     "timed out after 10m0s",
+		StepResults: []bootstrapper.StepResult{
+			{StepName: "ArcInstall", Success: true, SkipReason: "already completed"},
+			{StepName: "ContainerdInstaller", Success: true, Duration: 30 * time.Second, Attempts: 2},
+			{
+				StepName:   "KubeletInstaller",
+				Duration:   time.Minute,
+				Error:      "timed out after 10m0s",
+				ErrorClass: retry.ClassTransient,
+				TimedOut:   true,
+				LogTail:    []string{"waiting for kubelet"},
+			},
+		},
+	}
+}
+
+// TestNew verifies how execution results map onto the report.
+// Test: Builds a report from a result with skipped, executed and failed steps
+// Expected: Metadata is set and every step carries the matching status and duration
+func TestNew(t *testing.T) {
+	r := New("bootstrap", testResult(), "v1.2.3", "abc123")
+
+	if r.Operation != "bootstrap" || r.AgentVersion != "v1.2.3" || r.ConfigHash != "abc123" {
+		t.Errorf("Unexpected report metadata: %+v", r)
+	}
+	if r.DurationSeconds != 90 || r.FinishedAt.Sub(r.StartedAt) != 90*time.Second {
+		t.Errorf("Expected a 90s run, got %v seconds from %v to %v", r.DurationSeconds, r.StartedAt, r.FinishedAt)
+	}
+
+	wantStatuses := []string{StatusSkipped, StatusSucceeded, StatusFailed}
+	if len(r.Steps) != len(wantStatuses) {
+		t.Fatalf("Expected %d steps, got %d", len(wantStatuses), len(r.Steps))
+	}
+	for i, want := range wantStatuses {
+		if r.Steps[i].Status != want {
+			t.Errorf("Step %s status = %s, want %s", r.Steps[i].Name, r.Steps[i].Status, want)
+		}
+	}
+	if r.Steps[0].SkipReason != "already completed" || r.Steps[1].DurationSeconds != 30 {
+		t.Errorf("Expected skip reason and duration to be preserved, got %+v", r.Steps[:2])
+	}
+}
+
+// TestWriteJUnit verifies the JUnit XML rendering of a report.
+// Test: Renders the report of a failed run as JUnit XML and parses it back
+// Expected: One test case per step with skipped and failure elements and run metadata as properties
+func TestWriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := New("bootstrap", testResult(), "v1.2.3", "abc123").Write(&buf, FormatJUnit); err != nil {
+		t.Fatalf("WriteJUnit failed: %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("Expected valid XML, got %v:\n%s", err, buf.String())
+	}
+	if suites.Tests != 3 || suites.Failures != 1 || suites.Skipped != 1 {
+		t.Errorf("Expected 3 tests, 1 failure, 1 skipped, got %d/%d/%d", suites.Tests, suites.Failures, suites.Skipped)
+	}
+
+	suite := suites.Suites[0]
+	if suite.Name != "bootstrap" || suite.Properties[0].Value != "v1.2.3" || suite.Properties[1].Value != "abc123" {
+		t.Errorf("Unexpected suite metadata: %+v", suite)
+	}
+	failure := suite.Cases[2].Failure
+	if failure == nil || failure.Message != "timed out after 10m0s" || failure.Text != "waiting for kubelet" {
+		t.Errorf("Expected failure with error and log tail, got %+v", failure)
+	}
+}
+
+// TestWriteFile verifies format validation and the JSON file output.
+// Test: Writes the report as JSON into a new directory and with an unknown format
+// Expected: The JSON file round-trips; the unknown format is rejected
+func TestWriteFile(t *testing.T) {
+	r := New("unbootstrap", testResult(), "dev", "hash")
+	path := filepath.Join(t.TempDir(), "nested", "report.json")
+
+	if err := r.WriteFile(path, FormatJSON); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected report file: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Operation != "unbootstrap" || len(decoded.Steps) != 3 {
+		t.Errorf("Expected report to round-trip, got %+v (err %v)", decoded, err)
+	}
+
+	if err := r.WriteFile(path, "yaml"); err == nil {
+		t.Error("Expected unsupported format to be rejected")
+	}
+}
+
+// TestSaveToDir verifies that saved daemon reports are pruned to the newest ones.
+// Test: Saves four reports with distinct finish times while keeping two
+// Expected: Only the two newest report files remain
+func TestSaveToDir(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var paths []string
+	for i := 0; i < 4; i++ {
+		r := New("auto-bootstrap", testResult(), "dev", "hash")
+		r.FinishedAt = base.Add(time.Duration(i) * time.Minute)
+		path, err := r.SaveToDir(dir, 2)
+		if err != nil {
+			t.Fatalf("SaveToDir failed: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	remaining, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(remaining) != 2 || remaining[0] != paths[2] || remaining[1] != paths[3] {
+		t.Errorf("Expected only %v to remain, got %v", paths[2:], remaining)
+	}
+}
@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// Class describes how a failure should be handled by the caller
+type Class string
+
+const (
+	// ClassTransient marks failures that are expected to succeed when retried, such as network errors or throttling
+	ClassTransient Class = "transient"
+	// ClassPermanent marks failures that will not go away by retrying
+	ClassPermanent Class = "permanent"
+	// ClassNeedsUserAction marks failures that require an operator to fix the machine, configuration or permissions
+	ClassNeedsUserAction Class = "needs-user-action"
+	// ClassUnclassified is used for errors no component has classified; they are retried under the step's policy
+	ClassUnclassified Class = "unclassified"
+)
+
+// ClassifiedError wraps an error with its failure class
+type ClassifiedError struct {
+	Class Class
+	Err   error
+}
+
+func (e *ClassifiedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ClassifiedError) Unwrap() error {
+	return e.Err
+}
+
+// WithClass wraps err with the given class; nil errors stay nil
+func WithClass(class Class, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ClassifiedError{Class: class, Err: err}
+}
+
+// Transient marks err as worth retrying
+func Transient(err error) error {
+	return WithClass(ClassTransient, err)
+}
+
+// Permanent marks err as not worth retrying
+func Permanent(err error) error {
+	return WithClass(ClassPermanent, err)
+}
+
+// NeedsUserAction marks err as requiring operator intervention
+func NeedsUserAction(err error) error {
+	return WithClass(ClassNeedsUserAction, err)
+}
+
+// ClassForStatus classifies an HTTP status code returned by a download or an Azure API call
+func ClassForStatus(statusCode int) Class {
+	switch {
+	case statusCode == http.StatusTooManyRequests, statusCode == http.StatusRequestTimeout, statusCode >= 500:
+		return ClassTransient
+	case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+		return ClassNeedsUserAction
+	case statusCode >= 400:
+		return ClassPermanent
+	default:
+		return ClassUnclassified
+	}
+}
+
+// Classify returns the class of err.
+// Explicitly classified errors win; otherwise Azure response errors, network timeouts and
+// context errors are recognised, and anything else is unclassified.
+func Classify(err error) Class {
+	if err == nil {
+		return ClassUnclassified
+	}
+
+	var classified *ClassifiedError
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) {
+		return ClassForStatus(responseErr.StatusCode)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return ClassPermanent
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ClassTransient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ClassTransient
+	}
+
+	return ClassUnclassified
+}
+
+// IsRetryable reports whether err may succeed on a later attempt
+func IsRetryable(err error) bool {
+	switch Classify(err) {
+	case ClassTransient, ClassUnclassified:
+		return true
+	default:
+		return false
+	}
+}
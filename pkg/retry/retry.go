@@ -0,0 +1,84 @@
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Policy controls how often and how quickly a failed operation is retried
+type Policy struct {
+	// MaxAttempts is the total number of attempts, including the first one; values below 1 mean a single attempt
+	MaxAttempts int
+	// InitialBackoff is the delay before the second attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after every failed attempt; values below 1 keep it constant
+	Multiplier float64
+	// Jitter randomises each delay by up to this fraction in either direction (0.2 = ±20%)
+	Jitter float64
+}
+
+// NoRetry runs an operation exactly once
+var NoRetry = Policy{MaxAttempts: 1}
+
+// DefaultPolicy returns the policy used by steps that download artifacts, talk to Azure or drive systemd
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxAttempts:    3,
+		InitialBackoff: 5 * time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// Attempts returns the total number of attempts allowed by the policy, never less than one
+func (p Policy) Attempts() int {
+	return max(p.MaxAttempts, 1)
+}
+
+// Backoff returns the delay to wait after the given failed attempt (starting at 1)
+func (p Policy) Backoff(attempt int) time.Duration {
+	delay := float64(p.InitialBackoff)
+	for i := 1; i < attempt && p.Multiplier > 1; i++ {
+		delay *= p.Multiplier
+		if p.MaxBackoff > 0 && delay >= float64(p.MaxBackoff) {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// Do runs fn until it succeeds, returns a non-retryable error, the policy runs out of attempts
+// or ctx is done. onRetry, if set, is called before waiting for the next attempt.
+// It returns the number of attempts made and the error of the last attempt.
+func Do(ctx context.Context, policy Policy, fn func(context.Context) error, onRetry func(attempt int, err error, delay time.Duration)) (int, error) {
+	maxAttempts := policy.Attempts()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= maxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return attempt, err
+		}
+
+		delay := policy.Backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		case <-timer.C:
+		}
+	}
+}
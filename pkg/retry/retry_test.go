@@ -0,0 +1,129 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// TestClassify verifies the shared error classification.
+// Test: Classifies explicitly wrapped errors, Azure response errors, context errors and plain errors
+// Expected: Explicit classes survive wrapping and well-known errors map to the expected class
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Class
+	}{
+		{"transient wrapper", Transient(errors.New("boom")), ClassTransient},
+		{"wrapped permanent", fmt.Errorf("outer: %w", Permanent(errors.New("boom"))), ClassPermanent},
+		{"needs user action", NeedsUserAction(errors.New("login required")), ClassNeedsUserAction},
+		{"ARM throttling", &azcore.ResponseError{StatusCode: http.StatusTooManyRequests}, ClassTransient},
+		{"ARM server error", fmt.Errorf("get cluster: %w", &azcore.ResponseError{StatusCode: http.StatusBadGateway}), ClassTransient},
+		{"ARM forbidden", &azcore.ResponseError{StatusCode: http.StatusForbidden}, ClassNeedsUserAction},
+		{"ARM not found", &azcore.ResponseError{StatusCode: http.StatusNotFound}, ClassPermanent},
+		{"cancelled", context.Canceled, ClassPermanent},
+		{"deadline", context.DeadlineExceeded, ClassTransient},
+		{"plain error", errors.New("exit status 1"), ClassUnclassified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Classify(tt.err); got != tt.expected {
+				t.Errorf("Classify() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestPolicyBackoff verifies exponential backoff with a cap.
+// Test: Computes backoff for successive attempts without jitter
+// Expected: Delay doubles per attempt and never exceeds MaxBackoff
+func TestPolicyBackoff(t *testing.T) {
+	policy := Policy{MaxAttempts: 5, InitialBackoff: time.Second, MaxBackoff: 5 * time.Second, Multiplier: 2}
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+
+	for i, want := range expected {
+		if got := policy.Backoff(i + 1); got != want {
+			t.Errorf("Backoff(%d) = %s, want %s", i+1, got, want)
+		}
+	}
+
+	jittered := Policy{InitialBackoff: time.Second, Jitter: 0.5}
+	for i := 0; i < 20; i++ {
+		if got := jittered.Backoff(1); got < 500*time.Millisecond || got > 1500*time.Millisecond {
+			t.Fatalf("Jittered backoff %s outside expected range", got)
+		}
+	}
+}
+
+// TestDo verifies the retry loop.
+// Test: Runs operations that fail with different error classes under a three-attempt policy
+// Expected: Retryable errors are retried until success or exhaustion; permanent errors stop immediately
+func TestDo(t *testing.T) {
+	policy := Policy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"succeeds first time", 0, nil, 1, false},
+		{"recovers from transient errors", 2, Transient(errors.New("timeout")), 3, false},
+		{"retries unclassified errors", 1, errors.New("exit status 1"), 2, false},
+		{"gives up after max attempts", 5, Transient(errors.New("timeout")), 3, true},
+		{"stops on permanent error", 5, Permanent(errors.New("not found")), 1, true},
+		{"stops when user action is needed", 5, NeedsUserAction(errors.New("forbidden")), 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			retries := 0
+			attempts, err := Do(context.Background(), policy, func(context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			}, func(int, error, time.Duration) { retries++ })
+
+			if attempts != tt.wantAttempts || calls != tt.wantAttempts {
+				t.Errorf("attempts = %d, calls = %d, want %d", attempts, calls, tt.wantAttempts)
+			}
+			if retries != tt.wantAttempts-1 {
+				t.Errorf("onRetry called %d times, want %d", retries, tt.wantAttempts-1)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestDo_ContextCancelled verifies that cancellation interrupts the backoff wait.
+// Test: Cancels the context while Do waits before the second attempt
+// Expected: Do returns promptly after the first attempt with its error
+func TestDo_ContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := Policy{MaxAttempts: 3, InitialBackoff: time.Hour}
+
+	start := time.Now()
+	attempts, err := Do(ctx, policy, func(context.Context) error {
+		return Transient(errors.New("timeout"))
+	}, func(int, error, time.Duration) { cancel() })
+
+	if attempts != 1 || err == nil {
+		t.Errorf("Expected one failed attempt, got attempts=%d err=%v", attempts, err)
+	}
+	if time.Since(start) > time.Minute {
+		t.Error("Expected Do to stop waiting when the context is cancelled")
+	}
+}
@@ -0,0 +1,65 @@
+// Package sdnotify implements the systemd service notification protocol (see sd_notify(3)). A
+// Type=notify unit learns from these notifications when the agent is ready, what it is doing, and
+// through watchdog pings whether it still makes progress.
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Notification states understood by systemd
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Status returns the STATUS= state that systemctl status shows as the service's current activity
+func Status(format string, args ...interface{}) string {
+	return "STATUS=" + fmt.Sprintf(format, args...)
+}
+
+// Notify sends states to the service manager over the datagram socket named in NOTIFY_SOCKET. It
+// reports false without error when the process does not run under a unit expecting notifications.
+func Notify(states ...string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// Sockets in the abstract namespace start with @, which net maps to a leading NUL byte
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket %s: %w", socket, err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.Write([]byte(strings.Join(states, "\n"))); err != nil {
+		return false, fmt.Errorf("failed to notify systemd: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the time within which systemd expects a watchdog ping, and whether the
+// watchdog is enabled for this process
+func WatchdogInterval() (time.Duration, bool) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, false
+	}
+	// The watchdog is meant for the main process only, not for children that inherited the variables
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+
+	microseconds, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || microseconds <= 0 {
+		return 0, false
+	}
+	return time.Duration(microseconds) * time.Microsecond, true
+}
@@ -0,0 +1,83 @@
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestNotify verifies that states are sent as a single datagram to the notify socket.
+// Test: Listens on a datagram socket named in NOTIFY_SOCKET, then notifies readiness with a status
+// Expected: The socket receives the newline separated states; without NOTIFY_SOCKET nothing is sent
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Fatalf("Expected no notification without NOTIFY_SOCKET, got sent=%t err=%v", sent, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	sent, err := Notify(Ready, Status("Monitoring node"))
+	if err != nil || !sent {
+		t.Fatalf("Expected the notification to be sent, got sent=%t err=%v", sent, err)
+	}
+
+	buffer := make([]byte, 256)
+	if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("Failed to set read deadline: %v", err)
+	}
+	n, err := conn.Read(buffer)
+	if err != nil {
+		t.Fatalf("Failed to read notification: %v", err)
+	}
+	if got, want := string(buffer[:n]), "READY=1\nSTATUS=Monitoring node"; got != want {
+		t.Errorf("Expected notification %q, got %q", want, got)
+	}
+
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+	if _, err := Notify(Watchdog); err == nil {
+		t.Error("Expected an error for a missing notify socket")
+	}
+}
+
+// TestWatchdogInterval verifies how the watchdog settings of systemd are read.
+// Test: Sets WATCHDOG_USEC and WATCHDOG_PID to various values
+// Expected: The interval is returned only for a valid timeout meant for this process
+func TestWatchdogInterval(t *testing.T) {
+	self := strconv.Itoa(os.Getpid())
+	tests := []struct {
+		name         string
+		usec         string
+		pid          string
+		wantInterval time.Duration
+		wantEnabled  bool
+	}{
+		{name: "disabled", usec: "", pid: "", wantEnabled: false},
+		{name: "enabled", usec: "300000000", pid: self, wantInterval: 5 * time.Minute, wantEnabled: true},
+		{name: "enabled without pid", usec: "2000000", pid: "", wantInterval: 2 * time.Second, wantEnabled: true},
+		{name: "other process", usec: "2000000", pid: "1", wantEnabled: false},
+		{name: "invalid", usec: "soon", pid: self, wantEnabled: false},
+		{name: "zero", usec: "0", pid: self, wantEnabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+
+			interval, enabled := WatchdogInterval()
+			if enabled != tt.wantEnabled || interval != tt.wantInterval {
+				t.Errorf("Expected (%s, %t), got (%s, %t)", tt.wantInterval, tt.wantEnabled, interval, enabled)
+			}
+		})
+	}
+}
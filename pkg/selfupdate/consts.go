@@ -0,0 +1,33 @@
+package selfupdate
+
+import "time"
+
+const (
+	// DefaultBinaryPath is where scripts/install.sh installs the agent
+	DefaultBinaryPath = "/usr/local/bin/aks-flex-node"
+
+	// DefaultConfigPath is the configuration the agent unit runs with
+	DefaultConfigPath = "/etc/aks-flex-node/config.json"
+
+	// ServiceName is the systemd unit running the agent
+	ServiceName = "aks-flex-node-agent"
+
+	// DetachedUnitName is the transient unit the daemon runs self-update in, so that the update
+	// survives the restart of the agent unit
+	DetachedUnitName = "aks-flex-node-self-update"
+
+	// stageDirName is the directory inside agent.stateDir holding staged releases and the previous binary
+	stageDirName = "self-update"
+
+	// previousBinaryName is the copy of the replaced binary kept for rollback
+	previousBinaryName = "aks-flex-node.previous"
+
+	// manifestTimeout bounds fetching the release manifest
+	manifestTimeout = 30 * time.Second
+
+	// versionTimeout bounds running the staged binary to read its version
+	versionTimeout = 30 * time.Second
+)
+
+// healthPollInterval is how often the restarted agent is checked while waiting for it to report healthy
+var healthPollInterval = 5 * time.Second
@@ -0,0 +1,129 @@
+package selfupdate
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Manifest describes the agent release nodes should run
+type Manifest struct {
+	Version  string            `json:"version"`  // release version as printed by aks-flex-node version
+	Binaries map[string]Binary `json:"binaries"` // release files keyed by platform, such as linux/amd64
+}
+
+// Binary is the release file of one platform
+type Binary struct {
+	URL    string `json:"url"`    // HTTP(S) location of the binary or of a tar.gz archive containing it
+	SHA256 string `json:"sha256"` // hex SHA-256 of the downloaded file
+	// Signature is the base64 Ed25519 signature of the downloaded file, required when selfUpdate.publicKey is set
+	Signature string `json:"signature,omitempty"`
+	// ArchiveMember is the binary inside an archive; defaults to aks-flex-node-<os>-<arch>
+	ArchiveMember string `json:"archiveMember,omitempty"`
+}
+
+// fetchManifest downloads and parses the release manifest
+func fetchManifest(ctx context.Context, client *http.Client, url string) (*Manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, manifestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest URL %s: %w", url, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch release manifest from %s: %w", url, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch release manifest from %s: status %d", url, resp.StatusCode)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse release manifest from %s: %w", url, err)
+	}
+	if manifest.Version == "" {
+		return nil, fmt.Errorf("release manifest from %s has no version", url)
+	}
+	return &manifest, nil
+}
+
+// binaryFor returns the release file of platform
+func (m *Manifest) binaryFor(platform string) (Binary, error) {
+	binary, ok := m.Binaries[platform]
+	if !ok {
+		return Binary{}, fmt.Errorf("release %s has no binary for %s", m.Version, platform)
+	}
+	if binary.URL == "" || binary.SHA256 == "" {
+		return Binary{}, fmt.Errorf("release %s binary for %s must set url and sha256", m.Version, platform)
+	}
+	return binary, nil
+}
+
+// verifyChecksum compares the SHA-256 of data with the expected hex digest
+func verifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	actual := hex.EncodeToString(sum[:])
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch: expected sha256 %s, got %s", strings.ToLower(expected), actual)
+	}
+	return nil
+}
+
+// verifySignature checks the base64 Ed25519 signature of data against the PEM public key in keyFile
+func verifySignature(data []byte, signature, keyFile string) error {
+	if signature == "" {
+		return fmt.Errorf("release binary is not signed but selfUpdate.publicKey is set")
+	}
+	publicKey, err := loadPublicKey(keyFile)
+	if err != nil {
+		return err
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid release signature: %w", err)
+	}
+	if !ed25519.Verify(publicKey, data, sig) {
+		return fmt.Errorf("release signature does not match public key %s", keyFile)
+	}
+	return nil
+}
+
+// loadPublicKey reads a PEM encoded PKIX Ed25519 public key
+func loadPublicKey(keyFile string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key %s: %w", keyFile, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("public key %s is not PEM encoded", keyFile)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %s: %w", keyFile, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is not an Ed25519 key", keyFile)
+	}
+	return publicKey, nil
+}
+
+// isGzip reports whether data starts with the gzip magic number
+func isGzip(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
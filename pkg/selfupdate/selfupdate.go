@@ -0,0 +1,331 @@
+// Package selfupdate replaces the agent binary with the release named by a manifest.
+// The new binary is verified, staged and swapped in atomically, the agent unit is restarted, and the
+// previous binary is restored when the restarted agent does not report healthy in time.
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/adminapi"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Options control a single self-update
+type Options struct {
+	CheckOnly     bool          // only report whether an update is available
+	Force         bool          // install the release even if its version is the running one
+	NoRestart     bool          // swap the binary without restarting the agent unit
+	HealthTimeout time.Duration // bound for the restarted agent to report healthy; 0 uses selfUpdate.healthTimeout
+}
+
+// Result is the outcome of a self-update
+type Result struct {
+	CurrentVersion  string `json:"currentVersion"`
+	ReleaseVersion  string `json:"releaseVersion"`
+	UpdateAvailable bool   `json:"updateAvailable"`
+	Updated         bool   `json:"updated,omitempty"`
+	Restarted       bool   `json:"restarted,omitempty"`
+	RolledBack      bool   `json:"rolledBack,omitempty"`
+}
+
+// Updater updates the agent binary
+type Updater struct {
+	config         *config.Config
+	logger         *logrus.Logger
+	currentVersion string
+	binaryPath     string
+	stageDir       string
+	platform       string
+	httpClient     *http.Client
+
+	// Overridable for tests
+	binaryVersion  func(ctx context.Context, binary string) (string, error)
+	serviceExists  func(name string) bool
+	restartService func(name string) error
+	waitHealthy    func(ctx context.Context, version string, timeout time.Duration) error
+}
+
+// New creates an updater for the running agent of version currentVersion
+func New(cfg *config.Config, logger *logrus.Logger, currentVersion string) (*Updater, error) {
+	if cfg.SelfUpdate.ManifestURL == "" {
+		return nil, fmt.Errorf("selfUpdate.manifestUrl is not configured")
+	}
+
+	u := &Updater{
+		config:         cfg,
+		logger:         logger,
+		currentVersion: currentVersion,
+		binaryPath:     DefaultBinaryPath,
+		stageDir:       filepath.Join(cfg.Agent.StateDir, stageDirName),
+		platform:       runtime.GOOS + "/" + runtime.GOARCH,
+		httpClient:     &http.Client{},
+		binaryVersion:  binaryVersion,
+		serviceExists:  utils.ServiceExists,
+		restartService: utils.RestartService,
+	}
+	u.waitHealthy = u.waitForAgent
+	return u, nil
+}
+
+// Run checks the manifest and, unless opts.CheckOnly is set, installs a release whose version
+// differs from the running one
+func (u *Updater) Run(ctx context.Context, opts Options) (*Result, error) {
+	manifest, err := fetchManifest(ctx, u.httpClient, u.config.SelfUpdate.ManifestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		CurrentVersion:  u.currentVersion,
+		ReleaseVersion:  manifest.Version,
+		UpdateAvailable: manifest.Version != u.currentVersion,
+	}
+	if opts.CheckOnly || (!result.UpdateAvailable && !opts.Force) {
+		return result, nil
+	}
+
+	binary, err := manifest.binaryFor(u.platform)
+	if err != nil {
+		return result, err
+	}
+
+	u.logger.Infof("Updating agent from %s to %s", u.currentVersion, manifest.Version)
+	staged, err := u.stage(ctx, manifest.Version, binary)
+	if err != nil {
+		return result, fmt.Errorf("failed to stage release %s: %w", manifest.Version, err)
+	}
+
+	previous := filepath.Join(u.stageDir, previousBinaryName)
+	if err := utils.RunSystemCommand("cp", "-p", u.binaryPath, previous); err != nil {
+		return result, fmt.Errorf("failed to back up %s: %w", u.binaryPath, err)
+	}
+	if err := u.swap(staged); err != nil {
+		return result, err
+	}
+	result.Updated = true
+	u.logger.Infof("Installed agent %s at %s", manifest.Version, u.binaryPath)
+
+	if opts.NoRestart || !u.serviceExists(ServiceName) {
+		u.logger.Infof("Not restarting %s; the new version runs from its next start", ServiceName)
+		return result, nil
+	}
+
+	timeout := opts.HealthTimeout
+	if timeout <= 0 {
+		timeout = u.config.SelfUpdate.HealthTimeout
+	}
+	if err := u.restartService(ServiceName); err != nil {
+		err = fmt.Errorf("failed to restart %s: %w", ServiceName, err)
+		return result, u.rollback(result, previous, err)
+	}
+	result.Restarted = true
+
+	if err := u.waitHealthy(ctx, manifest.Version, timeout); err != nil {
+		return result, u.rollback(result, previous, fmt.Errorf("agent %s did not report healthy: %w", manifest.Version, err))
+	}
+
+	u.logger.Infof("Agent %s is running and healthy", manifest.Version)
+	return result, nil
+}
+
+// stage downloads, verifies and extracts the release binary into the stage directory and checks
+// that it reports the release version
+func (u *Updater) stage(ctx context.Context, version string, binary Binary) (string, error) {
+	dir := filepath.Join(u.stageDir, version)
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("failed to clear stage directory %s: %w", dir, err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create stage directory %s: %w", dir, err)
+	}
+
+	download := filepath.Join(dir, "download")
+	if err := utils.DownloadFile(binary.URL, download); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(download)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", download, err)
+	}
+
+	if err := verifyChecksum(data, binary.SHA256); err != nil {
+		return "", err
+	}
+	if keyFile := u.config.SelfUpdate.PublicKey; keyFile != "" {
+		if err := verifySignature(data, binary.Signature, keyFile); err != nil {
+			return "", err
+		}
+		u.logger.Info("Release signature verified")
+	}
+
+	if isGzip(data) {
+		member := binary.ArchiveMember
+		if member == "" {
+			member = "aks-flex-node-" + strings.ReplaceAll(u.platform, "/", "-")
+		}
+		if data, err = extractMember(data, member); err != nil {
+			return "", err
+		}
+	}
+
+	staged := filepath.Join(dir, filepath.Base(u.binaryPath))
+	if err := os.WriteFile(staged, data, 0o755); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", staged, err)
+	}
+	_ = os.Remove(download)
+
+	stagedVersion, err := u.binaryVersion(ctx, staged)
+	if err != nil {
+		return "", fmt.Errorf("staged binary does not run: %w", err)
+	}
+	if stagedVersion != version {
+		return "", fmt.Errorf("staged binary reports version %s, manifest names %s", stagedVersion, version)
+	}
+	return staged, nil
+}
+
+// swap copies source next to the installed binary and renames it over it, which works while the
+// agent is running and never leaves a partial binary behind
+func (u *Updater) swap(source string) error {
+	temp := u.binaryPath + ".new"
+	if err := utils.RunSystemCommand("cp", "-p", source, temp); err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", source, temp, err)
+	}
+	if err := utils.RunSystemCommand("mv", "-f", temp, u.binaryPath); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", u.binaryPath, err)
+	}
+	return nil
+}
+
+// rollback restores the previous binary and restarts the agent unit, returning cause joined with
+// any rollback failure
+func (u *Updater) rollback(result *Result, previous string, cause error) error {
+	u.logger.Errorf("%v, rolling back to %s", cause, u.currentVersion)
+	if err := u.swap(previous); err != nil {
+		return errors.Join(cause, fmt.Errorf("rollback failed: %w", err))
+	}
+	if err := u.restartService(ServiceName); err != nil {
+		return errors.Join(cause, fmt.Errorf("rollback failed to restart %s: %w", ServiceName, err))
+	}
+	result.RolledBack = true
+	return fmt.Errorf("%w; rolled back to %s", cause, u.currentVersion)
+}
+
+// waitForAgent waits until the agent unit is active and its admin API reports status collected by
+// the given version
+func (u *Updater) waitForAgent(ctx context.Context, version string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(healthPollInterval)
+	defer ticker.Stop()
+
+	lastErr := fmt.Errorf("no status reported yet")
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s: %w", timeout, lastErr)
+		case <-ticker.C:
+		}
+
+		if !utils.IsServiceActive(ServiceName) {
+			lastErr = fmt.Errorf("%s is not active", ServiceName)
+			continue
+		}
+		socket, ok := adminapi.FindSocket(u.config)
+		if !ok {
+			lastErr = fmt.Errorf("admin socket not available")
+			continue
+		}
+		nodeStatus, err := adminapi.NewClient(socket).Status(ctx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if nodeStatus.AgentVersion != version {
+			lastErr = fmt.Errorf("agent reports version %s", nodeStatus.AgentVersion)
+			continue
+		}
+		return nil
+	}
+}
+
+// Detach runs self-update of the installed agent in a transient systemd unit, outside the agent unit
+// whose restart it performs
+func Detach() error {
+	if output, err := utils.RunCommandWithOutput("systemd-run", detachArgs()...); err != nil {
+		return fmt.Errorf("failed to start %s: %w: %s", DetachedUnitName, err, strings.TrimSpace(output))
+	}
+	return nil
+}
+
+// detachArgs returns the systemd-run arguments of Detach. The sudoers rules allow exactly this
+// command, so the paths are fixed rather than taken from the running agent.
+func detachArgs() []string {
+	return []string{"--unit", DetachedUnitName, "--collect", DefaultBinaryPath, "self-update", "--config", DefaultConfigPath}
+}
+
+// binaryVersion runs "<binary> version" and returns the version it prints
+func binaryVersion(ctx context.Context, binary string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, binary, "version").CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s version failed: %w", binary, err)
+	}
+	return parseVersion(string(output))
+}
+
+// parseVersion extracts the version from the output of the version command
+func parseVersion(output string) (string, error) {
+	for _, line := range strings.Split(output, "\n") {
+		if version, ok := strings.CutPrefix(strings.TrimSpace(line), "Version:"); ok {
+			return strings.TrimSpace(version), nil
+		}
+	}
+	return "", fmt.Errorf("no version in output %q", strings.TrimSpace(output))
+}
+
+// extractMember returns the content of a regular file in a gzip compressed tar archive
+func extractMember(data []byte, member string) ([]byte, error) {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open release archive: %w", err)
+	}
+	defer func() {
+		_ = gzipReader.Close()
+	}()
+
+	want := path.Clean(strings.TrimPrefix(member, "./"))
+	tarReader := tar.NewReader(gzipReader)
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s not found in release archive", member)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release archive: %w", err)
+		}
+		if header.Typeflag == tar.TypeReg && path.Clean(strings.TrimPrefix(header.Name, "./")) == want {
+			return io.ReadAll(tarReader)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils/sudoerstest"
+)
+
+// tarGz returns a gzip compressed tar archive holding a single file
+func tarGz(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+	if err := tarWriter.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("failed to write tar header: %v", err)
+	}
+	if _, err := tarWriter.Write(content); err != nil {
+		t.Fatalf("failed to write tar content: %v", err)
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatalf("failed to close tar writer: %v", err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// sha256Hex returns the hex SHA-256 of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// TestParseVersion verifies reading the version from the output of the version command.
+// Test: Parses version command output with and without a version line
+// Expected: The version is returned, or an error when none is printed
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{name: "version output", output: "AKS Flex Node Agent\nVersion: v0.0.12\nGit Commit: abc\n", want: "v0.0.12"},
+		{name: "no version", output: "unknown command \"version\"\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVersion(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseVersion() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRun verifies the self-update flow against a release server.
+// Test: Serves manifests with raw and archived binaries, bad checksums, signatures and unhealthy releases
+// Expected: Only verified releases are installed, the unit is restarted, and an unhealthy release is rolled back
+func TestRun(t *testing.T) {
+	newBinary := []byte("new agent binary")
+	archive := tarGz(t, "aks-flex-node-linux-amd64", newBinary)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "release.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, newBinary))
+
+	tests := []struct {
+		name           string
+		manifest       func(url string) Manifest
+		publicKey      string
+		opts           Options
+		stagedVersion  string
+		serviceExists  bool
+		healthErr      error
+		wantErr        string
+		wantResult     Result
+		wantBinary     string
+		wantRestarts   int
+		wantHealthWait bool
+	}{
+		{
+			name:       "up to date",
+			manifest:   func(url string) Manifest { return Manifest{Version: "v1"} },
+			wantResult: Result{CurrentVersion: "v1", ReleaseVersion: "v1"},
+			wantBinary: "old agent binary",
+		},
+		{
+			name:       "check only",
+			manifest:   func(url string) Manifest { return Manifest{Version: "v2"} },
+			opts:       Options{CheckOnly: true},
+			wantResult: Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true},
+			wantBinary: "old agent binary",
+		},
+		{
+			name: "raw binary without service",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/raw", SHA256: sha256Hex(newBinary)}}}
+			},
+			stagedVersion: "v2",
+			wantResult:    Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true, Updated: true},
+			wantBinary:    string(newBinary),
+		},
+		{
+			name: "archive with restart",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/archive", SHA256: sha256Hex(archive)}}}
+			},
+			stagedVersion:  "v2",
+			serviceExists:  true,
+			wantResult:     Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true, Updated: true, Restarted: true},
+			wantBinary:     string(newBinary),
+			wantRestarts:   1,
+			wantHealthWait: true,
+		},
+		{
+			name: "checksum mismatch",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/raw", SHA256: strings.Repeat("0", 64)}}}
+			},
+			stagedVersion: "v2",
+			wantErr:       "checksum mismatch",
+			wantResult:    Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true},
+			wantBinary:    "old agent binary",
+		},
+		{
+			name: "staged binary reports another version",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/raw", SHA256: sha256Hex(newBinary)}}}
+			},
+			stagedVersion: "v3",
+			wantErr:       "reports version v3",
+			wantResult:    Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true},
+			wantBinary:    "old agent binary",
+		},
+		{
+			name: "no binary for platform",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/arm64": {URL: url + "/raw", SHA256: sha256Hex(newBinary)}}}
+			},
+			wantErr:    "no binary for linux/amd64",
+			wantResult: Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true},
+			wantBinary: "old agent binary",
+		},
+		{
+			name: "valid signature",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/raw", SHA256: sha256Hex(newBinary), Signature: signature}}}
+			},
+			publicKey:     keyFile,
+			stagedVersion: "v2",
+			wantResult:    Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true, Updated: true},
+			wantBinary:    string(newBinary),
+		},
+		{
+			name: "missing signature",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/raw", SHA256: sha256Hex(newBinary)}}}
+			},
+			publicKey:  keyFile,
+			wantErr:    "not signed",
+			wantResult: Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true},
+			wantBinary: "old agent binary",
+		},
+		{
+			name: "unhealthy release is rolled back",
+			manifest: func(url string) Manifest {
+				return Manifest{Version: "v2", Binaries: map[string]Binary{"linux/amd64": {URL: url + "/raw", SHA256: sha256Hex(newBinary)}}}
+			},
+			stagedVersion:  "v2",
+			serviceExists:  true,
+			healthErr:      errors.New("timed out"),
+			wantErr:        "rolled back to v1",
+			wantResult:     Result{CurrentVersion: "v1", ReleaseVersion: "v2", UpdateAvailable: true, Updated: true, Restarted: true, RolledBack: true},
+			wantBinary:     "old agent binary",
+			wantRestarts:   2,
+			wantHealthWait: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/manifest.json":
+					_ = json.NewEncoder(w).Encode(tt.manifest(server.URL))
+				case "/raw":
+					_, _ = w.Write(newBinary)
+				case "/archive":
+					_, _ = w.Write(archive)
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			binaryPath := filepath.Join(dir, "bin", "aks-flex-node")
+			if err := os.MkdirAll(filepath.Dir(binaryPath), 0o755); err != nil {
+				t.Fatalf("failed to create bin dir: %v", err)
+			}
+			if err := os.WriteFile(binaryPath, []byte("old agent binary"), 0o755); err != nil {
+				t.Fatalf("failed to write binary: %v", err)
+			}
+
+			cfg := &config.Config{
+				Agent:      config.AgentConfig{StateDir: filepath.Join(dir, "state")},
+				SelfUpdate: config.SelfUpdateConfig{ManifestURL: server.URL + "/manifest.json", PublicKey: tt.publicKey, HealthTimeout: time.Minute},
+			}
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+			updater, err := New(cfg, logger, "v1")
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			restarts := 0
+			healthWaited := false
+			updater.binaryPath = binaryPath
+			updater.platform = "linux/amd64"
+			updater.binaryVersion = func(ctx context.Context, binary string) (string, error) { return tt.stagedVersion, nil }
+			updater.serviceExists = func(string) bool { return tt.serviceExists }
+			updater.restartService = func(string) error { restarts++; return nil }
+			updater.waitHealthy = func(ctx context.Context, version string, timeout time.Duration) error {
+				healthWaited = true
+				if version != "v2" || timeout != time.Minute {
+					t.Errorf("waitHealthy(%s, %s), want v2 and the configured timeout", version, timeout)
+				}
+				return tt.healthErr
+			}
+
+			result, err := updater.Run(context.Background(), tt.opts)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Run() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if result == nil || *result != tt.wantResult {
+				t.Errorf("Run() result = %+v, want %+v", result, tt.wantResult)
+			}
+
+			data, err := os.ReadFile(binaryPath)
+			if err != nil {
+				t.Fatalf("failed to read binary: %v", err)
+			}
+			if string(data) != tt.wantBinary {
+				t.Errorf("installed binary = %q, want %q", data, tt.wantBinary)
+			}
+			if restarts != tt.wantRestarts {
+				t.Errorf("restarts = %d, want %d", restarts, tt.wantRestarts)
+			}
+			if healthWaited != tt.wantHealthWait {
+				t.Errorf("health waited = %v, want %v", healthWaited, tt.wantHealthWait)
+			}
+		})
+	}
+}
+
+// TestDetachArgsMatchSudoers verifies that the detached self-update is allowed by the shipped sudoers rules.
+// Test: Checks the systemd-run command of Detach and variants with another binary or command against the rules
+// Expected: Only the exact command of Detach is allowed
+func TestDetachArgsMatchSudoers(t *testing.T) {
+	rules := sudoerstest.Rules(t)
+	if !sudoerstest.Allows(rules, "systemd-run", detachArgs()...) {
+		t.Errorf("Expected sudoers to allow systemd-run %s", strings.Join(detachArgs(), " "))
+	}
+
+	for _, args := range [][]string{
+		{"--unit", DetachedUnitName, "/bin/sh", "-c", "id"},
+		{"--unit", DetachedUnitName, "--collect", "/tmp/aks-flex-node", "self-update", "--config", DefaultConfigPath},
+		{"--unit", DetachedUnitName, "--collect", DefaultBinaryPath, "self-update", "--config", "/tmp/config.json"},
+	} {
+		if sudoerstest.Allows(rules, "systemd-run", args...) {
+			t.Errorf("Expected sudoers to reject systemd-run %s", strings.Join(args, " "))
+		}
+	}
+}
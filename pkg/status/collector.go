@@ -3,6 +3,7 @@ package status
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"os/exec"
 	"os/user"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/remediation"
 	"go.goms.io/aks/AKSFlexNode/pkg/utils"
 )
 
@@ -58,9 +60,35 @@ func (c *Collector) CollectStatus(ctx context.Context) (*NodeStatus, error) {
 	}
 	status.ArcStatus = arcStatus
 
+	// Report the remediation circuit breaker kept in the agent state directory
+	if c.config != nil {
+		breaker, err := remediation.ReadState(remediation.Path(c.config.Agent.StateDir))
+		if err != nil {
+			c.logger.Warnf("Failed to read remediation state: %v", err)
+		} else {
+			status.Remediation = breaker
+		}
+	}
+
 	return status, nil
 }
 
+// ComponentVersions are the versions reported by the installed node component binaries, or "unknown"
+type ComponentVersions struct {
+	Kubelet    string `json:"kubelet"`
+	Containerd string `json:"containerd"`
+	Runc       string `json:"runc"`
+}
+
+// CollectVersions reports the versions of the installed kubelet, containerd and runc binaries
+func (c *Collector) CollectVersions(ctx context.Context) ComponentVersions {
+	return ComponentVersions{
+		Kubelet:    c.getKubeletVersion(ctx),
+		Containerd: c.getContainerdVersion(ctx),
+		Runc:       c.getRuncVersion(ctx),
+	}
+}
+
 // getKubeletVersion gets the kubelet version
 func (c *Collector) getKubeletVersion(ctx context.Context) string {
 	output, err := c.runCommand(ctx, "/usr/local/bin/kubelet", "--version")
@@ -193,13 +221,30 @@ func (c *Collector) parseArcShowOutput(status *ArcStatus, output string) {
 
 // isKubeletReady checks if the kubelet reports the node as Ready
 func (c *Collector) isKubeletReady(ctx context.Context) string {
-	hostName, err := os.Hostname()
+	condition, err := c.nodeReadyCondition(ctx)
 	if err != nil {
-		c.logger.Warnf("Failed to get hostname: %v", err)
+		// Common in dev: agent runs as ubuntu and can't read root:aks-flex-node 0640 kubeconfig.
+		c.logger.Errorf("%v", err)
+		return "Unknown"
+	}
+
+	switch condition {
+	case "True":
+		return "Ready"
+	case "False":
+		return "NotReady"
+	default:
 		return "Unknown"
 	}
+}
+
+// nodeReadyCondition returns the status of the node's Ready condition: True, False or Unknown
+func (c *Collector) nodeReadyCondition(ctx context.Context) (string, error) {
+	hostName, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
 
-	// Readiness condition status is one of: True, False, Unknown
 	args := []string{
 		"--kubeconfig",
 		"/var/lib/kubelet/kubeconfig",
@@ -212,19 +257,37 @@ func (c *Collector) isKubeletReady(ctx context.Context) string {
 
 	output, err := utils.RunCommandWithOutput("kubectl", args...)
 	if err != nil {
-		// Common in dev: agent runs as ubuntu and can't read root:aks-flex-node 0640 kubeconfig.
-		// Retry with sudo (non-interactive) if we see a permissions failure.
-		c.logger.Errorf("kubectl command failed: %v with output: %s", err, output)
-		return "Unknown"
+		return "", fmt.Errorf("kubectl command failed: %v with output: %s", err, output)
 	}
+	return strings.TrimSpace(output), nil
+}
 
-	switch strings.TrimSpace(output) {
-	case "True":
-		return "Ready"
-	case "False":
-		return "NotReady"
-	default:
-		return "Unknown"
+// WaitForNodeReady polls the node's Ready condition every interval until it is True.
+// It returns an error wrapping context.DeadlineExceeded when the node is not Ready within timeout.
+func (c *Collector) WaitForNodeReady(ctx context.Context, timeout, interval time.Duration) error {
+	c.logger.Infof("Waiting up to %s for the node to become Ready", timeout)
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		condition, err := c.nodeReadyCondition(waitCtx)
+		if err != nil {
+			c.logger.Debugf("Node readiness not available yet: %v", err)
+		}
+		if condition == "True" {
+			c.logger.Info("Node is Ready")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-waitCtx.Done():
+			return fmt.Errorf("node did not become Ready within %s: %w", timeout, context.DeadlineExceeded)
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -244,34 +307,15 @@ func (c *Collector) NeedsBootstrap(ctx context.Context) bool {
 		return true
 	}
 
-	// Check if status indicates unhealthy conditions
-	if !nodeStatus.KubeletRunning {
-		c.logger.Info("Status file indicates kubelet not running - bootstrap needed")
-		return true
-	}
-
-	// Check if Arc status is unhealthy (if configured)
-	if c.config != nil && c.config.GetArcMachineName() != "" {
-		if !nodeStatus.ArcStatus.Connected {
-			c.logger.Info("Status file indicates Arc agent not connected - bootstrap needed")
-			return true
-		}
-	}
-
-	// Check if status is too old (older than 5 minutes might indicate daemon issues)
-	if time.Since(nodeStatus.LastUpdated) > 5*time.Minute {
-		c.logger.Info("Status file is stale (older than 5 minutes) - bootstrap needed")
-		return true
-	}
-
-	// Check for essential component versions being unknown (indicates collection failures)
-	if nodeStatus.KubeletVersion == "unknown" || nodeStatus.KubeletVersion == "" {
-		c.logger.Info("Status file indicates kubelet version unknown - bootstrap needed")
+	// Check if status indicates conditions only a bootstrap can repair
+	if reasons := bootstrapReasons(&nodeStatus, c.config); len(reasons) > 0 {
+		c.logger.Infof("Status file indicates %s - bootstrap needed", reasons[0])
 		return true
 	}
 
-	if nodeStatus.RuncVersion == "unknown" || nodeStatus.RuncVersion == "" {
-		c.logger.Info("Status file indicates runc version unknown - bootstrap needed")
+	// Check if status is too old, which might indicate daemon issues
+	if threshold := staleThreshold(c.config); time.Since(nodeStatus.LastUpdated) > threshold {
+		c.logger.Infof("Status file is stale (older than %s) - bootstrap needed", threshold)
 		return true
 	}
 
@@ -0,0 +1,181 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+)
+
+// Health summarizes node status for operators and monitoring scripts
+type Health string
+
+const (
+	HealthHealthy        Health = "healthy"
+	HealthDegraded       Health = "degraded"
+	HealthNeedsBootstrap Health = "needs-bootstrap"
+	HealthNeedsAttention Health = "needs-attention"
+)
+
+// Exit codes of the status command for each health state; 1 is left for command errors
+const (
+	ExitCodeHealthy        = 0
+	ExitCodeDegraded       = 2
+	ExitCodeNeedsBootstrap = 3
+	ExitCodeNeedsAttention = 4
+)
+
+// Sources a status report can come from
+const (
+	SourceDaemon = "daemon"
+	SourceAPI    = "daemon-api"
+	SourceLive   = "live"
+)
+
+// staleStatusAge is the age after which the daemon's status no longer reflects the node,
+// unless agent.daemon.staleThreshold sets another one
+const staleStatusAge = 5 * time.Minute
+
+// staleThreshold returns the configured status staleness threshold
+func staleThreshold(cfg *config.Config) time.Duration {
+	if cfg != nil && cfg.Agent.Daemon.StaleThreshold > 0 {
+		return cfg.Agent.Daemon.StaleThreshold
+	}
+	return staleStatusAge
+}
+
+// ExitCode returns the status command exit code of the health state
+func (h Health) ExitCode() int {
+	switch h {
+	case HealthHealthy:
+		return ExitCodeHealthy
+	case HealthDegraded:
+		return ExitCodeDegraded
+	case HealthNeedsAttention:
+		return ExitCodeNeedsAttention
+	default:
+		return ExitCodeNeedsBootstrap
+	}
+}
+
+// HealthReport is a node status together with its assessed health
+type HealthReport struct {
+	Health     Health      `json:"health"`
+	Reasons    []string    `json:"reasons,omitempty"`
+	Source     string      `json:"source"`
+	StatusFile string      `json:"statusFile,omitempty"`
+	AgeSeconds int64       `json:"ageSeconds"`
+	Stale      bool        `json:"stale"`
+	Status     *NodeStatus `json:"status"`
+}
+
+// FindStatusFile returns the status file of this user if it exists, otherwise the one of
+// the aks-flex-node service user, so that the daemon's status is found from any account
+func FindStatusFile() string {
+	candidates := []string{
+		GetStatusFilePath(),
+		filepath.Join("/run/aks-flex-node", "status.json"),
+		filepath.Join("/tmp/aks-flex-node", "status.json"),
+	}
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return candidates[0]
+}
+
+// ReadStatusFile reads the status file written by the daemon.
+// It returns os.ErrNotExist (wrapped) when no daemon has written a status yet.
+func ReadStatusFile(path string) (*NodeStatus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status file %s: %w", path, err)
+	}
+
+	var nodeStatus NodeStatus
+	if err := json.Unmarshal(data, &nodeStatus); err != nil {
+		return nil, fmt.Errorf("failed to parse status file %s: %w", path, err)
+	}
+	return &nodeStatus, nil
+}
+
+// IsNotExist reports whether err means the status file has not been written
+func IsNotExist(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
+
+// Assess evaluates the health of a node status collected from source at now
+func Assess(nodeStatus *NodeStatus, cfg *config.Config, source string, now time.Time) *HealthReport {
+	report := &HealthReport{
+		Health: HealthHealthy,
+		Source: source,
+		Status: nodeStatus,
+	}
+
+	age := now.Sub(nodeStatus.LastUpdated)
+	if age < 0 {
+		age = 0
+	}
+	report.AgeSeconds = int64(age.Seconds())
+	report.Stale = age > staleThreshold(cfg)
+
+	// A tripped breaker means the daemon gave up, so only an operator can repair the node
+	if breaker := nodeStatus.Remediation; breaker != nil && breaker.Tripped {
+		report.Health = HealthNeedsAttention
+		report.Reasons = append([]string{fmt.Sprintf("automatic remediation stopped, the node needs manual repair: %s", breaker.Reason)},
+			bootstrapReasons(nodeStatus, cfg)...)
+		return report
+	}
+
+	if reasons := bootstrapReasons(nodeStatus, cfg); len(reasons) > 0 {
+		report.Health = HealthNeedsBootstrap
+		report.Reasons = reasons
+		return report
+	}
+
+	if report.Stale {
+		report.Reasons = append(report.Reasons,
+			fmt.Sprintf("status is stale (last updated %s ago), the daemon may not be running", age.Round(time.Second)))
+	}
+	if !nodeStatus.ContainerdRunning {
+		report.Reasons = append(report.Reasons, "containerd is not running")
+	}
+	if nodeStatus.KubeletReady != "Ready" {
+		report.Reasons = append(report.Reasons, fmt.Sprintf("node readiness is %s", nodeStatus.KubeletReady))
+	}
+	for _, component := range nodeStatus.Drift {
+		report.Reasons = append(report.Reasons,
+			fmt.Sprintf("component %s drifted from its desired state: %s", component.Name, component.Summary()))
+	}
+	if nodeStatus.ConfigError != "" {
+		report.Reasons = append(report.Reasons,
+			fmt.Sprintf("configuration reload was rejected, the previous configuration is still active: %s", nodeStatus.ConfigError))
+	}
+	if len(report.Reasons) > 0 {
+		report.Health = HealthDegraded
+	}
+	return report
+}
+
+// bootstrapReasons lists the conditions of a node status that only a bootstrap can repair
+func bootstrapReasons(nodeStatus *NodeStatus, cfg *config.Config) []string {
+	var reasons []string
+	if !nodeStatus.KubeletRunning {
+		reasons = append(reasons, "kubelet not running")
+	}
+	if cfg != nil && cfg.GetArcMachineName() != "" && !nodeStatus.ArcStatus.Connected {
+		reasons = append(reasons, "Arc agent not connected")
+	}
+	if nodeStatus.KubeletVersion == "unknown" || nodeStatus.KubeletVersion == "" {
+		reasons = append(reasons, "kubelet version unknown")
+	}
+	if nodeStatus.RuncVersion == "unknown" || nodeStatus.RuncVersion == "" {
+		reasons = append(reasons, "runc version unknown")
+	}
+	return reasons
+}
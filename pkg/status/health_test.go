@@ -0,0 +1,177 @@
+package status
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/remediation"
+)
+
+// healthyNodeStatus returns a status of a fully bootstrapped, Ready node updated at now
+func healthyNodeStatus(now time.Time) *NodeStatus {
+	return &NodeStatus{
+		KubeletVersion:    "v1.32.7",
+		RuncVersion:       "1.1.12",
+		ContainerdVersion: "1.7.20",
+		KubeletRunning:    true,
+		KubeletReady:      "Ready",
+		ContainerdRunning: true,
+		ArcStatus:         ArcStatus{Connected: true, MachineName: "edge-node"},
+		LastUpdated:       now,
+	}
+}
+
+// TestAssess verifies the health classification of node status.
+// Test: Assesses healthy, degraded, stale and broken node statuses
+// Expected: Each status maps to the expected health, reasons and exit code
+func TestAssess(t *testing.T) {
+	now := time.Now()
+	arcConfig := &config.Config{Azure: config.AzureConfig{Arc: &config.ArcConfig{MachineName: "edge-node"}}}
+
+	tests := []struct {
+		name        string
+		mutate      func(*NodeStatus)
+		cfg         *config.Config
+		wantHealth  Health
+		wantReasons int
+		wantStale   bool
+		wantCode    int
+	}{
+		{name: "healthy", mutate: func(*NodeStatus) {}, cfg: arcConfig, wantHealth: HealthHealthy, wantCode: 0},
+		{
+			name:        "not ready",
+			mutate:      func(s *NodeStatus) { s.KubeletReady = "NotReady" },
+			wantHealth:  HealthDegraded,
+			wantReasons: 1,
+			wantCode:    2,
+		},
+		{
+			name:        "stale and containerd stopped",
+			mutate:      func(s *NodeStatus) { s.LastUpdated = now.Add(-10 * time.Minute); s.ContainerdRunning = false },
+			wantHealth:  HealthDegraded,
+			wantReasons: 2,
+			wantStale:   true,
+			wantCode:    2,
+		},
+		{
+			name:       "within a longer configured stale threshold",
+			mutate:     func(s *NodeStatus) { s.LastUpdated = now.Add(-10 * time.Minute) },
+			cfg:        &config.Config{Agent: config.AgentConfig{Daemon: config.DaemonConfig{StaleThreshold: 15 * time.Minute}}},
+			wantHealth: HealthHealthy,
+			wantCode:   0,
+		},
+		{
+			name:        "beyond a shorter configured stale threshold",
+			mutate:      func(s *NodeStatus) { s.LastUpdated = now.Add(-2 * time.Minute) },
+			cfg:         &config.Config{Agent: config.AgentConfig{Daemon: config.DaemonConfig{StaleThreshold: time.Minute}}},
+			wantHealth:  HealthDegraded,
+			wantReasons: 1,
+			wantStale:   true,
+			wantCode:    2,
+		},
+		{
+			name: "drifted components",
+			mutate: func(s *NodeStatus) {
+				s.Drift = []drift.Component{
+					{Name: "containerd", Differences: []drift.Difference{{Kind: drift.KindFile, Target: "/etc/containerd/config.toml"}}},
+					{Name: "cni", Differences: []drift.Difference{{Kind: drift.KindFile, Target: "/etc/cni/net.d/10-bridge.conf", Actual: drift.Missing}}},
+				}
+			},
+			wantHealth:  HealthDegraded,
+			wantReasons: 2,
+			wantCode:    2,
+		},
+		{
+			name: "remediation stopped",
+			mutate: func(s *NodeStatus) {
+				s.KubeletRunning = false
+				s.Remediation = &remediation.State{Tripped: true, Reason: "3 remediation attempts within 1h0m0s did not keep the node healthy"}
+			},
+			wantHealth:  HealthNeedsAttention,
+			wantReasons: 2,
+			wantCode:    4,
+		},
+		{
+			name:        "rejected configuration reload",
+			mutate:      func(s *NodeStatus) { s.ConfigError = "invalid log level: verbose" },
+			wantHealth:  HealthDegraded,
+			wantReasons: 1,
+			wantCode:    2,
+		},
+		{
+			name:        "kubelet stopped",
+			mutate:      func(s *NodeStatus) { s.KubeletRunning = false; s.KubeletReady = "Unknown" },
+			wantHealth:  HealthNeedsBootstrap,
+			wantReasons: 1,
+			wantCode:    3,
+		},
+		{
+			name:        "arc disconnected",
+			mutate:      func(s *NodeStatus) { s.ArcStatus.Connected = false },
+			cfg:         arcConfig,
+			wantHealth:  HealthNeedsBootstrap,
+			wantReasons: 1,
+			wantCode:    3,
+		},
+		{
+			name:       "arc disconnected without arc config",
+			mutate:     func(s *NodeStatus) { s.ArcStatus.Connected = false },
+			wantHealth: HealthHealthy,
+			wantCode:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeStatus := healthyNodeStatus(now)
+			tt.mutate(nodeStatus)
+
+			report := Assess(nodeStatus, tt.cfg, SourceDaemon, now)
+			if report.Health != tt.wantHealth || len(report.Reasons) != tt.wantReasons || report.Stale != tt.wantStale {
+				t.Errorf("Expected health=%s reasons=%d stale=%v, got %+v", tt.wantHealth, tt.wantReasons, tt.wantStale, report)
+			}
+			if code := report.Health.ExitCode(); code != tt.wantCode {
+				t.Errorf("Expected exit code %d, got %d", tt.wantCode, code)
+			}
+		})
+	}
+}
+
+// TestReadStatusFile verifies reading the daemon's status file.
+// Test: Reads a missing file, an invalid file and a valid file
+// Expected: A missing file is reported as not existing, an invalid file fails, a valid file round-trips
+func TestReadStatusFile(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := ReadStatusFile(filepath.Join(dir, "missing.json")); !IsNotExist(err) {
+		t.Errorf("Expected not-exist error for missing file, got: %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadStatusFile(invalid); err == nil || IsNotExist(err) {
+		t.Errorf("Expected parse error for invalid file, got: %v", err)
+	}
+
+	want := healthyNodeStatus(time.Now().UTC().Truncate(time.Second))
+	valid := filepath.Join(dir, "status.json")
+	if err := os.WriteFile(valid, []byte(`{"kubeletVersion":"v1.32.7","runcVersion":"1.1.12","containerdVersion":"1.7.20",`+
+		`"kubeletRunning":true,"kubeletReady":"Ready","containerdRunning":true,`+
+		`"arcStatus":{"connected":true,"machineName":"edge-node"},"lastUpdated":"`+want.LastUpdated.Format(time.RFC3339)+`"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadStatusFile(valid)
+	if err != nil {
+		t.Fatalf("Expected valid status file to be read, got: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
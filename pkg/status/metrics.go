@@ -0,0 +1,423 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// metricsPrefix is the namespace of every metric the agent exports
+const metricsPrefix = "aks_flex_node_"
+
+// metricsContentType is the content type of the Prometheus text exposition format
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// runMetricsFileName is the file in the agent state directory that accumulates run metrics, so that
+// runs of the bootstrap and unbootstrap commands are exported by the daemon as well
+const runMetricsFileName = "run-metrics.json"
+
+// Run outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Upper bounds in seconds of the histogram buckets
+var (
+	stepDurationBuckets       = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200}
+	collectionDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+)
+
+// Histogram counts observations in buckets with fixed upper bounds
+type Histogram struct {
+	Buckets []uint64 `json:"buckets"` // observations per bucket, not cumulative; the last bucket is +Inf
+	Count   uint64   `json:"count"`
+	Sum     float64  `json:"sum"`
+}
+
+// observe adds value to the histogram with the given bucket bounds
+func (h *Histogram) observe(bounds []float64, value float64) {
+	if len(h.Buckets) != len(bounds)+1 {
+		// Counts kept for other bounds cannot be carried over
+		*h = Histogram{Buckets: make([]uint64, len(bounds)+1)}
+	}
+	h.Buckets[sort.SearchFloat64s(bounds, value)]++
+	h.Count++
+	h.Sum += value
+}
+
+// RunMetrics are the accumulated bootstrap and unbootstrap runs on the node
+type RunMetrics struct {
+	Operations map[string]*OperationMetrics `json:"operations"`
+}
+
+// OperationMetrics are the accumulated runs of a single operation
+type OperationMetrics struct {
+	Succeeded   uint64                `json:"succeeded"`
+	Failed      uint64                `json:"failed"`
+	LastRun     time.Time             `json:"lastRun"`
+	LastSuccess bool                  `json:"lastSuccess"`
+	Steps       map[string]*Histogram `json:"steps"` // step durations in seconds by step name
+}
+
+// RunMetricsPath returns the run metrics file in stateDir
+func RunMetricsPath(stateDir string) string {
+	return filepath.Join(stateDir, runMetricsFileName)
+}
+
+// ReadRunMetrics reads the run metrics from path; a missing file means no run was recorded yet
+func ReadRunMetrics(path string) (*RunMetrics, error) {
+	runs := &RunMetrics{Operations: map[string]*OperationMetrics{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return runs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run metrics %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, runs); err != nil {
+		return nil, fmt.Errorf("failed to parse run metrics %s: %w", path, err)
+	}
+	if runs.Operations == nil {
+		runs.Operations = map[string]*OperationMetrics{}
+	}
+	return runs, nil
+}
+
+// RecordRun adds the outcome and step durations of a run that finished at now to the run metrics in
+// stateDir. A nil result is a run that failed before executing any step.
+func RecordRun(stateDir, operation string, result *bootstrapper.ExecutionResult, now time.Time) error {
+	path := RunMetricsPath(stateDir)
+	runs, err := ReadRunMetrics(path)
+	if err != nil {
+		return err
+	}
+
+	op := runs.Operations[operation]
+	if op == nil {
+		op = &OperationMetrics{}
+		runs.Operations[operation] = op
+	}
+	op.LastRun = now
+	op.LastSuccess = result != nil && result.Success
+	if op.LastSuccess {
+		op.Succeeded++
+	} else {
+		op.Failed++
+	}
+
+	if result != nil {
+		if op.Steps == nil {
+			op.Steps = map[string]*Histogram{}
+		}
+		for _, step := range result.StepResults {
+			if step.SkipReason != "" {
+				continue // skipped steps did no work
+			}
+			histogram := op.Steps[step.StepName]
+			if histogram == nil {
+				histogram = &Histogram{}
+				op.Steps[step.StepName] = histogram
+			}
+			histogram.observe(stepDurationBuckets, step.Duration.Seconds())
+		}
+	}
+
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run metrics: %w", err)
+	}
+	if err := os.MkdirAll(stateDir, 0750); err != nil {
+		return fmt.Errorf("failed to create state directory %s: %w", stateDir, err)
+	}
+	// World-readable so that runs recorded as root stay readable for the daemon user
+	if err := utils.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write run metrics %s: %w", path, err)
+	}
+	return nil
+}
+
+// BuildInfo identifies the agent build in the metrics
+type BuildInfo struct {
+	Version   string
+	GitCommit string
+	BuildTime string
+}
+
+// remediationKey identifies a remediation counter
+type remediationKey struct {
+	operation string
+	outcome   string
+}
+
+// Metrics are the daemon's Prometheus metrics. Node gauges are derived from the latest collected
+// NodeStatus and run metrics are read from the agent state directory when scraped.
+type Metrics struct {
+	mu               sync.Mutex
+	config           *config.Config
+	build            BuildInfo
+	status           *NodeStatus
+	collections      Histogram
+	collectionErrors uint64
+	remediations     map[remediationKey]uint64
+	now              func() time.Time
+}
+
+// NewMetrics creates the metrics of a daemon running with cfg
+func NewMetrics(cfg *config.Config, build BuildInfo) *Metrics {
+	return &Metrics{
+		config:       cfg,
+		build:        build,
+		collections:  Histogram{Buckets: make([]uint64, len(collectionDurationBuckets)+1)},
+		remediations: map[remediationKey]uint64{},
+		now:          time.Now,
+	}
+}
+
+// SetConfig replaces the configuration used to assess the node, after the daemon reloaded it
+func (m *Metrics) SetConfig(cfg *config.Config) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config = cfg
+}
+
+// SetStatus records the latest collected node status
+func (m *Metrics) SetStatus(nodeStatus *NodeStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = nodeStatus
+}
+
+// ObserveCollection records how long a status collection took and whether it failed
+func (m *Metrics) ObserveCollection(duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.collections.observe(collectionDurationBuckets, duration.Seconds())
+	if err != nil {
+		m.collectionErrors++
+	}
+}
+
+// ObserveRemediation counts an automatic remediation attempt such as an auto-bootstrap or drift repair
+func (m *Metrics) ObserveRemediation(operation string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeFailure
+	}
+	m.remediations[remediationKey{operation: operation, outcome: outcome}]++
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body bytes.Buffer
+	if err := m.Write(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	_, _ = w.Write(body.Bytes())
+}
+
+// Write renders the metrics in the Prometheus text exposition format
+func (m *Metrics) Write(w io.Writer) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mw := &metricWriter{w: w}
+	mw.family("build_info", "gauge", "Agent build information.")
+	mw.sample("build_info", 1, "version", m.build.Version, "git_commit", m.build.GitCommit, "build_time", m.build.BuildTime)
+
+	if m.config != nil && m.config.Agent.StateDir != "" {
+		runs, err := ReadRunMetrics(RunMetricsPath(m.config.Agent.StateDir))
+		if err != nil {
+			return err
+		}
+		mw.runs(runs)
+	}
+	if m.status != nil {
+		mw.node(m.status, m.config, m.now())
+	}
+
+	mw.family("remediation_attempts_total", "counter", "Automatic remediation attempts of this daemon by operation and outcome.")
+	keys := make([]remediationKey, 0, len(m.remediations))
+	for key := range m.remediations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].operation != keys[j].operation {
+			return keys[i].operation < keys[j].operation
+		}
+		return keys[i].outcome < keys[j].outcome
+	})
+	for _, key := range keys {
+		mw.sample("remediation_attempts_total", float64(m.remediations[key]), "operation", key.operation, "outcome", key.outcome)
+	}
+
+	mw.family("status_collection_duration_seconds", "histogram", "Duration of node status collections.")
+	mw.histogram("status_collection_duration_seconds", collectionDurationBuckets, m.collections)
+	mw.family("status_collection_errors_total", "counter", "Node status collections that failed.")
+	mw.sample("status_collection_errors_total", float64(m.collectionErrors))
+	return mw.err
+}
+
+// metricWriter writes metric families in the text exposition format, keeping the first error
+type metricWriter struct {
+	w   io.Writer
+	err error
+}
+
+// runs writes the accumulated bootstrap and unbootstrap runs
+func (mw *metricWriter) runs(runs *RunMetrics) {
+	operations := make([]string, 0, len(runs.Operations))
+	for operation := range runs.Operations {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+
+	mw.family("runs_total", "counter", "Bootstrap and unbootstrap runs by operation and outcome.")
+	for _, operation := range operations {
+		op := runs.Operations[operation]
+		mw.sample("runs_total", float64(op.Succeeded), "operation", operation, "outcome", OutcomeSuccess)
+		mw.sample("runs_total", float64(op.Failed), "operation", operation, "outcome", OutcomeFailure)
+	}
+	mw.family("last_run_timestamp_seconds", "gauge", "Unix time the last run of each operation finished.")
+	for _, operation := range operations {
+		mw.sample("last_run_timestamp_seconds", float64(runs.Operations[operation].LastRun.Unix()), "operation", operation)
+	}
+	mw.family("last_run_success", "gauge", "Whether the last run of each operation succeeded.")
+	for _, operation := range operations {
+		mw.sample("last_run_success", boolValue(runs.Operations[operation].LastSuccess), "operation", operation)
+	}
+
+	mw.family("step_duration_seconds", "histogram", "Duration of executed bootstrap and unbootstrap steps.")
+	for _, operation := range operations {
+		steps := make([]string, 0, len(runs.Operations[operation].Steps))
+		for step := range runs.Operations[operation].Steps {
+			steps = append(steps, step)
+		}
+		sort.Strings(steps)
+		for _, step := range steps {
+			mw.histogram("step_duration_seconds", stepDurationBuckets, *runs.Operations[operation].Steps[step], "operation", operation, "step", step)
+		}
+	}
+}
+
+// node writes the gauges of the latest collected node status
+func (mw *metricWriter) node(nodeStatus *NodeStatus, cfg *config.Config, now time.Time) {
+	mw.family("kubelet_running", "gauge", "Whether the kubelet service is running.")
+	mw.sample("kubelet_running", boolValue(nodeStatus.KubeletRunning))
+	mw.family("kubelet_ready", "gauge", "Whether the node reports Ready.")
+	mw.sample("kubelet_ready", boolValue(nodeStatus.KubeletReady == "Ready"))
+	mw.family("containerd_running", "gauge", "Whether the containerd service is running.")
+	mw.sample("containerd_running", boolValue(nodeStatus.ContainerdRunning))
+	mw.family("arc_connected", "gauge", "Whether the Arc machine is connected.")
+	mw.sample("arc_connected", boolValue(nodeStatus.ArcStatus.Connected))
+	if !nodeStatus.ArcStatus.LastHeartbeat.IsZero() {
+		mw.family("arc_last_heartbeat_age_seconds", "gauge", "Seconds since the Arc agent last sent a heartbeat.")
+		mw.sample("arc_last_heartbeat_age_seconds", now.Sub(nodeStatus.ArcStatus.LastHeartbeat).Seconds())
+	}
+	mw.family("status_age_seconds", "gauge", "Seconds since the node status was last collected.")
+	mw.sample("status_age_seconds", now.Sub(nodeStatus.LastUpdated).Seconds())
+
+	health := Assess(nodeStatus, cfg, SourceAPI, now).Health
+	mw.family("health", "gauge", "Node health state; the current state is 1.")
+	for _, state := range []Health{HealthHealthy, HealthDegraded, HealthNeedsBootstrap, HealthNeedsAttention} {
+		mw.sample("health", boolValue(health == state), "state", string(state))
+	}
+	mw.family("drifted_components", "gauge", "Components whose installed state differs from their desired state.")
+	mw.sample("drifted_components", float64(len(nodeStatus.Drift)))
+
+	if breaker := nodeStatus.Remediation; breaker != nil {
+		mw.family("remediation_tripped", "gauge", "Whether automatic remediation stopped until it is reset.")
+		mw.sample("remediation_tripped", boolValue(breaker.Tripped))
+		mw.family("remediation_window_attempts", "gauge", "Remediation attempts counted against the current window.")
+		mw.sample("remediation_window_attempts", float64(len(breaker.Attempts)))
+		mw.family("remediation_consecutive_failures", "gauge", "Remediation attempts that failed in a row.")
+		mw.sample("remediation_consecutive_failures", float64(breaker.ConsecutiveFailures))
+	}
+}
+
+// family writes the HELP and TYPE lines of a metric
+func (mw *metricWriter) family(name, kind, help string) {
+	mw.printf("# HELP %s%s %s\n# TYPE %s%s %s\n", metricsPrefix, name, help, metricsPrefix, name, kind)
+}
+
+// histogram writes the cumulative buckets, sum and count of a histogram
+func (mw *metricWriter) histogram(name string, bounds []float64, h Histogram, labels ...string) {
+	var cumulative uint64
+	for i := 0; i <= len(bounds); i++ {
+		bound := math.Inf(1)
+		if i < len(bounds) {
+			bound = bounds[i]
+		}
+		if i < len(h.Buckets) {
+			cumulative += h.Buckets[i]
+		}
+		bucketLabels := append(append([]string{}, labels...), "le", formatValue(bound))
+		mw.sample(name+"_bucket", float64(cumulative), bucketLabels...)
+	}
+	mw.sample(name+"_sum", h.Sum, labels...)
+	mw.sample(name+"_count", float64(h.Count), labels...)
+}
+
+// sample writes a single sample; labels are name and value pairs
+func (mw *metricWriter) sample(name string, value float64, labels ...string) {
+	var builder strings.Builder
+	builder.WriteString(metricsPrefix + name)
+	if len(labels) > 0 {
+		builder.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			fmt.Fprintf(&builder, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		builder.WriteByte('}')
+	}
+	mw.printf("%s %s\n", builder.String(), formatValue(value))
+}
+
+// printf writes to the underlying writer unless an earlier write failed
+func (mw *metricWriter) printf(format string, args ...interface{}) {
+	if mw.err == nil {
+		_, mw.err = fmt.Fprintf(mw.w, format, args...)
+	}
+}
+
+// escapeLabelValue escapes a label value as the text exposition format requires
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// formatValue formats a sample value or bucket bound
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// boolValue returns 1 for true and 0 for false
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
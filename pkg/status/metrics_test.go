@@ -0,0 +1,152 @@
+package status
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/remediation"
+)
+
+// TestRecordRun verifies that run outcomes and step durations accumulate in the state directory.
+// Test: Records a successful bootstrap with a skipped step, a failed bootstrap and an unbootstrap that failed to start
+// Expected: Each operation counts its outcomes, the last outcome is kept, and skipped steps are not observed
+func TestRecordRun(t *testing.T) {
+	stateDir := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	succeeded := &bootstrapper.ExecutionResult{
+		Success: true,
+		StepResults: []bootstrapper.StepResult{
+			{StepName: "ContainerdInstaller", Success: true, Duration: 20 * time.Second},
+			{StepName: "ArcInstaller", Success: true, SkipReason: "already complete"},
+		},
+	}
+	failed := &bootstrapper.ExecutionResult{
+		StepResults: []bootstrapper.StepResult{{StepName: "ContainerdInstaller", Duration: 90 * time.Second}},
+	}
+	for _, result := range []*bootstrapper.ExecutionResult{succeeded, failed} {
+		if err := RecordRun(stateDir, "bootstrap", result, now); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if err := RecordRun(stateDir, "unbootstrap", nil, now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	runs, err := ReadRunMetrics(RunMetricsPath(stateDir))
+	if err != nil {
+		t.Fatalf("Failed to read run metrics: %v", err)
+	}
+	bootstrap := runs.Operations["bootstrap"]
+	if bootstrap == nil || bootstrap.Succeeded != 1 || bootstrap.Failed != 1 || bootstrap.LastSuccess {
+		t.Fatalf("Expected one success and a failed last bootstrap, got %+v", bootstrap)
+	}
+	if !bootstrap.LastRun.Equal(now) {
+		t.Errorf("Expected last run %s, got %s", now, bootstrap.LastRun)
+	}
+	containerd := bootstrap.Steps["ContainerdInstaller"]
+	if containerd == nil || containerd.Count != 2 || containerd.Sum != 110 {
+		t.Errorf("Expected two containerd observations summing to 110s, got %+v", containerd)
+	}
+	if _, ok := bootstrap.Steps["ArcInstaller"]; ok {
+		t.Error("Expected the skipped step not to be observed")
+	}
+	if unbootstrap := runs.Operations["unbootstrap"]; unbootstrap == nil || unbootstrap.Failed != 1 || len(unbootstrap.Steps) != 0 {
+		t.Errorf("Expected one failed unbootstrap without steps, got %+v", unbootstrap)
+	}
+}
+
+// TestMetricsWrite verifies the Prometheus text exposition of the daemon metrics.
+// Test: Records runs, a status, collections and remediations, then scrapes the metrics handler
+// Expected: The response has the exposition content type and holds the expected samples
+func TestMetricsWrite(t *testing.T) {
+	stateDir := t.TempDir()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	result := &bootstrapper.ExecutionResult{
+		Success:     true,
+		StepResults: []bootstrapper.StepResult{{StepName: "KubeletInstaller", Success: true, Duration: 3 * time.Second}},
+	}
+	if err := RecordRun(stateDir, "bootstrap", result, now); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	cfg := &config.Config{Agent: config.AgentConfig{StateDir: stateDir}}
+	metrics := NewMetrics(cfg, BuildInfo{Version: "v1.2.3", GitCommit: "abc", BuildTime: "today"})
+	metrics.now = func() time.Time { return now }
+
+	nodeStatus := healthyNodeStatus(now.Add(-30 * time.Second))
+	nodeStatus.ArcStatus.LastHeartbeat = now.Add(-2 * time.Minute)
+	nodeStatus.Remediation = &remediation.State{Attempts: []time.Time{now}, ConsecutiveFailures: 1}
+	metrics.SetStatus(nodeStatus)
+	metrics.ObserveCollection(200*time.Millisecond, nil)
+	metrics.ObserveCollection(3*time.Second, errors.New("kubectl failed"))
+	metrics.ObserveRemediation("drift-repair", nil)
+
+	recorder := httptest.NewRecorder()
+	metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("Expected the text exposition content type, got %q", contentType)
+	}
+
+	body := recorder.Body.String()
+	for _, want := range []string{
+		"# TYPE aks_flex_node_build_info gauge\n",
+		`aks_flex_node_build_info{version="v1.2.3",git_commit="abc",build_time="today"} 1` + "\n",
+		`aks_flex_node_runs_total{operation="bootstrap",outcome="success"} 1` + "\n",
+		`aks_flex_node_runs_total{operation="bootstrap",outcome="failure"} 0` + "\n",
+		`aks_flex_node_last_run_success{operation="bootstrap"} 1` + "\n",
+		`aks_flex_node_step_duration_seconds_bucket{operation="bootstrap",step="KubeletInstaller",le="1"} 0` + "\n",
+		`aks_flex_node_step_duration_seconds_bucket{operation="bootstrap",step="KubeletInstaller",le="5"} 1` + "\n",
+		`aks_flex_node_step_duration_seconds_bucket{operation="bootstrap",step="KubeletInstaller",le="+Inf"} 1` + "\n",
+		`aks_flex_node_step_duration_seconds_count{operation="bootstrap",step="KubeletInstaller"} 1` + "\n",
+		"aks_flex_node_kubelet_running 1\n",
+		"aks_flex_node_kubelet_ready 1\n",
+		"aks_flex_node_containerd_running 1\n",
+		"aks_flex_node_arc_connected 1\n",
+		"aks_flex_node_arc_last_heartbeat_age_seconds 120\n",
+		"aks_flex_node_status_age_seconds 30\n",
+		`aks_flex_node_health{state="healthy"} 1` + "\n",
+		`aks_flex_node_health{state="degraded"} 0` + "\n",
+		"aks_flex_node_remediation_window_attempts 1\n",
+		"aks_flex_node_remediation_consecutive_failures 1\n",
+		`aks_flex_node_remediation_attempts_total{operation="drift-repair",outcome="success"} 1` + "\n",
+		`aks_flex_node_status_collection_duration_seconds_bucket{le="0.25"} 1` + "\n",
+		`aks_flex_node_status_collection_duration_seconds_bucket{le="5"} 2` + "\n",
+		"aks_flex_node_status_collection_duration_seconds_sum 3.2\n",
+		"aks_flex_node_status_collection_errors_total 1\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+// TestEscapeLabelValue verifies that label values are escaped for the text exposition format.
+// Test: Escapes values containing backslashes, quotes and newlines
+// Expected: Each special character is backslash escaped
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "plain", want: "plain"},
+		{value: `C:\path`, want: `C:\\path`},
+		{value: `say "hi"`, want: `say \"hi\"`},
+		{value: "two\nlines", want: `two\nlines`},
+	}
+
+	for _, tt := range tests {
+		if got := escapeLabelValue(tt.value); got != tt.want {
+			t.Errorf("escapeLabelValue(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
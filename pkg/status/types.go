@@ -2,6 +2,9 @@ package status
 
 import (
 	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/drift"
+	"go.goms.io/aks/AKSFlexNode/pkg/remediation"
 )
 
 // NodeStatus represents the current status and health information of the AKS edge node
@@ -23,6 +26,28 @@ type NodeStatus struct {
 	// Metadata
 	LastUpdated  time.Time `json:"lastUpdated"`
 	AgentVersion string    `json:"agentVersion"`
+
+	// Daemon holds the schedule of the daemon that collected the status; nil for live collection
+	Daemon *DaemonSettings `json:"daemon,omitempty"`
+
+	// Drift lists the components whose installed state differs from their desired state, as found
+	// by the daemon's last remediation check
+	Drift []drift.Component `json:"drift,omitempty"`
+
+	// Remediation is the state of the remediation circuit breaker
+	Remediation *remediation.State `json:"remediation,omitempty"`
+
+	// ConfigError is the reason the daemon rejected the last configuration reload, if it did
+	ConfigError string `json:"configError,omitempty"`
+}
+
+// DaemonSettings are the active schedule values of the agent daemon
+type DaemonSettings struct {
+	StatusInterval      string `json:"statusInterval"`
+	RemediationInterval string `json:"remediationInterval"`
+	StaleThreshold      string `json:"staleThreshold"`
+	Jitter              string `json:"jitter"`
+	InitialDelay        string `json:"initialDelay"`
 }
 
 // ArcStatus contains Azure Arc machine registration and connection status
@@ -0,0 +1,414 @@
+// Package supportbundle collects logs, rendered configuration and host state into a single
+// tarball that can be attached to a support request. Secrets are redacted before anything is written.
+package supportbundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Options control what is collected and how large the bundle may grow
+type Options struct {
+	OutputDir string        // directory the tarball is written to
+	MaxBytes  int64         // upper bound for the uncompressed content; larger files are truncated
+	Since     time.Duration // how far back journal entries are collected
+}
+
+// Result describes a written bundle
+type Result struct {
+	Path      string   `json:"path"`
+	Files     int      `json:"files"`
+	Bytes     int64    `json:"bytes"`
+	Truncated []string `json:"truncated,omitempty"`
+	Skipped   []string `json:"skipped,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// file is a single file of the bundle
+type file struct {
+	name string
+	data []byte
+	tail bool // keep the end of the content when it has to be truncated, as logs grow at the end
+}
+
+// source produces one or more files of the bundle; missing inputs yield no files and no error
+type source struct {
+	name    string
+	collect func(ctx context.Context) ([]file, error)
+}
+
+// Collector gathers support bundles
+type Collector struct {
+	config       *config.Config
+	logger       *logrus.Logger
+	configPath   string
+	agentVersion string
+	run          func(name string, args ...string) (string, error)
+	now          func() time.Time
+}
+
+// New creates a collector; configPath is the configuration file whose effective values are included.
+// cfg may be nil when the configuration cannot be loaded, in which case default locations are used.
+func New(cfg *config.Config, logger *logrus.Logger, configPath, agentVersion string) *Collector {
+	return &Collector{
+		config:       cfg,
+		logger:       logger,
+		configPath:   configPath,
+		agentVersion: agentVersion,
+		run:          utils.RunCommandWithOutput,
+		now:          time.Now,
+	}
+}
+
+// Collect writes a timestamped bundle to opts.OutputDir. Inputs that cannot be collected are
+// listed in the manifest instead of failing the collection.
+func (c *Collector) Collect(ctx context.Context, opts Options) (*Result, error) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.Since <= 0 {
+		opts.Since = DefaultSince
+	}
+	if opts.OutputDir == "" {
+		opts.OutputDir = "."
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	base := fmt.Sprintf("%s-%s-%s", bundlePrefix, hostname, c.now().UTC().Format(timestampFormat))
+	bundlePath := filepath.Join(opts.OutputDir, base+".tar.gz")
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory %s: %w", opts.OutputDir, err)
+	}
+	out, err := os.OpenFile(bundlePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bundle %s: %w", bundlePath, err)
+	}
+
+	result := &Result{Path: bundlePath}
+	if err := c.write(ctx, out, base, opts, result); err != nil {
+		_ = out.Close()
+		_ = os.Remove(bundlePath)
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(bundlePath)
+		return nil, fmt.Errorf("failed to write bundle %s: %w", bundlePath, err)
+	}
+	return result, nil
+}
+
+// write streams the collected files and the manifest into a gzipped tarball
+func (c *Collector) write(ctx context.Context, out io.Writer, base string, opts Options, result *Result) error {
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	modTime := c.now()
+
+	var manifest []string
+	remaining := opts.MaxBytes
+	for _, src := range c.sources(opts) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		c.logger.Debugf("Collecting %s", src.name)
+		files, err := src.collect(ctx)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", src.name, err))
+		}
+		if err == nil && len(files) == 0 {
+			result.Skipped = append(result.Skipped, src.name)
+		}
+
+		for _, f := range files {
+			data := Redact(f.data)
+			note := ""
+			if int64(len(data)) > remaining {
+				note = " (truncated)"
+				if remaining == 0 {
+					note = " (size limit reached)"
+				}
+				data = truncate(data, remaining, f.tail)
+				result.Truncated = append(result.Truncated, f.name)
+			}
+
+			if err := writeFile(tw, path.Join(base, f.name), data, modTime); err != nil {
+				return err
+			}
+			remaining -= int64(len(data))
+			result.Files++
+			result.Bytes += int64(len(data))
+			manifest = append(manifest, fmt.Sprintf("%-60s %10d%s", f.name, len(data), note))
+		}
+	}
+
+	if err := writeFile(tw, path.Join(base, manifestName), c.manifest(opts, manifest, result), modTime); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	return nil
+}
+
+// manifest renders the index of the bundle
+func (c *Collector) manifest(opts Options, files []string, result *Result) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "aks-flex-node support bundle\n")
+	fmt.Fprintf(&b, "Agent version: %s\n", c.agentVersion)
+	fmt.Fprintf(&b, "Collected:     %s\n", c.now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Journal since: %s\n", opts.Since)
+	fmt.Fprintf(&b, "Size limit:    %d bytes\n\n", opts.MaxBytes)
+
+	fmt.Fprintf(&b, "Files:\n")
+	for _, line := range files {
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(&b, "\nNot present on this host:\n")
+		for _, name := range result.Skipped {
+			fmt.Fprintf(&b, "  %s\n", name)
+		}
+	}
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(&b, "\nErrors:\n")
+		for _, msg := range result.Errors {
+			fmt.Fprintf(&b, "  %s\n", msg)
+		}
+	}
+	return Redact(b.Bytes())
+}
+
+// sources lists everything a bundle contains, most important first, so that the size limit
+// truncates agent logs and journals rather than versions and configuration
+func (c *Collector) sources(opts Options) []source {
+	sources := []source{
+		{name: "versions.txt", collect: c.versions},
+		{name: "status.json", collect: func(ctx context.Context) ([]file, error) {
+			return readFile("status.json", statusFilePath(), false)
+		}},
+		{name: "config/aks-flex-node.json", collect: c.effectiveConfig},
+	}
+
+	for _, f := range configFiles {
+		f := f
+		sources = append(sources, source{name: f.name, collect: func(ctx context.Context) ([]file, error) {
+			return readFile(f.name, f.path, false)
+		}})
+	}
+	sources = append(sources, source{name: "config/cni", collect: func(ctx context.Context) ([]file, error) {
+		return readDir("config/cni", cniConfDir, false)
+	}})
+
+	for _, cmd := range systemCommands {
+		cmd := cmd
+		sources = append(sources, source{name: cmd.name, collect: func(ctx context.Context) ([]file, error) {
+			return c.command(cmd.name, cmd.args...)
+		}})
+	}
+	sources = append(sources, source{name: "arc/azcmagent-logs", collect: c.azcmagentLogs})
+
+	since := fmt.Sprintf("-%ds", int64(opts.Since.Seconds()))
+	for _, unit := range journalUnits {
+		name := fmt.Sprintf("journal/%s.log", unit)
+		unit := unit
+		sources = append(sources, source{name: name, collect: func(ctx context.Context) ([]file, error) {
+			files, err := c.command(name, "journalctl", "--no-pager", "--output=short-iso", "--since="+since, "--unit="+unit)
+			for i := range files {
+				files[i].tail = true
+			}
+			return files, err
+		}})
+	}
+
+	logDir := defaultAgentLogDir
+	if c.config != nil && c.config.Agent.LogDir != "" {
+		logDir = c.config.Agent.LogDir
+	}
+	sources = append(sources, source{name: "logs/agent", collect: func(ctx context.Context) ([]file, error) {
+		return readDir("logs/agent", logDir, true)
+	}})
+	return sources
+}
+
+// versions reports the agent version and the versions of the installed components
+func (c *Collector) versions(ctx context.Context) ([]file, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "aks-flex-node %s\n", c.agentVersion)
+	for _, args := range versionCommands {
+		output, err := c.run(args[0], args[1:]...)
+		if err != nil && output == "" {
+			output = err.Error()
+		}
+		fmt.Fprintf(&b, "\n$ %s\n%s\n", strings.Join(args, " "), strings.TrimSpace(output))
+	}
+	return []file{{name: "versions.txt", data: b.Bytes()}}, nil
+}
+
+// effectiveConfig renders the configuration the agent runs with, annotated with value sources
+func (c *Collector) effectiveConfig(ctx context.Context) ([]file, error) {
+	if c.configPath == "" {
+		return nil, nil
+	}
+	inspection, err := config.Inspect(c.configPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(inspection, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return []file{{name: "config/aks-flex-node.json", data: data}}, nil
+}
+
+// command runs a command and stores its output; output of failed commands is kept as well
+func (c *Collector) command(name string, args ...string) ([]file, error) {
+	output, err := c.run(args[0], args[1:]...)
+	if err != nil {
+		err = fmt.Errorf("%s failed: %w", strings.Join(args, " "), err)
+		if output == "" {
+			return nil, err
+		}
+	}
+	data := []byte(fmt.Sprintf("$ %s\n%s", strings.Join(args, " "), output))
+	return []file{{name: name, data: data}}, err
+}
+
+// azcmagentLogs collects the Arc agent logs, which azcmagent writes as a zip archive, and
+// extracts them so that every file can be redacted
+func (c *Collector) azcmagentLogs(ctx context.Context) ([]file, error) {
+	if !utils.BinaryExists("azcmagent") {
+		return nil, nil
+	}
+
+	dir, err := os.MkdirTemp("", "aks-flex-node-azcmagent-logs-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	archive := filepath.Join(dir, "azcmagent-logs.zip")
+	if output, err := c.run("azcmagent", "logs", "--output", archive); err != nil {
+		return nil, fmt.Errorf("azcmagent logs failed: %w: %s", err, strings.TrimSpace(output))
+	}
+
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open azcmagent logs: %w", err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	var files []file
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		data, err := readZipEntry(entry)
+		if err != nil {
+			return files, fmt.Errorf("failed to read %s from azcmagent logs: %w", entry.Name, err)
+		}
+		files = append(files, file{name: path.Join("arc/azcmagent-logs", path.Clean("/"+entry.Name)), data: data, tail: true})
+	}
+	return files, nil
+}
+
+// readZipEntry reads a single file of a zip archive
+func readZipEntry(entry *zip.File) ([]byte, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rc.Close() }()
+	return io.ReadAll(rc)
+}
+
+// readFile reads a single host file; a missing file yields no file
+func readFile(name, hostPath string, tail bool) ([]file, error) {
+	data, err := os.ReadFile(hostPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []file{{name: name, data: data, tail: tail}}, nil
+}
+
+// readDir reads the regular files directly inside dir in name order; a missing directory yields no files
+func readDir(name, dir string, tail bool) ([]file, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	var files []file
+	var errs []error
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		read, err := readFile(path.Join(name, entry.Name()), filepath.Join(dir, entry.Name()), tail)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		files = append(files, read...)
+	}
+	return files, errors.Join(errs...)
+}
+
+// truncate shortens data to limit bytes, keeping its end for logs and its beginning otherwise
+func truncate(data []byte, limit int64, tail bool) []byte {
+	if limit <= 0 {
+		return nil
+	}
+	if tail {
+		return data[int64(len(data))-limit:]
+	}
+	return data[:limit]
+}
+
+// writeFile adds a regular file to the tarball
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+	}
+	return nil
+}
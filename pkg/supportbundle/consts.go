@@ -0,0 +1,75 @@
+package supportbundle
+
+import (
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+)
+
+const (
+	// DefaultMaxBytes bounds the uncompressed content of a bundle
+	DefaultMaxBytes = 100 << 20
+	// DefaultSince is how far back journal entries are collected
+	DefaultSince = 24 * time.Hour
+
+	// bundlePrefix starts the name of every bundle and of its top level directory
+	bundlePrefix = "aks-flex-node-logs"
+	// timestampFormat is the UTC timestamp in bundle names
+	timestampFormat = "20060102T150405Z"
+	// manifestName is the bundle file listing what was collected, truncated or skipped
+	manifestName = "manifest.txt"
+
+	// defaultAgentLogDir is collected when no configuration could be loaded
+	defaultAgentLogDir = "/var/log/aks-flex-node"
+
+	// redactedValue replaces secrets in collected content
+	redactedValue = "<redacted>"
+)
+
+// fileSource is a host file copied into the bundle
+type fileSource struct {
+	name string // path inside the bundle
+	path string // path on the host
+}
+
+// commandSource is a command whose output is stored in the bundle
+type commandSource struct {
+	name string // path inside the bundle
+	args []string
+}
+
+// configFiles are the rendered component configurations; kubeconfig credentials are redacted
+var configFiles = []fileSource{
+	{name: "config/kubelet/default-kubelet", path: "/etc/default/kubelet"},
+	{name: "config/kubelet/config.yaml", path: "/var/lib/kubelet/config.yaml"},
+	{name: "config/kubelet/kubeconfig", path: "/var/lib/kubelet/kubeconfig"},
+	{name: "config/kubelet/10-containerd.conf", path: "/etc/systemd/system/kubelet.service.d/10-containerd.conf"},
+	{name: "config/containerd/config.toml", path: "/etc/containerd/config.toml"},
+	{name: "config/sysctl/999-sysctl-aks.conf", path: "/etc/sysctl.d/999-sysctl-aks.conf"},
+	{name: "system/os-release", path: "/etc/os-release"},
+}
+
+// cniConfDir holds the CNI conflist files
+var cniConfDir = "/etc/cni/net.d"
+
+// statusFilePath locates the status file written by the agent daemon
+var statusFilePath = status.FindStatusFile
+
+// versionCommands report the versions of the node components
+var versionCommands = [][]string{
+	{"kubelet", "--version"},
+	{"containerd", "--version"},
+	{"runc", "--version"},
+	{"uname", "-a"},
+}
+
+// systemCommands capture the kernel and network state of the host
+var systemCommands = []commandSource{
+	{name: "system/sysctl.txt", args: []string{"sysctl", "-a"}},
+	{name: "system/ip-addr.txt", args: []string{"ip", "addr"}},
+	{name: "system/ip-route.txt", args: []string{"ip", "route"}},
+	{name: "arc/azcmagent-show.txt", args: []string{"azcmagent", "show"}},
+}
+
+// journalUnits are the systemd units whose journal is collected
+var journalUnits = []string{"kubelet", "containerd", "node-problem-detector", "aks-flex-node-agent"}
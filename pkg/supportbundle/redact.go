@@ -0,0 +1,47 @@
+package supportbundle
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// secretAssignment matches key/value pairs such as `token: abc`, `"clientSecret": "abc"` or
+	// `client-key-data=abc` in YAML, JSON, TOML, INI and log lines
+	secretAssignment = regexp.MustCompile(`(?i)("?[\w.-]*(?:token|secret|password|passwd|client-key-data|client-certificate-data|private-key)[\w.-]*"?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,;}&]+)`)
+	// secretFlag matches command line flags such as `--client-secret abc`
+	secretFlag = regexp.MustCompile(`(?i)(--[\w-]*(?:token|secret|password)[\w-]*[= ])([^\s-]\S*)`)
+	// bearerToken matches HTTP authorization headers
+	bearerToken = regexp.MustCompile(`(?i)(bearer\s+)[\w.~+/=-]+`)
+	// sasSignature matches the signature of Azure SAS URLs
+	sasSignature = regexp.MustCompile(`(?i)([?&]sig=)[^&\s"']+`)
+	// bootstrapToken matches Kubernetes bootstrap tokens
+	bootstrapToken = regexp.MustCompile(`\b[a-z0-9]{6}\.[a-z0-9]{16}\b`)
+	// privateKey matches PEM encoded private keys
+	privateKey = regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`)
+)
+
+// Redact replaces tokens, client secrets, passwords and kubeconfig credentials in data
+func Redact(data []byte) []byte {
+	s := string(data)
+	s = privateKey.ReplaceAllString(s, redactedValue)
+	s = secretAssignment.ReplaceAllStringFunc(s, func(match string) string {
+		parts := secretAssignment.FindStringSubmatch(match)
+		return parts[1] + quoted(parts[2], redactedValue)
+	})
+	s = secretFlag.ReplaceAllString(s, "${1}"+redactedValue)
+	s = bearerToken.ReplaceAllString(s, "${1}"+redactedValue)
+	s = sasSignature.ReplaceAllString(s, "${1}"+redactedValue)
+	s = bootstrapToken.ReplaceAllString(s, redactedValue)
+	return []byte(s)
+}
+
+// quoted returns replacement in the quotes surrounding original, so redacted JSON stays valid
+func quoted(original, replacement string) string {
+	for _, quote := range []string{`"`, `'`} {
+		if len(original) >= 2 && strings.HasPrefix(original, quote) && strings.HasSuffix(original, quote) {
+			return quote + replacement + quote
+		}
+	}
+	return replacement
+}
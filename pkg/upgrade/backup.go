@@ -0,0 +1,58 @@
+package upgrade
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// backupFile is a binary saved before an upgrade replaced it
+type backupFile struct {
+	original string
+	saved    string
+}
+
+// backup copies the existing binaries of the drifted components into dir.
+// Binaries that do not exist yet are skipped; restoring leaves them in place.
+func (u *Upgrader) backup(drifts []Drift, dir string) ([]backupFile, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to clear backup directory %s: %w", dir, err)
+	}
+
+	var files []backupFile
+	for _, drift := range drifts {
+		componentDir := filepath.Join(dir, drift.Component)
+		if err := os.MkdirAll(componentDir, 0o750); err != nil {
+			return nil, fmt.Errorf("failed to create backup directory %s: %w", componentDir, err)
+		}
+		for _, binary := range u.binaries[drift.Component] {
+			if !utils.FileExists(binary) {
+				continue
+			}
+			saved := filepath.Join(componentDir, filepath.Base(binary))
+			if err := utils.RunSystemCommand("cp", "-p", binary, saved); err != nil {
+				return nil, fmt.Errorf("failed to back up %s: %w", binary, err)
+			}
+			files = append(files, backupFile{original: binary, saved: saved})
+		}
+	}
+	return files, nil
+}
+
+// restore puts the saved binaries back. Each file is copied next to its original and renamed over it,
+// which works while the binary is running and never leaves a partial file behind.
+func (u *Upgrader) restore(files []backupFile) error {
+	for _, file := range files {
+		temp := file.original + ".restore"
+		if err := utils.RunSystemCommand("cp", "-p", file.saved, temp); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", file.original, err)
+		}
+		if err := utils.RunSystemCommand("mv", "-f", temp, file.original); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", file.original, err)
+		}
+		u.logger.Infof("Restored %s", file.original)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package upgrade
+
+import "time"
+
+const (
+	// kubeletKubeconfigPath is the kubeconfig kubelet talks to the API server with
+	kubeletKubeconfigPath = "/var/lib/kubelet/kubeconfig"
+	// DrainKubeconfigPath is the kubeconfig used to cordon, drain and uncordon the node. The kubelet's
+	// credential cannot list and evict pods, so the operator provisions one with that RBAC.
+	DrainKubeconfigPath = "/etc/aks-flex-node/drain-kubeconfig"
+
+	// backupDirName is the directory inside agent.stateDir holding the binaries replaced by an upgrade
+	backupDirName = "upgrade-backup"
+
+	// DefaultDrainTimeout bounds evicting the node's pods before binaries are swapped
+	DefaultDrainTimeout = 10 * time.Minute
+	// DefaultReadyTimeout bounds waiting for the node to report Ready after the upgrade
+	DefaultReadyTimeout = 10 * time.Minute
+)
+
+// restartOrder lists the services restarted after a swap; kubelet needs the new container runtime running
+var restartOrder = []string{"containerd", "kubelet"}
+
+// readyPollInterval is how often node readiness is checked while waiting
+var readyPollInterval = 5 * time.Second
@@ -0,0 +1,81 @@
+package upgrade
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// nodeClient changes and observes this machine's Node object through the API server
+type nodeClient interface {
+	Cordon(ctx context.Context) error
+	Drain(ctx context.Context, timeout time.Duration) error
+	Uncordon(ctx context.Context) error
+	Ready(ctx context.Context) (bool, error)
+}
+
+// kubectlNode drives the Node object with kubectl. Readiness is read with kubelet's credentials,
+// while cordoning and draining need the drain kubeconfig.
+type kubectlNode struct {
+	name            string
+	kubeconfig      string
+	drainKubeconfig string
+	run             func(ctx context.Context, name string, args ...string) (string, error)
+}
+
+// newKubectlNode returns a client for the Node named after this machine's hostname
+func newKubectlNode() (*kubectlNode, error) {
+	hostName, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get hostname: %w", err)
+	}
+	return &kubectlNode{
+		name:            strings.ToLower(hostName),
+		kubeconfig:      kubeletKubeconfigPath,
+		drainKubeconfig: DrainKubeconfigPath,
+		run:             utils.RunCommandWithOutputContext,
+	}, nil
+}
+
+// kubectl runs kubectl with kubeconfig. The arguments must stay in the exact form the sudoers rules allow.
+func (n *kubectlNode) kubectl(ctx context.Context, kubeconfig string, args ...string) (string, error) {
+	output, err := n.run(ctx, "kubectl", append([]string{"--kubeconfig", kubeconfig}, args...)...)
+	if err != nil {
+		return output, fmt.Errorf("kubectl %s failed: %w: %s", args[0], err, strings.TrimSpace(output))
+	}
+	return output, nil
+}
+
+// Cordon marks the node unschedulable
+func (n *kubectlNode) Cordon(ctx context.Context) error {
+	if !utils.FileExists(n.drainKubeconfig) {
+		return fmt.Errorf("drain kubeconfig %s not found: provision a kubeconfig allowed to cordon and drain the node, or skip draining", n.drainKubeconfig)
+	}
+	_, err := n.kubectl(ctx, n.drainKubeconfig, "cordon", n.name)
+	return err
+}
+
+// Drain evicts every pod except DaemonSet pods from the node
+func (n *kubectlNode) Drain(ctx context.Context, timeout time.Duration) error {
+	_, err := n.kubectl(ctx, n.drainKubeconfig, "drain", n.name, "--ignore-daemonsets", "--delete-emptydir-data", "--timeout="+timeout.String())
+	return err
+}
+
+// Uncordon marks the node schedulable again
+func (n *kubectlNode) Uncordon(ctx context.Context) error {
+	_, err := n.kubectl(ctx, n.drainKubeconfig, "uncordon", n.name)
+	return err
+}
+
+// Ready reports whether the node's Ready condition is True
+func (n *kubectlNode) Ready(ctx context.Context) (bool, error) {
+	output, err := n.kubectl(ctx, n.kubeconfig, "get", "node", n.name, "-o", `jsonpath={.status.conditions[?(@.type=="Ready")].status}`)
+	if err != nil {
+		return false, err
+	}
+	return strings.TrimSpace(output) == "True", nil
+}
@@ -0,0 +1,86 @@
+package upgrade
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/utils/sudoerstest"
+)
+
+// TestKubectlNodeCommandsMatchSudoers verifies that the node operations of an upgrade are allowed by the shipped sudoers rules.
+// Test: Runs cordon, drain, uncordon and the readiness check with a recording runner and the installed kubeconfig paths
+// Expected: Every kubectl command matches a sudoers rule and receives the caller's context
+func TestKubectlNodeCommandsMatchSudoers(t *testing.T) {
+	// The drain kubeconfig must exist for cordon to run; point at a temp file and rewrite the path for matching
+	drainKubeconfig := filepath.Join(t.TempDir(), "drain-kubeconfig")
+	if err := os.WriteFile(drainKubeconfig, []byte("apiVersion: v1"), 0o600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+
+	type contextKey struct{}
+	ctx := context.WithValue(context.Background(), contextKey{}, "upgrade")
+	var commands [][]string
+	node := &kubectlNode{
+		name:            "flex-node-1",
+		kubeconfig:      kubeletKubeconfigPath,
+		drainKubeconfig: drainKubeconfig,
+		run: func(runCtx context.Context, name string, args ...string) (string, error) {
+			if runCtx.Value(contextKey{}) != "upgrade" {
+				t.Errorf("Expected kubectl %s to run with the caller's context", args[2])
+			}
+			for i, arg := range args {
+				if arg == drainKubeconfig {
+					args[i] = DrainKubeconfigPath
+				}
+			}
+			commands = append(commands, append([]string{name}, args...))
+			return "True", nil
+		},
+	}
+
+	if err := node.Cordon(ctx); err != nil {
+		t.Fatalf("Cordon failed: %v", err)
+	}
+	if err := node.Drain(ctx, 10*time.Minute); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if err := node.Uncordon(ctx); err != nil {
+		t.Fatalf("Uncordon failed: %v", err)
+	}
+	if ready, err := node.Ready(ctx); err != nil || !ready {
+		t.Fatalf("Expected the node to be Ready, got %t, %v", ready, err)
+	}
+
+	rules := sudoerstest.Rules(t)
+	if len(commands) != 4 {
+		t.Fatalf("Expected 4 kubectl commands, got %v", commands)
+	}
+	for _, command := range commands {
+		if !sudoerstest.Allows(rules, command[0], command[1:]...) {
+			t.Errorf("Expected sudoers to allow %s", strings.Join(command, " "))
+		}
+	}
+}
+
+// TestKubectlNodeCordonWithoutDrainKubeconfig verifies the error when no drain kubeconfig is provisioned.
+// Test: Cordons with a drain kubeconfig path that does not exist
+// Expected: An error naming the kubeconfig is returned and kubectl is not run
+func TestKubectlNodeCordonWithoutDrainKubeconfig(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "drain-kubeconfig")
+	node := &kubectlNode{
+		name:            "flex-node-1",
+		drainKubeconfig: missing,
+		run: func(context.Context, string, ...string) (string, error) {
+			t.Error("Expected kubectl not to run")
+			return "", nil
+		},
+	}
+
+	if err := node.Cordon(context.Background()); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("Expected an error naming %s, got %v", missing, err)
+	}
+}
@@ -0,0 +1,283 @@
+// Package upgrade moves an installed node to the component versions pinned in configuration.
+// The node is cordoned and drained, binaries are swapped and services restarted, and the previous
+// binaries are restored when the node does not become Ready again.
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/containerd"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/kube_binaries"
+	"go.goms.io/aks/AKSFlexNode/pkg/components/runc"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+	"go.goms.io/aks/AKSFlexNode/pkg/utils"
+)
+
+// Drift is a component whose installed version differs from the configured one
+type Drift struct {
+	Component string `json:"component"`
+	Installed string `json:"installed"`
+	Desired   string `json:"desired"`
+}
+
+// Options control a single upgrade
+type Options struct {
+	DryRun       bool          // only detect drift
+	SkipDrain    bool          // swap binaries without cordoning and draining the node
+	DrainTimeout time.Duration // bound for draining the node
+	ReadyTimeout time.Duration // bound for the node to become Ready after the swap
+}
+
+// Result is the outcome of an upgrade
+type Result struct {
+	Drifts     []Drift  `json:"drifts"`
+	Upgraded   []string `json:"upgraded,omitempty"`
+	RolledBack bool     `json:"rolledBack,omitempty"`
+}
+
+// componentInstaller reinstalls a single node component; implemented by bootstrapper.Bootstrapper
+type componentInstaller interface {
+	InstallComponent(ctx context.Context, name string, withPrerequisites bool) (*bootstrapper.ExecutionResult, error)
+}
+
+// versionedComponent is a component whose version is pinned by configuration
+type versionedComponent struct {
+	name      string
+	desired   func(cfg *config.Config) string
+	installed func(versions status.ComponentVersions) string
+}
+
+// versionedComponents lists the upgradable components in install order
+var versionedComponents = []versionedComponent{
+	{
+		name:      bootstrapper.ComponentRunc,
+		desired:   func(cfg *config.Config) string { return cfg.Runc.Version },
+		installed: func(versions status.ComponentVersions) string { return versions.Runc },
+	},
+	{
+		name:      bootstrapper.ComponentContainerd,
+		desired:   func(cfg *config.Config) string { return cfg.Containerd.Version },
+		installed: func(versions status.ComponentVersions) string { return versions.Containerd },
+	},
+	{
+		name:      bootstrapper.ComponentKubeBinaries,
+		desired:   func(cfg *config.Config) string { return cfg.GetKubernetesVersion() },
+		installed: func(versions status.ComponentVersions) string { return versions.Kubelet },
+	},
+}
+
+// Upgrader detects version drift and upgrades the drifted components
+type Upgrader struct {
+	config    *config.Config
+	logger    *logrus.Logger
+	installer componentInstaller
+	node      nodeClient
+	versions  func(ctx context.Context) status.ComponentVersions
+	restart   func(service string) error
+	binaries  map[string][]string
+	backupDir string
+}
+
+// New creates an Upgrader that reinstalls components through installer
+func New(cfg *config.Config, logger *logrus.Logger, installer componentInstaller, agentVersion string) (*Upgrader, error) {
+	node, err := newKubectlNode()
+	if err != nil {
+		return nil, err
+	}
+
+	collector := status.NewCollector(cfg, logger, agentVersion)
+	return &Upgrader{
+		config:    cfg,
+		logger:    logger,
+		installer: installer,
+		node:      node,
+		versions:  collector.CollectVersions,
+		restart:   utils.RestartService,
+		binaries: map[string][]string{
+			bootstrapper.ComponentRunc:         runc.BinaryPaths(),
+			bootstrapper.ComponentContainerd:   containerd.BinaryPaths(),
+			bootstrapper.ComponentKubeBinaries: kube_binaries.BinaryPaths(),
+		},
+		backupDir: filepath.Join(cfg.Agent.StateDir, backupDirName),
+	}, nil
+}
+
+// DetectDrift compares the installed component versions with the configured ones. Components that are
+// not installed or report no version are left to bootstrap and not reported as drift.
+func (u *Upgrader) DetectDrift(ctx context.Context) []Drift {
+	versions := u.versions(ctx)
+
+	var drifts []Drift
+	for _, component := range versionedComponents {
+		desired := component.desired(u.config)
+		installed := component.installed(versions)
+		if desired == "" || installed == "" || installed == "unknown" {
+			continue
+		}
+		if normalizeVersion(installed) != normalizeVersion(desired) {
+			drifts = append(drifts, Drift{Component: component.name, Installed: installed, Desired: desired})
+		}
+	}
+	return drifts
+}
+
+// Run upgrades every drifted component. The node is cordoned and drained first and uncordoned once it is
+// Ready on the new versions. If swapping binaries or becoming Ready fails, the previous binaries are
+// restored and the node is uncordoned only if it becomes Ready on them.
+func (u *Upgrader) Run(ctx context.Context, opts Options) (*Result, error) {
+	drifts := u.DetectDrift(ctx)
+	result := &Result{Drifts: drifts}
+	if len(drifts) == 0 {
+		u.logger.Info("All components match the configured versions, nothing to upgrade")
+		return result, nil
+	}
+	for _, drift := range drifts {
+		u.logger.Infof("%s is at version %s, configured version is %s", drift.Component, drift.Installed, drift.Desired)
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	if !opts.SkipDrain {
+		if err := u.cordonAndDrain(ctx, opts.DrainTimeout); err != nil {
+			return result, err
+		}
+	}
+
+	backups, err := u.backup(drifts, u.backupDir)
+	if err != nil {
+		u.uncordon(ctx, opts)
+		return result, err
+	}
+
+	upgradeErr := u.swap(ctx, drifts, result)
+	if upgradeErr == nil {
+		upgradeErr = u.restartAndWait(ctx, opts.ReadyTimeout)
+	}
+	if upgradeErr == nil {
+		if remaining := u.DetectDrift(ctx); len(remaining) > 0 {
+			upgradeErr = fmt.Errorf("%s still reports version %s after the upgrade", remaining[0].Component, remaining[0].Installed)
+		}
+	}
+
+	if upgradeErr != nil {
+		u.logger.Errorf("Upgrade failed, restoring previous binaries: %v", upgradeErr)
+		if err := u.rollback(ctx, backups, opts.ReadyTimeout); err != nil {
+			return result, fmt.Errorf("upgrade failed: %v; restoring previous binaries failed, node left cordoned: %w", upgradeErr, err)
+		}
+		result.RolledBack = true
+		u.uncordon(ctx, opts)
+		return result, fmt.Errorf("upgrade failed, previous binaries restored: %w", upgradeErr)
+	}
+
+	u.uncordon(ctx, opts)
+	if err := os.RemoveAll(u.backupDir); err != nil {
+		u.logger.Warnf("Failed to remove upgrade backup %s: %v", u.backupDir, err)
+	}
+	u.logger.Infof("Upgraded %s", strings.Join(result.Upgraded, ", "))
+	return result, nil
+}
+
+// cordonAndDrain stops new pods from landing on the node and evicts the existing ones
+func (u *Upgrader) cordonAndDrain(ctx context.Context, timeout time.Duration) error {
+	u.logger.Info("Cordoning node")
+	if err := u.node.Cordon(ctx); err != nil {
+		return fmt.Errorf("failed to cordon node: %w", err)
+	}
+
+	u.logger.Infof("Draining node (timeout %s)", timeout)
+	if err := u.node.Drain(ctx, timeout); err != nil {
+		if uncordonErr := u.node.Uncordon(ctx); uncordonErr != nil {
+			u.logger.Warnf("Failed to uncordon node after failed drain: %v", uncordonErr)
+		}
+		return fmt.Errorf("failed to drain node: %w", err)
+	}
+	return nil
+}
+
+// uncordon makes the node schedulable again unless draining was skipped
+func (u *Upgrader) uncordon(ctx context.Context, opts Options) {
+	if opts.SkipDrain {
+		return
+	}
+	u.logger.Info("Uncordoning node")
+	if err := u.node.Uncordon(ctx); err != nil {
+		u.logger.Errorf("Failed to uncordon node, run 'kubectl uncordon' manually: %v", err)
+	}
+}
+
+// swap reinstalls the drifted components at their configured versions
+func (u *Upgrader) swap(ctx context.Context, drifts []Drift, result *Result) error {
+	for _, drift := range drifts {
+		u.logger.Infof("Upgrading %s from %s to %s", drift.Component, drift.Installed, drift.Desired)
+		execution, err := u.installer.InstallComponent(ctx, drift.Component, false)
+		if err == nil && execution != nil && !execution.Success {
+			err = errors.New(execution.Error)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to install %s %s: %w", drift.Component, drift.Desired, err)
+		}
+		result.Upgraded = append(result.Upgraded, drift.Component)
+	}
+	return nil
+}
+
+// restartAndWait restarts the node services in dependency order and waits for the node to be Ready
+func (u *Upgrader) restartAndWait(ctx context.Context, timeout time.Duration) error {
+	for _, service := range restartOrder {
+		u.logger.Infof("Restarting %s", service)
+		if err := u.restart(service); err != nil {
+			return fmt.Errorf("failed to restart %s: %w", service, err)
+		}
+	}
+	return u.waitReady(ctx, timeout)
+}
+
+// waitReady polls the node's Ready condition until it is True or timeout passes
+func (u *Upgrader) waitReady(ctx context.Context, timeout time.Duration) error {
+	u.logger.Infof("Waiting up to %s for the node to become Ready", timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+	for {
+		ready, err := u.node.Ready(ctx)
+		if err != nil {
+			u.logger.Debugf("Failed to get node readiness: %v", err)
+		}
+		if ready {
+			u.logger.Info("Node is Ready")
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node did not become Ready within %s", timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// rollback restores the saved binaries, restarts the services and waits for the node to be Ready again
+func (u *Upgrader) rollback(ctx context.Context, backups []backupFile, timeout time.Duration) error {
+	if err := u.restore(backups); err != nil {
+		return err
+	}
+	return u.restartAndWait(ctx, timeout)
+}
+
+// normalizeVersion strips the optional v prefix so 1.32.7 and v1.32.7 compare equal
+func normalizeVersion(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(version), "v")
+}
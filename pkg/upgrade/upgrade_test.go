@@ -0,0 +1,160 @@
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/bootstrapper"
+	"go.goms.io/aks/AKSFlexNode/pkg/config"
+	"go.goms.io/aks/AKSFlexNode/pkg/status"
+)
+
+// fakeNode records node operations; the node is Ready while the kubelet binary has readyContent
+type fakeNode struct {
+	calls        []string
+	drainErr     error
+	kubelet      string
+	readyContent string
+}
+
+func (n *fakeNode) Cordon(context.Context) error { n.calls = append(n.calls, "cordon"); return nil }
+func (n *fakeNode) Uncordon(context.Context) error {
+	n.calls = append(n.calls, "uncordon")
+	return nil
+}
+func (n *fakeNode) Drain(context.Context, time.Duration) error {
+	n.calls = append(n.calls, "drain")
+	return n.drainErr
+}
+func (n *fakeNode) Ready(context.Context) (bool, error) {
+	data, err := os.ReadFile(n.kubelet)
+	return err == nil && string(data) == n.readyContent, err
+}
+
+// fakeInstaller writes the new kubelet binary and reports the configured version from then on
+type fakeInstaller struct {
+	kubelet   string
+	versions  *status.ComponentVersions
+	installed []string
+}
+
+func (f *fakeInstaller) InstallComponent(_ context.Context, name string, _ bool) (*bootstrapper.ExecutionResult, error) {
+	f.installed = append(f.installed, name)
+	if err := os.WriteFile(f.kubelet, []byte("new"), 0o755); err != nil {
+		return nil, err
+	}
+	f.versions.Kubelet = "1.32.7"
+	return &bootstrapper.ExecutionResult{Success: true}, nil
+}
+
+// newTestUpgrader returns an Upgrader for kubelet 1.31.5 installed in a temp dir and configured for 1.32.7
+func newTestUpgrader(t *testing.T) (*Upgrader, *fakeNode, *fakeInstaller) {
+	t.Helper()
+	dir := t.TempDir()
+	kubelet := filepath.Join(dir, "kubelet")
+	if err := os.WriteFile(kubelet, []byte("old"), 0o755); err != nil {
+		t.Fatalf("Failed to write kubelet: %v", err)
+	}
+
+	versions := &status.ComponentVersions{Kubelet: "1.31.5", Containerd: "1.7.20", Runc: "1.1.12"}
+	node := &fakeNode{kubelet: kubelet, readyContent: "new"}
+	installer := &fakeInstaller{kubelet: kubelet, versions: versions}
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+
+	cfg := &config.Config{
+		Kubernetes: config.KubernetesConfig{Version: "1.32.7"},
+		Containerd: config.ContainerdConfig{Version: "1.7.20"},
+		Runc:       config.RuntimeConfig{Version: "v1.1.12"},
+	}
+	u := &Upgrader{
+		config:    cfg,
+		logger:    logger,
+		installer: installer,
+		node:      node,
+		versions:  func(context.Context) status.ComponentVersions { return *versions },
+		restart:   func(string) error { return nil },
+		binaries:  map[string][]string{bootstrapper.ComponentKubeBinaries: {kubelet}},
+		backupDir: filepath.Join(dir, backupDirName),
+	}
+	return u, node, installer
+}
+
+// TestDetectDrift verifies version drift detection.
+// Test: Compares installed versions with configured versions, with and without v prefixes and unknown versions
+// Expected: Only components with a known, different version are reported
+func TestDetectDrift(t *testing.T) {
+	u, _, _ := newTestUpgrader(t)
+
+	drifts := u.DetectDrift(context.Background())
+	if len(drifts) != 1 || drifts[0] != (Drift{Component: bootstrapper.ComponentKubeBinaries, Installed: "1.31.5", Desired: "1.32.7"}) {
+		t.Errorf("Expected only kube-binaries drift, got %+v", drifts)
+	}
+
+	u.versions = func(context.Context) status.ComponentVersions {
+		return status.ComponentVersions{Kubelet: "unknown", Containerd: "1.7.20", Runc: "1.1.12"}
+	}
+	if drifts := u.DetectDrift(context.Background()); len(drifts) != 0 {
+		t.Errorf("Expected no drift for an unknown version, got %+v", drifts)
+	}
+}
+
+// TestRun verifies the upgrade sequence and its rollback.
+// Test: Upgrades kubelet where the node becomes Ready on the new binary, where it never becomes Ready,
+// where draining fails, and as a dry run
+// Expected: A healthy upgrade keeps the new binary and uncordons; an unhealthy one restores the old binary
+// and uncordons once Ready again; a failed drain uncordons without installing; a dry run changes nothing
+func TestRun(t *testing.T) {
+	readyPollInterval = 10 * time.Millisecond
+
+	tests := []struct {
+		name           string
+		opts           Options
+		readyContent   string
+		drainErr       error
+		wantErr        bool
+		wantRolledBack bool
+		wantKubelet    string
+		wantCalls      string
+		wantInstalled  int
+	}{
+		{name: "healthy upgrade", readyContent: "new", wantKubelet: "new", wantCalls: "cordon,drain,uncordon", wantInstalled: 1},
+		{name: "unhealthy upgrade is rolled back", readyContent: "old", wantErr: true, wantRolledBack: true, wantKubelet: "old", wantCalls: "cordon,drain,uncordon", wantInstalled: 1},
+		{name: "failed drain", drainErr: errors.New("pdb"), wantErr: true, wantKubelet: "old", wantCalls: "cordon,drain,uncordon"},
+		{name: "dry run", opts: Options{DryRun: true}, wantKubelet: "old"},
+		{name: "skip drain", opts: Options{SkipDrain: true}, readyContent: "new", wantKubelet: "new", wantInstalled: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, node, installer := newTestUpgrader(t)
+			node.readyContent = tt.readyContent
+			node.drainErr = tt.drainErr
+			tt.opts.ReadyTimeout = 100 * time.Millisecond
+
+			result, err := u.Run(context.Background(), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%v, got %v", tt.wantErr, err)
+			}
+			if len(result.Drifts) != 1 || result.RolledBack != tt.wantRolledBack {
+				t.Errorf("Unexpected result %+v", result)
+			}
+			if data, _ := os.ReadFile(node.kubelet); string(data) != tt.wantKubelet {
+				t.Errorf("Expected kubelet %q, got %q", tt.wantKubelet, data)
+			}
+			if calls := strings.Join(node.calls, ","); calls != tt.wantCalls {
+				t.Errorf("Expected node calls %q, got %q", tt.wantCalls, calls)
+			}
+			if len(installer.installed) != tt.wantInstalled {
+				t.Errorf("Expected %d installs, got %v", tt.wantInstalled, installer.installed)
+			}
+		})
+	}
+}
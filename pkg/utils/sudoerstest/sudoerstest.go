@@ -0,0 +1,106 @@
+// Package sudoerstest checks commands run with sudo against the sudoers rules shipped with the agent,
+// so that tests catch commands the aks-flex-node service user is not allowed to run.
+package sudoerstest
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+const (
+	// sudoersFile is the sudoers file at the repository root that scripts/install.sh installs
+	sudoersFile = "aks-flex-node-sudoers"
+	// rulePrefix starts every rule granted to the service user
+	rulePrefix = "aks-flex-node ALL=(root) NOPASSWD:SETENV: "
+)
+
+// securePath lists the directories sudo searches for commands given without a path
+var securePath = []string{"/usr/local/sbin", "/usr/local/bin", "/usr/sbin", "/usr/bin", "/sbin", "/bin"}
+
+// Rules returns the commands the shipped sudoers file allows, one entry per comma separated command
+func Rules(t testing.TB) []string {
+	t.Helper()
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	for !fileExists(filepath.Join(dir, "go.mod")) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			t.Fatal("Failed to find the repository root")
+		}
+		dir = parent
+	}
+
+	file, err := os.Open(filepath.Join(dir, sudoersFile))
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", sudoersFile, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var rules []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		commands, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), rulePrefix)
+		if !ok {
+			continue
+		}
+		for _, command := range strings.Split(commands, ", ") {
+			rules = append(rules, strings.TrimSpace(command))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("Failed to read %s: %v", sudoersFile, err)
+	}
+	return rules
+}
+
+// Allows reports whether one of rules matches name run with args. A name without a path matches
+// if any directory sudo searches would make it match. As in sudoers, * and ? match any characters,
+// including spaces and slashes.
+func Allows(rules []string, name string, args ...string) bool {
+	paths := []string{name}
+	if !filepath.IsAbs(name) {
+		paths = paths[:0]
+		for _, dir := range securePath {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+
+	for _, rule := range rules {
+		pattern := rulePattern(rule)
+		for _, path := range paths {
+			if pattern.MatchString(strings.Join(append([]string{path}, args...), " ")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rulePattern converts a sudoers command with wildcards to an anchored regular expression
+func rulePattern(rule string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for _, r := range rule {
+		switch r {
+		case '*':
+			pattern.WriteString(".*")
+		case '?':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String())
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -11,16 +11,22 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 )
 
+// commandStopDelay is how long a command stopped by its context may take to exit before it is killed
+const commandStopDelay = 10 * time.Second
+
 // sudoCommandLists holds the command lists for sudo determination
 var (
-	alwaysNeedsSudo = []string{"apt", "apt-get", "dpkg", "systemctl", "mount", "umount", "modprobe", "sysctl", "azcmagent", "usermod", "kubectl"}
+	alwaysNeedsSudo = []string{"apt", "apt-get", "dpkg", "systemctl", "mount", "umount", "modprobe", "sysctl", "azcmagent", "usermod", "kubectl", "systemd-run"}
 	conditionalSudo = []string{"mkdir", "cp", "chmod", "chown", "mv", "tar", "rm", "bash", "install", "ln", "cat"}
 	systemPaths     = []string{"/etc/", "/usr/", "/var/", "/opt/", "/boot/", "/sys/"}
 )
@@ -54,12 +60,17 @@ func requiresSudoAccess(name string, args []string) bool {
 
 // createCommand creates an exec.Cmd with appropriate sudo handling
 func createCommand(name string, args []string) *exec.Cmd {
+	name, args = sudoCommand(name, args)
+	return exec.Command(name, args...)
+}
+
+// sudoCommand returns the command actually run for name and args: prefixed with sudo when needed
+func sudoCommand(name string, args []string) (string, []string) {
 	if requiresSudoAccess(name, args) && os.Geteuid() != 0 {
-		allArgs := append([]string{"-E", name}, args...)
-		return exec.Command("sudo", allArgs...)
+		return "sudo", append([]string{"-E", name}, args...)
 	}
 	// Run directly (either doesn't need sudo or already running as root)
-	return exec.Command(name, args...)
+	return name, args
 }
 
 // RunSystemCommand executes a system command with sudo when needed for privileged operations
@@ -77,6 +88,19 @@ func RunCommandWithOutput(name string, args ...string) (string, error) {
 	return string(output), err
 }
 
+// RunCommandWithOutputContext is RunCommandWithOutput for a command that is stopped when ctx is done.
+// The command is sent SIGTERM, which sudo relays, and killed if it has not exited shortly after.
+func RunCommandWithOutputContext(ctx context.Context, name string, args ...string) (string, error) {
+	name, args = sudoCommand(name, args)
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = commandStopDelay
+	output, err := cmd.CombinedOutput()
+	return string(output), err
+}
+
 // FileExists checks if a file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
@@ -323,17 +347,18 @@ func DownloadFile(url, destination string) error {
 		Timeout: 10 * time.Minute,
 	}
 
-	// Make request
+	// Make request; connection failures are worth retrying
 	resp, err := client.Get(url)
 	if err != nil {
-		return fmt.Errorf("failed to download from %s: %w", url, err)
+		return retry.Transient(fmt.Errorf("failed to download from %s: %w", url, err))
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status %d for %s", resp.StatusCode, url)
+		return retry.WithClass(retry.ClassForStatus(resp.StatusCode),
+			fmt.Errorf("download failed with status %d for %s", resp.StatusCode, url))
 	}
 
 	// Create destination file
@@ -346,9 +371,10 @@ func DownloadFile(url, destination string) error {
 	}()
 
 	// Copy response body to file
+	// A failed copy is usually a connection dropped mid-transfer
 	_, err = io.Copy(out, resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to write file %s: %w", destination, err)
+		return retry.Transient(fmt.Errorf("failed to write file %s: %w", destination, err))
 	}
 
 	return nil
@@ -1,9 +1,15 @@
 package utils
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"go.goms.io/aks/AKSFlexNode/pkg/retry"
 )
 
 // TestFileExists verifies the FileExists utility function for checking file existence.
@@ -418,3 +424,61 @@ func TestCleanupTempFile(t *testing.T) {
 	// Cleanup non-existent file should not panic
 	CleanupTempFile("/non/existent/file")
 }
+
+// TestDownloadFile_ClassifiesFailures verifies that download failures carry a retry class.
+// Test: Downloads from a test server returning success, throttling, server and not-found responses
+// Expected: Success writes the file; 429/5xx are transient and 404 is permanent
+func TestDownloadFile_ClassifiesFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantErr   bool
+		wantClass retry.Class
+	}{
+		{"success", http.StatusOK, false, ""},
+		{"throttled", http.StatusTooManyRequests, true, retry.ClassTransient},
+		{"server error", http.StatusServiceUnavailable, true, retry.ClassTransient},
+		{"not found", http.StatusNotFound, true, retry.ClassPermanent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte("payload"))
+			}))
+			defer server.Close()
+
+			destination := filepath.Join(t.TempDir(), "download")
+			err := DownloadFile(server.URL, destination)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if class := retry.Classify(err); class != tt.wantClass {
+					t.Errorf("Classify() = %s, want %s", class, tt.wantClass)
+				}
+				return
+			}
+			if data, _ := os.ReadFile(destination); string(data) != "payload" {
+				t.Errorf("Expected downloaded content 'payload', got %q", string(data))
+			}
+		})
+	}
+}
+
+// TestRunCommandWithOutputContext verifies that a command is stopped when its context is done.
+// Test: Runs a long sleep under a context that times out quickly
+// Expected: The call returns an error well before the sleep would have finished
+func TestRunCommandWithOutputContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := RunCommandWithOutputContext(ctx, "sleep", "30"); err == nil {
+		t.Fatal("Expected an error for a stopped command")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to stop with its context, took %s", elapsed)
+	}
+}